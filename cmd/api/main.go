@@ -4,12 +4,21 @@
 // @host localhost:8080
 // @BasePath /api/v1
 // @schemes http
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin token as "Bearer <ADMIN_TOKEN>"
+// @securityDefinitions.apikey APIKey
+// @in header
+// @name X-API-Key
+// @description Internal service key, one of API_KEYS
 package main
 
 import (
 	"context"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -17,92 +26,253 @@ import (
 	"time"
 
 	"user-crud/internal/application/command"
+	"user-crud/internal/application/event"
 	"user-crud/internal/application/query"
 	"user-crud/internal/config"
+	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/http/handler"
 	"user-crud/internal/infrastructure/http/router"
 	"user-crud/internal/infrastructure/persistence"
+	"user-crud/internal/infrastructure/retry"
 	"user-crud/internal/infrastructure/tracing"
+	"user-crud/internal/infrastructure/webhook"
 
-	_ "user-crud/docs"
+	"user-crud/migrations"
 
-	"github.com/jackc/pgx/v5/pgxpool"
+	_ "user-crud/docs"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
 
-	// Initialize Jaeger tracing
-	jaegerEndpoint := getEnv("JAEGER_ENDPOINT", "http://jaeger:14268/api/traces")
-	shutdown, err := tracing.InitTracer("user-crud-service", jaegerEndpoint)
-	if err != nil {
-		log.Printf("Warning: Failed to initialize tracer: %v", err)
+	// Configure password policy
+	if err := configurePasswordPolicy(cfg); err != nil {
+		log.Fatalf("Failed to configure password policy: %v", err)
+	}
+	domain.SetAgePolicy(domain.AgePolicy{Min: cfg.MinAge, Max: cfg.MaxAge})
+	domain.SetBlockedEmailDomains(cfg.BlockedEmailDomains)
+
+	// Summarize failed exports periodically instead of logging each one, so an
+	// unreachable collector doesn't flood the log
+	stopErrorReporter := tracing.ReportExportErrors(cfg.TelemetryErrorLogInterval)
+
+	// Initialize OTLP tracing (JAEGER_ENDPOINT kept as a fallback)
+	var shutdownTracer func(context.Context) error
+	var err error
+	if cfg.TracingEnabled {
+		traceEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", getEnv("JAEGER_ENDPOINT", "http://jaeger:4318"))
+		shutdownTracer, err = tracing.InitTracer("user-crud-service", traceEndpoint, cfg.TracingQueueSize)
+		if err != nil {
+			log.Printf("Warning: Failed to initialize tracer, requests will not be traced: %v", err)
+			shutdownTracer = nil
+		} else {
+			log.Println("OTLP tracing initialized successfully")
+		}
 	} else {
-		defer shutdown(context.Background())
-		log.Println("Jaeger tracing initialized successfully")
+		log.Println("Tracing and metrics export disabled (TRACING_ENABLED=false)")
+	}
+
+	// Initialize OTLP metrics (optional); TRACING_ENABLED=false turns these off as well
+	var shutdownMeter func(context.Context) error
+	if otlpEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); cfg.TracingEnabled && otlpEndpoint != "" {
+		shutdownMeter, err = tracing.InitMeter("user-crud-service", otlpEndpoint)
+		if err != nil {
+			log.Printf("Warning: Failed to initialize metrics: %v", err)
+			shutdownMeter = nil
+		} else {
+			log.Println("OTLP metrics initialized successfully")
+		}
 	}
 
+	// Bound the time spent waiting for dependencies at startup
+	startupCtx, cancelStartup := context.WithTimeout(context.Background(), cfg.StartupTimeout)
+	defer cancelStartup()
+
 	// Initialize database connection
-	dbpool, err := initDatabase(cfg)
+	dbpool, err := persistence.NewPool(startupCtx, cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer dbpool.Close()
+
+	// Queries read from the replica when one is configured; only the primary is migrated
+	readPool := dbpool
+	if replicaCfg := cfg.ReplicaConfig(); replicaCfg != nil {
+		readPool, err = persistence.NewPool(startupCtx, replicaCfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize database replica: %v", err)
+		}
+		log.Printf("Routing reads to replica %s:%s", replicaCfg.DBHost, replicaCfg.DBPort)
+	}
+
+	if err := tracing.RegisterDBPoolMetrics(func() tracing.DBPoolStats { return persistence.PoolStats(dbpool) }); err != nil {
+		log.Printf("Warning: Failed to register database pool metrics: %v", err)
+	}
 
 	// Run migrations
-	if err := runMigrations(dbpool); err != nil {
+	log.Println("Running database migrations...")
+	if err := persistence.Migrate(startupCtx, dbpool, migrations.FS); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
+	log.Println("Migrations completed successfully")
 
 	// Initialize Redis cache
 	redisHost := getEnv("REDIS_HOST", "localhost")
 	redisPort := getEnv("REDIS_PORT", "6379")
-	redisCache, err := cache.NewRedisCache(redisHost, redisPort, 5*time.Minute)
+	// Cache errors go to their own logger so their level can be tuned independently
+	cacheLogger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: cfg.CacheLogLevel})).
+		With("component", "cache")
+
+	redisOpts := cache.RedisOptions{
+		PoolSize:     cfg.RedisPoolSize,
+		DialTimeout:  cfg.RedisDialTimeout,
+		ReadTimeout:  cfg.RedisReadTimeout,
+		WriteTimeout: cfg.RedisWriteTimeout,
+		MaxRetries:   cfg.RedisMaxRetries,
+		OpTimeout:    cfg.CacheOpTimeout,
+		Logger:       cacheLogger,
+	}
+	log.Printf("Redis pool: size=%d dial_timeout=%v read_timeout=%v write_timeout=%v max_retries=%d op_timeout=%v",
+		redisOpts.PoolSize, redisOpts.DialTimeout, redisOpts.ReadTimeout, redisOpts.WriteTimeout, redisOpts.MaxRetries, redisOpts.OpTimeout)
+	var redisCache *cache.RedisCache
+	err = retry.Do(startupCtx, 5, time.Second, func(ctx context.Context) error {
+		redisCache, err = cache.NewRedisCache(redisHost, redisPort, 5*time.Minute, redisOpts)
+		if err != nil {
+			log.Printf("Redis not ready: %v", err)
+		}
+		return err
+	})
 	if err != nil {
 		log.Fatalf("Failed to initialize Redis: %v", err)
 	}
-	defer redisCache.Close()
 	log.Println("Successfully connected to Redis")
+	cancelStartup()
 
-	// Initialize repository
+	// Initialize background cache workers
+	cacheWorkers := cache.NewWorkerPool(4, 256, cacheLogger)
+
+	// Initialize domain event publisher; commands write events to the outbox
+	// and the relay publishes them (or just marks them sent when disabled)
+	var publishers event.MultiPublisher
+	if cfg.EventsEnabled {
+		publishers = append(publishers, cache.NewRedisEventPublisher(redisCache, cfg.EventsChannel))
+		log.Printf("Publishing user events to Redis channel %q", cfg.EventsChannel)
+	}
+	var webhookPublisher *webhook.Publisher
+	if cfg.WebhookURL != "" {
+		webhookPublisher = webhook.NewPublisher(webhook.Config{
+			URL:         cfg.WebhookURL,
+			Secret:      cfg.WebhookSecret,
+			Timeout:     cfg.WebhookTimeout,
+			MaxAttempts: cfg.WebhookMaxAttempts,
+			QueueSize:   cfg.WebhookQueueSize,
+		})
+		publishers = append(publishers, webhookPublisher)
+		log.Printf("Posting user events to webhook (signed: %t)", cfg.WebhookSecret != "")
+	}
+	var events event.Publisher = event.NoopPublisher{}
+	if len(publishers) > 0 {
+		events = publishers
+	}
+	outboxRelay := persistence.NewOutboxRelay(dbpool, events, cfg.OutboxPollInterval, cfg.OutboxRetention)
+	outboxRelay.Start()
+
+	// Initialize repositories. Commands read before they write (existence, uniqueness,
+	// the current row), so they stay on the primary rather than risk replica lag;
+	// queries read from the replica when there is one. Lookups that fill the per-user
+	// cache also use the primary: a lagging replica would write a pre-update row back
+	// into Redis right after the command invalidated it, and it would stay there for
+	// the whole cache TTL.
 	userRepo := persistence.NewPostgresUserRepository(dbpool)
+	readRepo := persistence.NewPostgresUserRepositoryRW(dbpool, readPool)
+
+	// Warm the user cache in the background so it doesn't delay readiness
+	warmCtx, cancelWarm := context.WithCancel(context.Background())
+	defer cancelWarm()
+	if cfg.CacheWarmOnStart {
+		go func() {
+			if _, err := redisCache.WarmUsers(warmCtx, userRepo, cfg.CacheWarmIDs, cfg.CacheWarmLimit); err != nil {
+				log.Printf("Warning: Cache warming stopped early: %v", err)
+			}
+		}()
+	}
 
 	// Initialize command handlers (WITH CACHE)
 	createUserHandler := command.NewCreateUserHandler(userRepo, redisCache)
-	updateUserHandler := command.NewUpdateUserHandler(userRepo, redisCache)
-	deleteUserHandler := command.NewDeleteUserHandler(userRepo, redisCache)
-	changePasswordHandler := command.NewChangePasswordHandler(userRepo, redisCache)
+	validateUserHandler := command.NewValidateUserHandler(userRepo, cfg.HideUserEnumeration)
+	updateUserHandler := command.NewUpdateUserHandler(userRepo, redisCache, cacheWorkers)
+	deleteUserHandler := command.NewDeleteUserHandler(userRepo, redisCache, cacheWorkers)
+	batchDeleteHandler := command.NewBatchDeleteUsersHandler(userRepo, redisCache, cacheWorkers)
+	batchUpdateHandler := command.NewBatchUpdateUsersHandler(userRepo, redisCache, cacheWorkers)
+	mergeUsersHandler := command.NewMergeUsersHandler(userRepo, redisCache, cacheWorkers)
+	changePasswordHandler := command.NewChangePasswordHandler(userRepo, redisCache, cacheWorkers, cfg.PasswordReuseLimit)
+	suspendUserHandler := command.NewSuspendUserHandler(userRepo, redisCache, cacheWorkers)
+	activateUserHandler := command.NewActivateUserHandler(userRepo, redisCache, cacheWorkers)
+	forgotPasswordHandler := command.NewForgotPasswordHandler(userRepo, redisCache, cfg.PasswordResetTokenTTL)
+	resetPasswordHandler := command.NewResetPasswordHandler(userRepo, redisCache, cacheWorkers, cfg.PasswordReuseLimit)
+	loginHandler := command.NewLoginHandler(userRepo, redisCache, cacheWorkers, cfg.HideUserEnumeration)
 
 	// Initialize query handlers (WITH CACHE)
-	getUserHandler := query.NewGetUserHandler(userRepo, redisCache)
-	listUsersHandler := query.NewListUsersHandler(userRepo)
-	searchUsersHandler := query.NewSearchUsersHandler(userRepo)
+	getUserHandler := query.NewGetUserHandler(userRepo, redisCache, cacheWorkers, cfg.CacheSingleflight, cacheLogger)
+	userExistsHandler := query.NewUserExistsHandler(readRepo, redisCache, cacheLogger)
+	getByUsernameHandler := query.NewGetUserByUsernameHandler(readRepo)
+	getUsersByIDsHandler := query.NewGetUsersByIDsHandler(userRepo, redisCache, cacheWorkers, cacheLogger)
+	getActivityHandler := query.NewGetUserActivityHandler(readRepo)
+	userStatsHandler := query.NewUserStatsHandler(readRepo, redisCache, cacheWorkers, cfg.UserStatsCacheTTL, cacheLogger)
+	recentUsersHandler := query.NewRecentUsersHandler(readRepo, redisCache, cacheWorkers, cfg.RecentUsersCacheTTL, cacheLogger)
+	pagination := query.Pagination{DefaultLimit: cfg.PaginationDefaultLimit, MaxLimit: cfg.PaginationMaxLimit}
+	passwordHistoryHandler := query.NewGetPasswordHistoryHandler(readRepo, pagination)
+	requestEmailChangeHandler := command.NewRequestEmailChangeHandler(userRepo, redisCache, cfg.EmailChangeTokenTTL)
+	confirmEmailChangeHandler := command.NewConfirmEmailChangeHandler(userRepo, redisCache, cacheWorkers)
+	// List and search share one concurrency cap so they can't starve point lookups of connections
+	listQueryLimiter := query.NewQueryLimiter(cfg.ListQueryConcurrency, cfg.ListQueryWait)
+	listUsersHandler := query.NewListUsersHandler(readRepo, redisCache, cacheWorkers, pagination, cfg.SortDefaultOrders, listQueryLimiter, cfg.ListCacheTTL, cacheLogger)
+	searchUsersHandler := query.NewSearchUsersHandler(readRepo, cfg.FuzzySearchThreshold, query.KeywordLength{Min: cfg.SearchKeywordMinLength, Max: cfg.SearchKeywordMaxLength}, pagination, listQueryLimiter)
 
 	// Initialize HTTP handler
 	h := handler.NewHandler(
 		createUserHandler,
+		validateUserHandler,
 		updateUserHandler,
 		deleteUserHandler,
+		batchDeleteHandler,
+		batchUpdateHandler,
+		mergeUsersHandler,
 		changePasswordHandler,
+		suspendUserHandler,
+		activateUserHandler,
+		forgotPasswordHandler,
+		resetPasswordHandler,
+		loginHandler,
 		getUserHandler,
+		userExistsHandler,
+		getByUsernameHandler,
+		getUsersByIDsHandler,
+		getActivityHandler,
+		userStatsHandler,
+		recentUsersHandler,
+		passwordHistoryHandler,
+		requestEmailChangeHandler,
+		confirmEmailChangeHandler,
 		listUsersHandler,
 		searchUsersHandler,
 		dbpool,
 		redisCache,
+		cfg.HideUserEnumeration,
 	)
 
 	// Setup router
-	r := router.SetupRouter(h)
+	r := router.SetupRouter(h, cfg, redisCache)
 
 	// Create HTTP server
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", cfg.ServerPort),
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              fmt.Sprintf(":%s", cfg.ServerPort),
+		Handler:           r,
+		ReadTimeout:       cfg.ServerReadTimeout,
+		ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+		WriteTimeout:      cfg.ServerWriteTimeout,
+		IdleTimeout:       cfg.ServerIdleTimeout,
 	}
 
 	// Start server in goroutine
@@ -117,82 +287,99 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	cancelWarm()
 
-	log.Println("Shutting down server...")
+	log.Printf("Shutting down server (timeout %v)...", cfg.ShutdownTimeout)
+	shutdownStart := time.Now()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Every step below shares one budget, so a slow step leaves less time for the rest
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+		log.Printf("Server forced to shutdown: %v", err)
 	}
 
-	log.Println("Server exited gracefully")
-}
+	// Stop relaying events before the Redis client is closed; unsent rows stay in the outbox
+	if err := outboxRelay.Stop(ctx); err != nil {
+		log.Printf("Outbox relay did not stop before shutdown: %v", err)
+	}
 
-func initDatabase(cfg *config.Config) (*pgxpool.Pool, error) {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.DBUser,
-		cfg.DBPassword,
-		cfg.DBHost,
-		cfg.DBPort,
-		cfg.DBName,
-	)
+	// Deliver events already handed to the webhook; the relay has stopped adding more
+	if webhookPublisher != nil {
+		if err := webhookPublisher.Stop(ctx); err != nil {
+			log.Printf("Webhook deliveries did not finish before shutdown: %v", err)
+		}
+	}
 
-	config, err := pgxpool.ParseConfig(dsn)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse database config: %w", err)
+	// Drain pending cache writes before the Redis client is closed
+	if err := cacheWorkers.Shutdown(ctx); err != nil {
+		log.Printf("Cache workers did not finish before shutdown: %v", err)
 	}
 
-	config.MaxConns = 10
-	config.MinConns = 2
+	if err := redisCache.Close(); err != nil {
+		log.Printf("Failed to close Redis client: %v", err)
+	}
 
-	var dbpool *pgxpool.Pool
-	maxRetries := 5
-	for i := 0; i < maxRetries; i++ {
-		dbpool, err = pgxpool.NewWithConfig(context.Background(), config)
-		if err == nil {
-			if err = dbpool.Ping(context.Background()); err == nil {
-				log.Println("Successfully connected to database")
-				return dbpool, nil
-			}
+	// pgxpool.Close waits for acquired connections, so don't let it outlive the budget
+	poolClosed := make(chan struct{})
+	go func() {
+		if readPool != dbpool {
+			readPool.Close()
 		}
+		dbpool.Close()
+		close(poolClosed)
+	}()
+	select {
+	case <-poolClosed:
+	case <-ctx.Done():
+		log.Printf("Database pool did not close before shutdown timeout")
+	}
 
-		waitTime := time.Duration(i+1) * 2 * time.Second
-		log.Printf("Failed to connect to database, retrying in %v... (attempt %d/%d)", waitTime, i+1, maxRetries)
-		time.Sleep(waitTime)
+	// Flush telemetry last so the shutdown itself is recorded
+	if shutdownMeter != nil {
+		if err := shutdownMeter(ctx); err != nil {
+			log.Printf("Failed to flush metrics: %v", err)
+		}
 	}
+	if shutdownTracer != nil {
+		if err := shutdownTracer(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}
+	stopErrorReporter()
 
-	return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, err)
+	log.Printf("Server exited gracefully in %v", time.Since(shutdownStart).Round(time.Millisecond))
 }
 
-func runMigrations(dbpool *pgxpool.Pool) error {
-	log.Println("Running database migrations...")
+func configurePasswordPolicy(cfg *config.Config) error {
+	policy := domain.PasswordPolicy{
+		MinLength:       cfg.PasswordMinLength,
+		RequireUpper:    cfg.PasswordRequireUpper,
+		RequireLower:    cfg.PasswordRequireLower,
+		RequireDigit:    cfg.PasswordRequireDigit,
+		RequireSymbol:   cfg.PasswordRequireSymbol,
+		DenylistEnabled: cfg.PasswordDenylistEnabled,
+		Denylist:        domain.DefaultPasswordPolicy().Denylist,
+	}
 
-	migration := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) NOT NULL UNIQUE,
-		password_hash VARCHAR(255) NOT NULL,
-		age INTEGER NOT NULL,
-		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
-		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
-	CREATE INDEX IF NOT EXISTS idx_users_name ON users(name);
-	CREATE INDEX IF NOT EXISTS idx_users_age ON users(age);
-	CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
-	`
-
-	_, err := dbpool.Exec(context.Background(), migration)
-	if err != nil {
-		return fmt.Errorf("failed to run migrations: %w", err)
+	if cfg.PasswordDenylistEnabled && cfg.PasswordDenylistFile != "" {
+		denylist, err := domain.LoadPasswordDenylist(cfg.PasswordDenylistFile)
+		if err != nil {
+			return err
+		}
+		policy.Denylist = denylist
+		log.Printf("Loaded %d passwords into denylist from %s", len(denylist), cfg.PasswordDenylistFile)
 	}
 
-	log.Println("Migrations completed successfully")
+	domain.SetPasswordPolicy(policy)
+
+	hasher, err := domain.NewPasswordHasher(cfg.PasswordHasher)
+	if err != nil {
+		return err
+	}
+	domain.SetPasswordHasher(hasher)
+	log.Printf("Hashing new passwords with %s", hasher.Scheme())
 	return nil
 }
 
@@ -202,4 +389,4 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
@@ -15,128 +15,231 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
-            "get": {
-                "description": "Check if the service is healthy",
+        "/admin/cache": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Delete every cached user, list page, recent users list and stats entry. Reset and email change tokens, idempotency records and the maintenance flag are kept.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
                 ],
-                "summary": "Health check",
+                "summary": "Flush the cache",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Number of keys deleted",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "503": {
-                        "description": "Service Unavailable",
+                    "401": {
+                        "description": "Invalid admin token",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/metrics": {
+        "/admin/cache/users/{id}": {
             "get": {
-                "description": "Get application metrics",
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Report whether a user is cached in Redis and how many seconds the entry has left",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "metrics"
+                    "admin"
+                ],
+                "summary": "Inspect a user's cache entry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get metrics",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Cache entry",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
                     }
                 }
-            }
-        },
-        "/users": {
-            "get": {
-                "description": "Get paginated list of users with optional filters",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Delete a user's Redis cache entry so the next read loads it from the database",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "List users with filters",
+                "summary": "Evict a user from the cache",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Search by name or email",
-                        "name": "search",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "description": "Minimum age",
-                        "name": "age_min",
-                        "in": "query"
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of keys deleted (0 if the user was not cached)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     },
-                    {
-                        "type": "integer",
-                        "description": "Maximum age",
-                        "name": "age_max",
-                        "in": "query"
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Sort field (id, name, email, age, created_at)",
-                        "name": "sort",
-                        "in": "query"
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Sort order (asc, desc)",
-                        "name": "order",
-                        "in": "query"
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
                     },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/maintenance": {
+            "get": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
+                        "AdminToken": []
                     },
                     {
-                        "type": "integer",
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "APIKey": []
                     }
                 ],
+                "description": "Report whether maintenance mode is toggled on at runtime (MAINTENANCE_MODE forces it on regardless)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get maintenance mode",
                 "responses": {
                     "200": {
-                        "description": "Users list",
+                        "description": "Maintenance flag",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create a new user with name, email, password, and age",
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Turn maintenance mode on or off at runtime; while on, writes return 503 and reads still work",
                 "consumes": [
                     "application/json"
                 ],
@@ -144,23 +247,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Create a new user",
+                "summary": "Toggle maintenance mode",
                 "parameters": [
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "Maintenance flag",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/command.CreateUserCommand"
+                            "$ref": "#/definitions/handler.SetMaintenanceRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "User created successfully",
+                    "200": {
+                        "description": "Maintenance flag updated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -169,93 +272,1547 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid input",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "User already exists",
+                    "401": {
+                        "description": "Invalid admin token",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/users/search": {
-            "get": {
-                "description": "Search users by keyword",
+        "/admin/users/merge": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Soft-delete the duplicate account and record that it was merged into the primary, in one transaction. Repeating a completed merge returns the primary unchanged.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Search users",
+                "summary": "Merge a duplicate user into another",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Search keyword",
-                        "name": "q",
-                        "in": "query",
-                        "required": true
+                        "description": "Surviving and duplicate user IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.MergeUsersCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Surviving user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid ids or both ids are the same user",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
                     },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Primary or duplicate user not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/activate": {
+            "post": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
+                        "AdminToken": []
                     },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Re-enable a suspended account",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Activate user",
+                "parameters": [
                     {
                         "type": "integer",
-                        "description": "Items per page",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Search results",
+                        "description": "User activated",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid input",
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User is not suspended",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/{id}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Disable an active account without deleting it; suspended users cannot log in",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Suspend user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User suspended",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User is not active",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/forgot-password": {
+            "post": {
+                "description": "Issue a password reset token for the given email. Always returns 200 to avoid user enumeration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.ForgotPasswordCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset requested",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Verify a user's email and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.LoginCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Login successful",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found (only when HIDE_USER_ENUMERATION is disabled)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password-policy": {
+            "get": {
+                "description": "Password rules enforced on registration, password changes and resets, so clients can show them up front",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get the password policy",
+                "responses": {
+                    "200": {
+                        "description": "Active password policy",
+                        "schema": {
+                            "$ref": "#/definitions/domain.PasswordRequirements"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/reset-password": {
+            "post": {
+                "description": "Set a new password using a password reset token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.ResetPasswordCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password reset",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or token, or recently used password",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the service is healthy",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Get application metrics",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Get metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Check that the database is reachable and migrations have created the expected schema",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "Ready to serve traffic",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "503": {
+                        "description": "Database unreachable or migrations still running",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "description": "Get paginated list of users with optional filters",
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List users with filters",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search by name or email",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum age",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated exact ages (at most 50), ANDed with age_min and age_max",
+                        "name": "ages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field (id, name, email, age, created_at, updated_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order (asc, desc); defaults per field from SORT_DEFAULT_ORDERS (created_at and updated_at: desc, others: asc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (must not be negative)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (defaults to PAGINATION_DEFAULT_LIMIT, clamped to PAGINATION_MAX_LIMIT)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated user ids. On its own (max 100) returns those users in order with a not_found list; with search, age or inactive_since filters (max 1000) it is ANDed with them and paginated",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users who have not logged in since this date (2006-01-02) or RFC 3339 time",
+                        "name": "inactive_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return (id, name, username, email, age, avatar_url, status, last_login_at, created_at, updated_at)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Set to false to skip counting matches: total and total_pages are -1 and has_more tells whether a next page exists (default true)",
+                        "name": "with_total",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also list suspended users, which are excluded by default (default false)",
+                        "name": "include_suspended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "page (default) for page numbers and totals, or cursor for {data, next_cursor} without a count query",
+                        "name": "paginate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor from the previous response, with paginate=cursor and the same sort and order",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/vnd.api+json for a JSON:API document instead of the default envelope",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users list (pagination also in X-Total-Count, X-Page, X-Limit, X-Total-Pages and Link headers)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Unknown field, invalid ages, inactive_since, with_total, include_suspended, paginate or cursor, or negative page",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Too many concurrent list and search queries (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new user with name, email, password, and age",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.CreateUserCommand"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client-generated key; retries with the same key replay the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "User created successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "202": {
+                        "description": "Registration accepted (when HIDE_USER_ENUMERATION is enabled)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User or username already exists, or a request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Delete several users by ID in a single transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete multiple users",
+                "parameters": [
+                    {
+                        "description": "User IDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.BatchDeleteUsersCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted and not found IDs",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key (only when API_KEYS is set)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Pagination headers of the matching GET /users request without the body; only the count query runs",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Count users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search by name or email",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum age",
+                        "name": "age_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum age",
+                        "name": "age_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated exact ages (at most 50), ANDed with age_min and age_max",
+                        "name": "ages",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated user ids (at most 1000)",
+                        "name": "ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users who have not logged in since this date (2006-01-02) or RFC 3339 time",
+                        "name": "inactive_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also count suspended users (default false)",
+                        "name": "include_suspended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number used for the Link header",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page used for X-Total-Pages and the Link header",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Counts in X-Total-Count, X-Total-Pages, X-Page, X-Limit and Link headers"
+                    },
+                    "400": {
+                        "description": "Invalid filter"
+                    },
+                    "503": {
+                        "description": "Too many concurrent list and search queries (see Retry-After)"
+                    }
+                }
+            }
+        },
+        "/users/batch": {
+            "patch": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Apply the same partial patch (name, age, avatar_url, status) to several users in a single transaction. Each user is validated after patching. In all_or_nothing mode (default) any missing, invalid or failed user rolls the whole batch back; in best_effort mode each user is written in its own savepoint and the users that succeed are saved.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update multiple users",
+                "parameters": [
+                    {
+                        "description": "User IDs and patch",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.BatchUpdateUsersCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether the batch was committed, and the outcome per id: updated, not_found, invalid, failed or rolled_back",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input, empty patch or unknown mode",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid admin token or API key",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin endpoints disabled",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/by-username": {
+            "get": {
+                "description": "Get a single user by their username (case-insensitive)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by username",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Missing username",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/recent": {
+            "get": {
+                "description": "The newest users by registration time, including suspended ones (cached briefly)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get recently registered users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of users (default 10, capped at 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users, newest first",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/search": {
+            "get": {
+                "description": "Search users by keyword",
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Search users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search keyword (SEARCH_KEYWORD_MIN_LENGTH to SEARCH_KEYWORD_MAX_LENGTH characters)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search mode: exact (substring, default) or fuzzy (typo tolerant, adds a score per user)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum similarity (0-1] for fuzzy mode",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add each user's relevance (0-1] in exact mode; fuzzy mode always includes it (default false)",
+                        "name": "with_score",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (must not be negative)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (defaults to PAGINATION_DEFAULT_LIMIT, clamped to PAGINATION_MAX_LIMIT)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/vnd.api+json for a JSON:API document instead of the default envelope",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Search results (pagination also in X-Total-Count, X-Page, X-Limit, X-Total-Pages and Link headers)",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Too many concurrent list and search queries (see Retry-After)",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/stats": {
+            "get": {
+                "description": "Total users, average age, age distribution and signups per day over the last N days (cached briefly)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user statistics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Lookback window in days for signups per day (1-365, default 30)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User statistics",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid days",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/validate": {
+            "post": {
+                "description": "Run every check of user creation, including that the email and username are not taken, without saving anything. With HIDE_USER_ENUMERATION the email is not checked for uniqueness.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Validate a new user without creating it",
+                "parameters": [
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.CreateUserCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Valid",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid fields, with a message per field",
+                        "schema": {
+                            "$ref": "#/definitions/handler.validationFailedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}": {
+            "get": {
+                "description": "Get a single user by their ID (with Redis caching)",
+                "produces": [
+                    "application/json",
+                    "application/vnd.api+json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated fields to return (id, name, username, email, age, avatar_url, status, last_login_at, created_at, updated_at)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/vnd.api+json for a JSON:API document instead of the default envelope",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "304": {
+                        "description": "Not modified"
+                    },
+                    "400": {
+                        "description": "Invalid user ID or unknown field",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update user information. The email must match the current one; change it with POST /users/{id}/email-change.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.UpdateUserCommand"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reject with 412 if the user was updated after this HTTP date",
+                        "name": "If-Unmodified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or email differs from the current one",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "User modified since If-Unmodified-Since",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a user by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reject with 412 if the user was updated after this HTTP date",
+                        "name": "If-Unmodified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User deleted",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "User modified since If-Unmodified-Since",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Returns 200 if the user exists and 404 otherwise, with no body",
+                "tags": [
+                    "users"
+                ],
+                "summary": "Check if a user exists (HEAD)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User exists"
+                    },
+                    "400": {
+                        "description": "Invalid user ID"
+                    },
+                    "404": {
+                        "description": "User not found"
+                    },
+                    "500": {
+                        "description": "Internal server error"
+                    }
+                }
+            }
+        },
+        "/users/{id}/activity": {
+            "get": {
+                "description": "Get a user's status, creation, update, last login and password change times in one call",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user activity summary",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Activity summary",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/change-password": {
+            "put": {
+                "description": "Change password for a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Change user password",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password data",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.ChangePasswordCommand"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or recently used password",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Incorrect old password",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/users/{id}": {
-            "get": {
-                "description": "Get a single user by their ID (with Redis caching)",
+        "/users/{id}/email-change": {
+            "post": {
+                "description": "Start changing a user's email. A confirmation token is sent to the new address; the current email stays active until the change is confirmed.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Request an email change",
                 "parameters": [
                     {
                         "type": "integer",
@@ -263,41 +1820,55 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/command.RequestEmailChangeCommand"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User found",
+                    "202": {
+                        "description": "Confirmation sent",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid user ID",
+                        "description": "Invalid input or blocked email domain",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already exists",
+                        "schema": {
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update user information",
+            }
+        },
+        "/users/{id}/email-change/confirm": {
+            "post": {
+                "description": "Commit a pending email change using the token sent to the new address",
                 "consumes": [
                     "application/json"
                 ],
@@ -307,7 +1878,7 @@ const docTemplate = `{
                 "tags": [
                     "users"
                 ],
-                "summary": "Update user",
+                "summary": "Confirm an email change",
                 "parameters": [
                     {
                         "type": "integer",
@@ -317,62 +1888,60 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "User data",
-                        "name": "user",
+                        "description": "Confirmation token",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/command.UpdateUserCommand"
+                            "$ref": "#/definitions/command.ConfirmEmailChangeCommand"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User updated",
+                        "description": "Email changed",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid input or token, or blocked email domain",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "409": {
                         "description": "Email already exists",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a user by ID",
+            }
+        },
+        "/users/{id}/exists": {
+            "get": {
+                "description": "Cheap existence check that does not return the user (consults Redis cache first)",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Delete user",
+                "summary": "Check if a user exists",
                 "parameters": [
                     {
                         "type": "integer",
@@ -384,7 +1953,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "User deleted",
+                        "description": "User exists",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -393,40 +1962,34 @@ const docTemplate = `{
                     "400": {
                         "description": "Invalid user ID",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/users/{id}/change-password": {
-            "put": {
-                "description": "Change password for a user",
-                "consumes": [
-                    "application/json"
-                ],
+        "/users/{id}/password-history": {
+            "get": {
+                "description": "List when a user's password was changed or reset, newest first. Password hashes are never returned.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Change user password",
+                "summary": "Get password change history",
                 "parameters": [
                     {
                         "type": "integer",
@@ -436,49 +1999,42 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Password data",
-                        "name": "password",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/command.ChangePasswordCommand"
-                        }
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default and max configurable)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Password changed",
+                        "description": "Paginated password changes",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "401": {
-                        "description": "Incorrect old password",
+                        "description": "Invalid user ID or page",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/response.ErrorResponse"
                         }
                     }
                 }
@@ -486,6 +2042,47 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "command.BatchDeleteUsersCommand": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "command.BatchUpdateUsersCommand": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "mode": {
+                    "description": "Mode is BatchModeAllOrNothing (the default) or BatchModeBestEffort",
+                    "type": "string",
+                    "enum": [
+                        "all_or_nothing",
+                        "best_effort"
+                    ]
+                },
+                "patch": {
+                    "$ref": "#/definitions/command.UserPatch"
+                }
+            }
+        },
         "command.ChangePasswordCommand": {
             "type": "object",
             "required": [
@@ -494,14 +2091,24 @@ const docTemplate = `{
             ],
             "properties": {
                 "new_password": {
-                    "type": "string",
-                    "minLength": 8
+                    "type": "string"
                 },
                 "old_password": {
                     "type": "string"
                 }
             }
         },
+        "command.ConfirmEmailChangeCommand": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "command.CreateUserCommand": {
             "type": "object",
             "required": [
@@ -512,9 +2119,12 @@ const docTemplate = `{
             ],
             "properties": {
                 "age": {
-                    "type": "integer",
-                    "maximum": 150,
-                    "minimum": 0
+                    "description": "Age is a pointer so 0 is a valid age while a missing field is still rejected",
+                    "type": "integer"
+                },
+                "avatar_url": {
+                    "description": "AvatarURL is optional; when set it must be an http(s) URL",
+                    "type": "string"
                 },
                 "email": {
                     "type": "string"
@@ -523,8 +2133,77 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "password": {
-                    "type": "string",
-                    "minLength": 8
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "command.ForgotPasswordCommand": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "command.LoginCommand": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "command.MergeUsersCommand": {
+            "type": "object",
+            "required": [
+                "duplicate_id",
+                "primary_id"
+            ],
+            "properties": {
+                "duplicate_id": {
+                    "type": "integer"
+                },
+                "primary_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "command.RequestEmailChangeCommand": {
+            "type": "object",
+            "required": [
+                "new_email"
+            ],
+            "properties": {
+                "new_email": {
+                    "type": "string"
+                }
+            }
+        },
+        "command.ResetPasswordCommand": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
                 }
             }
         },
@@ -537,18 +2216,202 @@ const docTemplate = `{
             ],
             "properties": {
                 "age": {
-                    "type": "integer",
-                    "maximum": 150,
-                    "minimum": 0
+                    "description": "Age is a pointer so 0 is a valid age while a missing field is still rejected",
+                    "type": "integer"
+                },
+                "avatar_url": {
+                    "description": "AvatarURL is optional; omit it to keep the current avatar, send \"\" to clear it",
+                    "type": "string"
                 },
                 "email": {
+                    "description": "Email must match the current email; use the email change endpoints to change it",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username is optional; omit it to keep the current username, send \"\" to clear it",
+                    "type": "string"
+                }
+            }
+        },
+        "command.UserPatch": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "type": "integer"
+                },
+                "avatar_url": {
                     "type": "string"
                 },
                 "name": {
                     "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "suspended"
+                    ]
+                }
+            }
+        },
+        "domain.PasswordRequirements": {
+            "type": "object",
+            "properties": {
+                "denylist_enabled": {
+                    "type": "boolean"
+                },
+                "min_length": {
+                    "type": "integer"
+                },
+                "required_classes": {
+                    "description": "RequiredClasses lists the character classes a password needs: uppercase, lowercase,\ndigit and symbol",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "handler.SetMaintenanceRequest": {
+            "type": "object",
+            "required": [
+                "enabled"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handler.validationFailedResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/response.Code"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "errors": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "response.Code": {
+            "type": "string",
+            "enum": [
+                "VALIDATION_ERROR",
+                "INVALID_ID",
+                "INVALID_PARAMETER",
+                "SEARCH_KEYWORD_TOO_SHORT",
+                "SEARCH_KEYWORD_TOO_LONG",
+                "USER_NOT_FOUND",
+                "NOT_FOUND",
+                "METHOD_NOT_ALLOWED",
+                "EMAIL_TAKEN",
+                "EMAIL_DOMAIN_BLOCKED",
+                "USERNAME_TAKEN",
+                "INVALID_CREDENTIALS",
+                "INCORRECT_PASSWORD",
+                "WEAK_PASSWORD",
+                "PASSWORD_REUSED",
+                "INVALID_RESET_TOKEN",
+                "INVALID_EMAIL_CHANGE_TOKEN",
+                "EMAIL_CHANGE_NOT_ALLOWED",
+                "ACCOUNT_SUSPENDED",
+                "INVALID_STATUS_TRANSITION",
+                "IDEMPOTENCY_CONFLICT",
+                "IDEMPOTENCY_KEY_REUSED",
+                "PRECONDITION_FAILED",
+                "UNAUTHORIZED",
+                "FORBIDDEN",
+                "UNSUPPORTED_MEDIA_TYPE",
+                "RATE_LIMITED",
+                "MAINTENANCE",
+                "SERVICE_UNAVAILABLE",
+                "REQUEST_TIMEOUT",
+                "INTERNAL_ERROR"
+            ],
+            "x-enum-varnames": [
+                "CodeValidationError",
+                "CodeInvalidID",
+                "CodeInvalidParameter",
+                "CodeSearchKeywordTooShort",
+                "CodeSearchKeywordTooLong",
+                "CodeUserNotFound",
+                "CodeNotFound",
+                "CodeMethodNotAllowed",
+                "CodeEmailTaken",
+                "CodeEmailDomainBlocked",
+                "CodeUsernameTaken",
+                "CodeInvalidCredentials",
+                "CodeIncorrectPassword",
+                "CodeWeakPassword",
+                "CodePasswordReused",
+                "CodeInvalidResetToken",
+                "CodeInvalidEmailChangeToken",
+                "CodeEmailChangeNotAllowed",
+                "CodeAccountSuspended",
+                "CodeInvalidStatusTransition",
+                "CodeIdempotencyConflict",
+                "CodeIdempotencyKeyReused",
+                "CodePreconditionFailed",
+                "CodeUnauthorized",
+                "CodeForbidden",
+                "CodeUnsupportedMediaType",
+                "CodeRateLimited",
+                "CodeMaintenance",
+                "CodeServiceUnavailable",
+                "CodeRequestTimeout",
+                "CodeInternalError"
+            ]
+        },
+        "response.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "$ref": "#/definitions/response.Code"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "APIKey": {
+            "description": "Internal service key, one of API_KEYS",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "AdminToken": {
+            "description": "Admin token as \"Bearer \u003cADMIN_TOKEN\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"context"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+type BatchDeleteUsersCommand struct {
+	IDs []int64 `json:"ids" binding:"required,min=1,dive,gt=0"`
+}
+
+// BatchDeleteUsersResult reports which ids were deleted and which did not exist
+type BatchDeleteUsersResult struct {
+	Deleted  []int64 `json:"deleted"`
+	NotFound []int64 `json:"not_found"`
+}
+
+type BatchDeleteUsersHandler struct {
+	repo  domain.UserRepository
+	cache *cache.RedisCache
+	async *cache.WorkerPool
+}
+
+func NewBatchDeleteUsersHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *BatchDeleteUsersHandler {
+	return &BatchDeleteUsersHandler{repo: repo, cache: cache, async: async}
+}
+
+func (h *BatchDeleteUsersHandler) Handle(ctx context.Context, cmd BatchDeleteUsersCommand) (*BatchDeleteUsersResult, error) {
+	ctx, span := tracing.StartSpan(ctx, "BatchDeleteUsersHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	var deleted []int64
+	err := h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		var err error
+		deleted, err = repo.DeleteMany(ctx, cmd.IDs)
+		if err != nil {
+			return err
+		}
+		for _, id := range deleted {
+			if err := recordEvent(ctx, repo, event.UserDeleted, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deletedSet := make(map[int64]bool, len(deleted))
+	for _, id := range deleted {
+		deletedSet[id] = true
+	}
+
+	result := &BatchDeleteUsersResult{
+		Deleted:  []int64{},
+		NotFound: []int64{},
+	}
+	seen := make(map[int64]bool, len(cmd.IDs))
+	for _, id := range cmd.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if deletedSet[id] {
+			result.Deleted = append(result.Deleted, id)
+		} else {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+
+	deletedIDs := result.Deleted
+	h.async.Submit(func(ctx context.Context) {
+		for _, id := range deletedIDs {
+			h.cache.DeleteUser(ctx, id)
+		}
+	})
+	if len(deletedIDs) > 0 {
+		invalidateLists(ctx, h.cache)
+	}
+
+	return result, nil
+}
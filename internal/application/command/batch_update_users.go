@@ -0,0 +1,223 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+// maxBatchUpdateIDs limits how many users one batch update loads and writes
+const maxBatchUpdateIDs = 1000
+
+// UserPatch lists the fields a batch update can set; omitted fields are left unchanged.
+// Email and username are unique per user, so they can't be patched in bulk.
+type UserPatch struct {
+	Name      *string `json:"name"`
+	Age       *int    `json:"age"`
+	AvatarURL *string `json:"avatar_url"`
+	Status    *string `json:"status" binding:"omitempty,oneof=active suspended"`
+}
+
+type BatchUpdateUsersCommand struct {
+	IDs   []int64   `json:"ids" binding:"required,min=1,dive,gt=0"`
+	Patch UserPatch `json:"patch"`
+	// Mode is BatchModeAllOrNothing (the default) or BatchModeBestEffort
+	Mode string `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+}
+
+// Batch update modes
+const (
+	// BatchModeAllOrNothing saves the batch only if every user can be updated
+	BatchModeAllOrNothing = "all_or_nothing"
+	// BatchModeBestEffort updates each user in its own savepoint and saves those that succeed
+	BatchModeBestEffort = "best_effort"
+)
+
+// Outcomes of a batch update for each id
+const (
+	BatchUpdateUpdated  = "updated"
+	BatchUpdateNotFound = "not_found"
+	BatchUpdateInvalid  = "invalid"
+	// BatchUpdateFailed means the database rejected the write (best_effort only)
+	BatchUpdateFailed = "failed"
+	// BatchUpdateRolledBack means the user was valid but an all_or_nothing batch was
+	// rolled back because another user could not be updated
+	BatchUpdateRolledBack = "rolled_back"
+)
+
+// errBatchRolledBack rolls back an all_or_nothing batch in which some user failed
+var errBatchRolledBack = errors.New("batch rolled back")
+
+// BatchUpdateItem reports the outcome for one id; Error explains why the user was left
+// unchanged
+type BatchUpdateItem struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchUpdateUsersResult lists the outcome for each distinct id, in request order.
+// Committed is false when an all_or_nothing batch was rolled back.
+type BatchUpdateUsersResult struct {
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Results   []BatchUpdateItem `json:"results"`
+}
+
+// BatchUpdateUsersHandler applies the same patch to several users in one transaction.
+// Each user is validated after patching. In all_or_nothing mode one missing, invalid or
+// failed user rolls the whole batch back; in best_effort mode each write gets its own
+// savepoint, so failures are reported per user and the rest are saved.
+type BatchUpdateUsersHandler struct {
+	repo  domain.UserRepository
+	cache *cache.RedisCache
+	async *cache.WorkerPool
+}
+
+func NewBatchUpdateUsersHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *BatchUpdateUsersHandler {
+	return &BatchUpdateUsersHandler{repo: repo, cache: cache, async: async}
+}
+
+func (h *BatchUpdateUsersHandler) Handle(ctx context.Context, cmd BatchUpdateUsersCommand) (*BatchUpdateUsersResult, error) {
+	ctx, span := tracing.StartSpan(ctx, "BatchUpdateUsersHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	mode := cmd.Mode
+	if mode == "" {
+		mode = BatchModeAllOrNothing
+	}
+
+	var result *BatchUpdateUsersResult
+	var updated []int64
+	err := h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		users, err := repo.GetByIDs(ctx, cmd.IDs)
+		if err != nil {
+			return err
+		}
+
+		result = &BatchUpdateUsersResult{Mode: mode, Committed: true, Results: []BatchUpdateItem{}}
+		updated = nil
+		failed := false
+		seen := make(map[int64]bool, len(cmd.IDs))
+		for _, id := range cmd.IDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			item := BatchUpdateItem{ID: id, Status: BatchUpdateUpdated}
+			user, ok := users[id]
+			if !ok {
+				item.Status = BatchUpdateNotFound
+			} else if err := cmd.Patch.apply(user); err != nil {
+				item.Status, item.Error = BatchUpdateInvalid, err.Error()
+			} else if mode == BatchModeAllOrNothing && failed {
+				// Still validated for the report, but not written: the batch rolls back anyway
+				item.Status = BatchUpdateRolledBack
+			} else if err := h.save(ctx, repo, mode, user); err != nil {
+				switch {
+				case errors.Is(err, domain.ErrUserNotFound):
+					item.Status = BatchUpdateNotFound
+				case mode == BatchModeBestEffort && !errors.Is(err, domain.ErrDatabaseUnavailable):
+					log.Printf("Batch update of user %d failed: %v", id, err)
+					item.Status, item.Error = BatchUpdateFailed, "update failed"
+				default:
+					return err
+				}
+			}
+
+			if item.Status == BatchUpdateUpdated {
+				updated = append(updated, id)
+			} else if item.Status != BatchUpdateRolledBack {
+				failed = true
+			}
+			result.Results = append(result.Results, item)
+		}
+
+		if mode == BatchModeAllOrNothing && failed {
+			for i := range result.Results {
+				if result.Results[i].Status == BatchUpdateUpdated {
+					result.Results[i].Status = BatchUpdateRolledBack
+				}
+			}
+			result.Committed = false
+			updated = nil
+			return errBatchRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchRolledBack) {
+		return nil, err
+	}
+
+	if len(updated) > 0 {
+		h.async.Submit(func(ctx context.Context) {
+			for _, id := range updated {
+				h.cache.DeleteUser(ctx, id)
+			}
+		})
+		invalidateLists(ctx, h.cache)
+	}
+
+	return result, nil
+}
+
+// save writes the patched user and its event. In best-effort mode it does so in a
+// savepoint, so a failed write leaves the rest of the batch's transaction usable.
+func (h *BatchUpdateUsersHandler) save(ctx context.Context, repo domain.UserRepository, mode string, user *domain.User) error {
+	write := func(repo domain.UserRepository) error {
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserUpdated, user.ID)
+	}
+
+	if mode == BatchModeBestEffort {
+		return repo.WithinTransaction(ctx, write)
+	}
+	return write(repo)
+}
+
+// apply sets the patched fields on user through the domain's validating setters. A
+// status the user already has is left as is rather than treated as a bad transition.
+func (p UserPatch) apply(user *domain.User) error {
+	name, age := user.Name, user.Age
+	if p.Name != nil {
+		name = *p.Name
+	}
+	if p.Age != nil {
+		age = *p.Age
+	}
+	if p.Name != nil || p.Age != nil {
+		if err := user.Update(name, age); err != nil {
+			return err
+		}
+	}
+
+	if p.AvatarURL != nil {
+		if err := user.SetAvatarURL(*p.AvatarURL); err != nil {
+			return err
+		}
+	}
+
+	if p.Status != nil && *p.Status != user.Status {
+		transition := user.Activate
+		if *p.Status == domain.UserStatusSuspended {
+			transition = user.Suspend
+		}
+		if err := transition(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
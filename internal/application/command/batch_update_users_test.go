@@ -0,0 +1,221 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+// batchFixture holds users 1 and 3, which any name patch fits, and user 2, whose stored
+// age is out of bounds so every patch of it fails validation
+func batchFixture() *domaintest.UserRepository {
+	return domaintest.NewUserRepository(
+		&domain.User{ID: 1, Name: "One", Email: "one@example.com", Age: 20},
+		&domain.User{ID: 2, Name: "Two", Email: "two@example.com", Age: 999},
+		&domain.User{ID: 3, Name: "Three", Email: "three@example.com", Age: 30},
+	)
+}
+
+func newBatchUpdateHandler(t *testing.T, repo domain.UserRepository) (*BatchUpdateUsersHandler, *cache.WorkerPool, *cachetest.Server) {
+	t.Helper()
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	t.Cleanup(func() { async.Shutdown(context.Background()) })
+	return NewBatchUpdateUsersHandler(repo, redisCache, async), async, server
+}
+
+func renamePatch(name string) UserPatch {
+	return UserPatch{Name: &name}
+}
+
+func statuses(result *BatchUpdateUsersResult) map[int64]string {
+	got := make(map[int64]string, len(result.Results))
+	for _, item := range result.Results {
+		got[item.ID] = item.Status
+	}
+	return got
+}
+
+func TestBatchUpdateBestEffortOutcomes(t *testing.T) {
+	repo := batchFixture()
+	h, _, _ := newBatchUpdateHandler(t, repo)
+
+	result, err := h.Handle(context.Background(), BatchUpdateUsersCommand{
+		IDs:   []int64{1, 2, 99, 3, 1},
+		Patch: renamePatch("Renamed"),
+		Mode:  BatchModeBestEffort,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]string{1: BatchUpdateUpdated, 2: BatchUpdateInvalid, 99: BatchUpdateNotFound, 3: BatchUpdateUpdated}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("outcomes = %v, want %v", got, want)
+	}
+	if len(result.Results) != 4 {
+		t.Errorf("got %d results, want one per distinct id", len(result.Results))
+	}
+	if result.Results[1].Error == "" {
+		t.Error("invalid user is reported without a reason")
+	}
+	if !result.Committed {
+		t.Error("best-effort batch was not committed")
+	}
+	if repo.User(1).Name != "Renamed" || repo.User(3).Name != "Renamed" {
+		t.Error("valid users were not saved")
+	}
+	if repo.User(2).Name != "Two" {
+		t.Error("invalid user was changed")
+	}
+}
+
+func TestBatchUpdateAllOrNothingRollsBack(t *testing.T) {
+	repo := batchFixture()
+	h, _, server := newBatchUpdateHandler(t, repo)
+
+	result, err := h.Handle(context.Background(), BatchUpdateUsersCommand{
+		IDs:   []int64{1, 2, 3},
+		Patch: renamePatch("Renamed"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Mode != BatchModeAllOrNothing {
+		t.Errorf("mode = %q, want all_or_nothing by default", result.Mode)
+	}
+	if result.Committed {
+		t.Error("batch with an invalid user was committed")
+	}
+	want := map[int64]string{1: BatchUpdateRolledBack, 2: BatchUpdateInvalid, 3: BatchUpdateRolledBack}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("outcomes = %v, want %v", got, want)
+	}
+	if repo.User(1).Name != "One" || repo.User(3).Name != "Three" {
+		t.Error("rolled back batch changed users")
+	}
+	if events := repo.Events(); len(events) != 0 {
+		t.Errorf("rolled back batch recorded events %v", events)
+	}
+	if n := server.Calls("INCR"); n != 0 {
+		t.Errorf("rolled back batch invalidated cached lists %d times", n)
+	}
+}
+
+func TestBatchUpdateAllOrNothingCommits(t *testing.T) {
+	repo := batchFixture()
+	h, async, server := newBatchUpdateHandler(t, repo)
+	server.Set("user:v2:1", `{"id":1}`)
+	server.Set("user:v2:3", `{"id":3}`)
+
+	result, err := h.Handle(context.Background(), BatchUpdateUsersCommand{
+		IDs:   []int64{1, 3},
+		Patch: renamePatch("Renamed"),
+		Mode:  BatchModeAllOrNothing,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	async.Shutdown(context.Background())
+
+	if !result.Committed {
+		t.Fatal("valid batch was not committed")
+	}
+	want := map[int64]string{1: BatchUpdateUpdated, 3: BatchUpdateUpdated}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("outcomes = %v, want %v", got, want)
+	}
+	if keys := server.Keys("user:*"); len(keys) != 0 {
+		t.Errorf("cached users %v were not invalidated", keys)
+	}
+	if gen, _ := server.Get("users:list:gen"); gen != "1" {
+		t.Errorf("list generation = %q, want it bumped to 1", gen)
+	}
+}
+
+func TestBatchUpdateBestEffortIsolatesFailedWrites(t *testing.T) {
+	repo := batchFixture()
+	enqueued := 0
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "EnqueueEvent" {
+			if enqueued++; enqueued == 1 {
+				return errors.New("outbox insert failed")
+			}
+		}
+		return nil
+	}
+	h, _, _ := newBatchUpdateHandler(t, repo)
+
+	result, err := h.Handle(context.Background(), BatchUpdateUsersCommand{
+		IDs:   []int64{1, 3},
+		Patch: renamePatch("Renamed"),
+		Mode:  BatchModeBestEffort,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int64]string{1: BatchUpdateFailed, 3: BatchUpdateUpdated}
+	if got := statuses(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("outcomes = %v, want %v", got, want)
+	}
+	if repo.User(1).Name != "One" {
+		t.Error("savepoint of the failed user was not rolled back")
+	}
+	if repo.User(3).Name != "Renamed" {
+		t.Error("user after the failure was not saved")
+	}
+}
+
+func TestBatchUpdateAbortsWhenDatabaseIsUnavailable(t *testing.T) {
+	repo := batchFixture()
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "Update" {
+			return domain.ErrDatabaseUnavailable
+		}
+		return nil
+	}
+	h, _, _ := newBatchUpdateHandler(t, repo)
+
+	_, err := h.Handle(context.Background(), BatchUpdateUsersCommand{
+		IDs:   []int64{1, 3},
+		Patch: renamePatch("Renamed"),
+		Mode:  BatchModeBestEffort,
+	})
+
+	if !errors.Is(err, domain.ErrDatabaseUnavailable) {
+		t.Fatalf("err = %v, want ErrDatabaseUnavailable", err)
+	}
+}
+
+func TestBatchUpdateValidate(t *testing.T) {
+	name := "Renamed"
+	tests := []struct {
+		name  string
+		cmd   BatchUpdateUsersCommand
+		field string
+	}{
+		{"no ids", BatchUpdateUsersCommand{Patch: UserPatch{Name: &name}}, "ids"},
+		{"non-positive id", BatchUpdateUsersCommand{IDs: []int64{0}, Patch: UserPatch{Name: &name}}, "ids"},
+		{"too many ids", BatchUpdateUsersCommand{IDs: make([]int64, maxBatchUpdateIDs+1), Patch: UserPatch{Name: &name}}, "ids"},
+		{"empty patch", BatchUpdateUsersCommand{IDs: []int64{1}}, "patch"},
+		{"unknown mode", BatchUpdateUsersCommand{IDs: []int64{1}, Patch: UserPatch{Name: &name}, Mode: "some"}, "mode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verr *ValidationError
+			if err := tt.cmd.Validate(); !errors.As(err, &verr) || verr.Field != tt.field {
+				t.Errorf("Validate() = %v, want a %s validation error", err, tt.field)
+			}
+		})
+	}
+}
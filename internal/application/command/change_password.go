@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"user-crud/internal/application/event"
 	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/tracing"
@@ -10,36 +11,61 @@ import (
 type ChangePasswordCommand struct {
 	UserID      int64  `json:"-"`
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required"`
+	SourceIP    string `json:"-"` // Recorded in the password history
 }
 
 type ChangePasswordHandler struct {
-	repo  domain.UserRepository
-	cache *cache.RedisCache
+	repo       domain.UserRepository
+	cache      *cache.RedisCache
+	async      *cache.WorkerPool
+	reuseLimit int
 }
 
-func NewChangePasswordHandler(repo domain.UserRepository, cache *cache.RedisCache) *ChangePasswordHandler {
-	return &ChangePasswordHandler{repo: repo, cache: cache}
+// NewChangePasswordHandler creates a ChangePasswordHandler; the new password must not match
+// any of the user's last reuseLimit passwords (0 disables the check)
+func NewChangePasswordHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, reuseLimit int) *ChangePasswordHandler {
+	return &ChangePasswordHandler{repo: repo, cache: cache, async: async, reuseLimit: reuseLimit}
 }
 
 func (h *ChangePasswordHandler) Handle(ctx context.Context, cmd ChangePasswordCommand) error {
 	ctx, span := tracing.StartSpan(ctx, "ChangePasswordHandler.Handle")
 	defer span.End()
 
+	if err := cmd.Validate(); err != nil {
+		return err
+	}
+
 	user, err := h.repo.GetByID(ctx, cmd.UserID)
 	if err != nil {
-		return domain.ErrUserNotFound
+		return err
 	}
 
+	previousHash := user.PasswordHash
 	if err := user.UpdatePassword(cmd.OldPassword, cmd.NewPassword); err != nil {
 		return err
 	}
 
-	if err := h.repo.Update(ctx, user); err != nil {
+	if err := checkPasswordReuse(ctx, h.repo, user.ID, previousHash, cmd.NewPassword, h.reuseLimit); err != nil {
 		return err
 	}
 
-	go h.cache.DeleteUser(context.Background(), cmd.UserID)
+	err = h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := repo.AddPasswordHistory(ctx, user.ID, previousHash, cmd.SourceIP); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserPasswordChanged, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	h.async.Submit(func(ctx context.Context) {
+		h.cache.DeleteUser(ctx, cmd.UserID)
+	})
 
 	return nil
-}
\ No newline at end of file
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"context"
+	"user-crud/internal/application/event"
 	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/tracing"
@@ -9,9 +10,13 @@ import (
 
 type CreateUserCommand struct {
 	Name     string `json:"name" binding:"required"`
+	Username string `json:"username"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
-	Age      int    `json:"age" binding:"required,min=0,max=150"`
+	Password string `json:"password" binding:"required"`
+	// Age is a pointer so 0 is a valid age while a missing field is still rejected
+	Age *int `json:"age" binding:"required"`
+	// AvatarURL is optional; when set it must be an http(s) URL
+	AvatarURL string `json:"avatar_url"`
 }
 
 type CreateUserHandler struct {
@@ -27,19 +32,48 @@ func (h *CreateUserHandler) Handle(ctx context.Context, cmd CreateUserCommand) (
 	ctx, span := tracing.StartSpan(ctx, "CreateUserHandler.Handle")
 	defer span.End()
 
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Every field is validated before uniqueness is checked, so an invalid request gets
+	// the same 400 whether or not its email is registered and can't be used to probe
+	// for accounts when HIDE_USER_ENUMERATION is on
+	user, err := domain.NewUser(cmd.Name, cmd.Email, cmd.Password, *cmd.Age)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := user.SetUsername(cmd.Username); err != nil {
+		return nil, err
+	}
+
+	if err := user.SetAvatarURL(cmd.AvatarURL); err != nil {
+		return nil, err
+	}
+
 	existingUser, _ := h.repo.GetByEmail(ctx, cmd.Email)
 	if existingUser != nil {
 		return nil, domain.ErrUserAlreadyExists
 	}
 
-	user, err := domain.NewUser(cmd.Name, cmd.Email, cmd.Password, cmd.Age)
-	if err != nil {
-		return nil, err
+	if user.Username != "" {
+		if existing, _ := h.repo.GetByUsername(ctx, user.Username); existing != nil {
+			return nil, domain.ErrUsernameTaken
+		}
 	}
 
-	if err := h.repo.Create(ctx, user); err != nil {
+	err = h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		if err := repo.Create(ctx, user); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserCreated, user.ID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	invalidateLists(ctx, h.cache)
+
 	return user, nil
-}
\ No newline at end of file
+}
@@ -2,6 +2,8 @@ package command
 
 import (
 	"context"
+	"time"
+	"user-crud/internal/application/event"
 	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/tracing"
@@ -9,31 +11,51 @@ import (
 
 type DeleteUserCommand struct {
 	ID int64
+	// UnmodifiedSince rejects the delete with ErrPreconditionFailed if the user changed after it
+	UnmodifiedSince *time.Time
 }
 
 type DeleteUserHandler struct {
 	repo  domain.UserRepository
 	cache *cache.RedisCache
+	async *cache.WorkerPool
 }
 
-func NewDeleteUserHandler(repo domain.UserRepository, cache *cache.RedisCache) *DeleteUserHandler {
-	return &DeleteUserHandler{repo: repo, cache: cache}
+func NewDeleteUserHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *DeleteUserHandler {
+	return &DeleteUserHandler{repo: repo, cache: cache, async: async}
 }
 
 func (h *DeleteUserHandler) Handle(ctx context.Context, cmd DeleteUserCommand) error {
 	ctx, span := tracing.StartSpan(ctx, "DeleteUserHandler.Handle")
 	defer span.End()
 
-	_, err := h.repo.GetByID(ctx, cmd.ID)
+	if err := cmd.Validate(); err != nil {
+		return err
+	}
+
+	user, err := h.repo.GetByID(ctx, cmd.ID)
 	if err != nil {
-		return domain.ErrUserNotFound
+		return err
 	}
 
-	if err := h.repo.Delete(ctx, cmd.ID); err != nil {
+	if cmd.UnmodifiedSince != nil && user.ModifiedSince(*cmd.UnmodifiedSince) {
+		return domain.ErrPreconditionFailed
+	}
+
+	err = h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		if err := repo.Delete(ctx, cmd.ID); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserDeleted, cmd.ID)
+	})
+	if err != nil {
 		return err
 	}
 
-	go h.cache.DeleteUser(context.Background(), cmd.ID)
+	h.async.Submit(func(ctx context.Context) {
+		h.cache.DeleteUser(ctx, cmd.ID)
+	})
+	invalidateLists(ctx, h.cache)
 
 	return nil
-}
\ No newline at end of file
+}
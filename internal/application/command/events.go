@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+)
+
+// recordEvent adds a user event to the outbox through repo, so it commits or rolls back
+// together with the change it describes; the outbox relay publishes it afterwards
+func recordEvent(ctx context.Context, repo domain.UserRepository, eventType string, userID int64) error {
+	e := event.New(eventType, userID)
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return repo.EnqueueEvent(ctx, e.Type, e.UserID, payload)
+}
+
+// invalidateLists bumps the list cache generation after a write that can change list
+// results. It runs synchronously so the writer's next listing already misses the cache;
+// a failure only leaves lists stale until their TTL, so it is logged rather than returned.
+func invalidateLists(ctx context.Context, redisCache *cache.RedisCache) {
+	if _, err := redisCache.BumpListGeneration(ctx); err != nil {
+		log.Printf("Failed to invalidate cached user lists: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+type ForgotPasswordCommand struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ForgotPasswordHandler struct {
+	repo     domain.UserRepository
+	cache    *cache.RedisCache
+	tokenTTL time.Duration
+}
+
+func NewForgotPasswordHandler(repo domain.UserRepository, cache *cache.RedisCache, tokenTTL time.Duration) *ForgotPasswordHandler {
+	return &ForgotPasswordHandler{repo: repo, cache: cache, tokenTTL: tokenTTL}
+}
+
+// Handle issues a reset token for the user with the given email.
+// Unknown emails are not reported as errors to avoid user enumeration.
+func (h *ForgotPasswordHandler) Handle(ctx context.Context, cmd ForgotPasswordCommand) error {
+	ctx, span := tracing.StartSpan(ctx, "ForgotPasswordHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return err
+	}
+
+	user, err := h.repo.GetByEmail(ctx, cmd.Email)
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return err
+	}
+
+	if err := h.cache.SetPasswordResetToken(ctx, token, user.ID, h.tokenTTL); err != nil {
+		return err
+	}
+
+	// TODO: send the token by email instead of logging it
+	log.Printf("Password reset token for user ID %d: %s (expires in %v)", user.ID, token, h.tokenTTL)
+
+	return nil
+}
+
+// generateToken returns a random hex-encoded token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+type LoginCommand struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type LoginHandler struct {
+	repo                domain.UserRepository
+	cache               *cache.RedisCache
+	async               *cache.WorkerPool
+	hideUserEnumeration bool
+}
+
+// dummyHash is compared against when the email is unknown so both failure paths take similar
+// time. It is made on first use so it comes from the configured hasher.
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := domain.HashPassword("dummy-password")
+	return hash
+})
+
+func NewLoginHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, hideUserEnumeration bool) *LoginHandler {
+	return &LoginHandler{repo: repo, cache: cache, async: async, hideUserEnumeration: hideUserEnumeration}
+}
+
+// Handle verifies the credentials and returns the authenticated user.
+// When user enumeration hiding is enabled, unknown emails and wrong passwords
+// both return ErrInvalidCredentials.
+func (h *LoginHandler) Handle(ctx context.Context, cmd LoginCommand) (*domain.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "LoginHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	user, err := h.repo.GetByEmail(ctx, cmd.Email)
+	if err != nil {
+		if err != domain.ErrUserNotFound {
+			return nil, err
+		}
+		if h.hideUserEnumeration {
+			domain.ComparePasswordHash(dummyHash(), cmd.Password)
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err := user.ComparePassword(cmd.Password); err != nil {
+		if h.hideUserEnumeration {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, domain.ErrInvalidPassword
+	}
+
+	// Checked after the password so suspension is only revealed to the account owner
+	if user.IsSuspended() {
+		return nil, domain.ErrAccountSuspended
+	}
+
+	// Move hashes from an old scheme to the configured one while the password is at hand
+	if user.PasswordNeedsRehash() {
+		h.upgradePasswordHash(ctx, user, cmd.Password)
+	}
+
+	// A failure to record the login shouldn't fail the login itself
+	lastLoginAt, err := h.repo.TouchLastLogin(ctx, user.ID)
+	if err != nil {
+		span.RecordError(err)
+		log.Printf("Failed to record last login for user %d: %v", user.ID, err)
+	} else {
+		user.LastLoginAt = &lastLoginAt
+		h.async.Submit(func(ctx context.Context) {
+			h.cache.DeleteUser(ctx, user.ID)
+		})
+		// Listed users carry last_login_at too
+		invalidateLists(ctx, h.cache)
+	}
+
+	return user, nil
+}
+
+// upgradePasswordHash re-hashes a verified password with the configured scheme. Like
+// recording the login, a failure is logged and doesn't fail the login.
+func (h *LoginHandler) upgradePasswordHash(ctx context.Context, user *domain.User, password string) {
+	oldHash := user.PasswordHash
+	if err := user.RehashPassword(password); err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+
+	updated, err := h.repo.UpdatePasswordHash(ctx, user.ID, oldHash, user.PasswordHash)
+	if err != nil {
+		log.Printf("Failed to store rehashed password for user %d: %v", user.ID, err)
+		return
+	}
+	if updated {
+		log.Printf("Upgraded password hash of user %d to the configured scheme", user.ID)
+	}
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newLoginHandler(t *testing.T, repo domain.UserRepository) (*LoginHandler, *cachetest.Server) {
+	t.Helper()
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	t.Cleanup(func() { async.Shutdown(context.Background()) })
+	return NewLoginHandler(repo, redisCache, async, false), server
+}
+
+// useArgon2id configures a cheap argon2id hasher for new hashes for the rest of the test
+func useArgon2id(t *testing.T) {
+	t.Helper()
+	domain.SetPasswordHasher(domain.Argon2idHasher{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32})
+	t.Cleanup(func() { domain.SetPasswordHasher(domain.BcryptHasher{Cost: bcrypt.DefaultCost}) })
+}
+
+// bcryptUser returns a repository holding user 1 with a bcrypt hash of password
+func bcryptUser(t *testing.T, password string) *domaintest.UserRepository {
+	t.Helper()
+	hash, err := domain.BcryptHasher{Cost: bcrypt.MinCost}.Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return domaintest.NewUserRepository(&domain.User{ID: 1, Email: "alice@example.com", PasswordHash: hash, Status: domain.UserStatusActive})
+}
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	useArgon2id(t)
+	repo := bcryptUser(t, "Str0ng!pass")
+	h, _ := newLoginHandler(t, repo)
+	login := LoginCommand{Email: "alice@example.com", Password: "Str0ng!pass"}
+
+	if _, err := h.Handle(context.Background(), LoginCommand{Email: login.Email, Password: "wrong"}); !errors.Is(err, domain.ErrInvalidPassword) {
+		t.Fatalf("wrong password = %v, want ErrInvalidPassword", err)
+	}
+	if n := repo.Calls("UpdatePasswordHash"); n != 0 {
+		t.Errorf("failed login rehashed the password %d times", n)
+	}
+
+	if _, err := h.Handle(context.Background(), login); err != nil {
+		t.Fatal(err)
+	}
+	stored := repo.User(1).PasswordHash
+	if !strings.HasPrefix(stored, "$argon2id$") {
+		t.Fatalf("stored hash %q was not upgraded to argon2id", stored)
+	}
+
+	// The upgraded hash verifies and needs no further upgrade
+	if _, err := h.Handle(context.Background(), login); err != nil {
+		t.Fatalf("login after the upgrade = %v", err)
+	}
+	if n := repo.Calls("UpdatePasswordHash"); n != 1 {
+		t.Errorf("password was rehashed %d times, want once", n)
+	}
+}
+
+func TestLoginSucceedsWhenUpgradeFails(t *testing.T) {
+	useArgon2id(t)
+	repo := bcryptUser(t, "Str0ng!pass")
+	original := repo.User(1).PasswordHash
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "UpdatePasswordHash" {
+			return errors.New("write failed")
+		}
+		return nil
+	}
+
+	h, _ := newLoginHandler(t, repo)
+	if _, err := h.Handle(context.Background(), LoginCommand{Email: "alice@example.com", Password: "Str0ng!pass"}); err != nil {
+		t.Fatalf("login with a failed upgrade = %v, want success", err)
+	}
+	if repo.User(1).PasswordHash != original {
+		t.Error("stored hash changed although the upgrade failed")
+	}
+}
+
+func TestLoginInvalidatesCachedLists(t *testing.T) {
+	repo := bcryptUser(t, "Str0ng!pass")
+	h, server := newLoginHandler(t, repo)
+
+	if _, err := h.Handle(context.Background(), LoginCommand{Email: "alice@example.com", Password: "wrong"}); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	if gen, _ := server.Get("users:list:gen"); gen != "" {
+		t.Errorf("failed login bumped the list generation to %s", gen)
+	}
+
+	if _, err := h.Handle(context.Background(), LoginCommand{Email: "alice@example.com", Password: "Str0ng!pass"}); err != nil {
+		t.Fatal(err)
+	}
+	if gen, _ := server.Get("users:list:gen"); gen != "1" {
+		t.Errorf("list generation = %q after a login, want it bumped to 1 so last_login_at is fresh", gen)
+	}
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+// MergeUsersCommand merges a duplicate account into the account that survives
+type MergeUsersCommand struct {
+	PrimaryID   int64 `json:"primary_id" binding:"required"`
+	DuplicateID int64 `json:"duplicate_id" binding:"required"`
+}
+
+type MergeUsersHandler struct {
+	repo  domain.UserRepository
+	cache *cache.RedisCache
+	async *cache.WorkerPool
+}
+
+func NewMergeUsersHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *MergeUsersHandler {
+	return &MergeUsersHandler{repo: repo, cache: cache, async: async}
+}
+
+// Handle merges the duplicate into the primary user and returns the primary. Repeating a
+// merge that already happened returns the primary again without changing anything.
+func (h *MergeUsersHandler) Handle(ctx context.Context, cmd MergeUsersCommand) (*domain.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "MergeUsersHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	var primary *domain.User
+	merged := false
+	err := h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		var err error
+		primary, err = repo.GetByID(ctx, cmd.PrimaryID)
+		if err != nil {
+			return fmt.Errorf("primary_id %d: %w", cmd.PrimaryID, err)
+		}
+
+		mergedInto, err := repo.GetMergedInto(ctx, cmd.DuplicateID)
+		if err != nil {
+			return err
+		}
+		if mergedInto == cmd.PrimaryID {
+			return nil
+		}
+
+		// Rows in tables that belong to the person rather than the account get reassigned
+		// to the primary by MergeUsers; the duplicate is then soft-deleted
+		if err := repo.MergeUsers(ctx, cmd.PrimaryID, cmd.DuplicateID); err != nil {
+			// An identical merge may have committed since the check above, leaving the
+			// duplicate already deleted; the statement sees it, so look the merge up again
+			if errors.Is(err, domain.ErrUserNotFound) {
+				mergedInto, lookupErr := repo.GetMergedInto(ctx, cmd.DuplicateID)
+				if lookupErr != nil {
+					return lookupErr
+				}
+				if mergedInto == cmd.PrimaryID {
+					return nil
+				}
+			}
+			return fmt.Errorf("duplicate_id %d: %w", cmd.DuplicateID, err)
+		}
+		if err := recordEvent(ctx, repo, event.UserDeleted, cmd.DuplicateID); err != nil {
+			return err
+		}
+		merged = true
+		return recordEvent(ctx, repo, event.UserUpdated, cmd.PrimaryID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if merged {
+		h.async.Submit(func(ctx context.Context) {
+			h.cache.DeleteUser(ctx, cmd.PrimaryID)
+			h.cache.DeleteUser(ctx, cmd.DuplicateID)
+		})
+		invalidateLists(ctx, h.cache)
+	}
+
+	return primary, nil
+}
@@ -0,0 +1,147 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+)
+
+func mergeFixture() *domaintest.UserRepository {
+	return domaintest.NewUserRepository(
+		&domain.User{ID: 1, Name: "Primary", Email: "primary@example.com", Age: 30},
+		&domain.User{ID: 2, Name: "Duplicate", Email: "duplicate@example.com", Age: 30},
+		&domain.User{ID: 3, Name: "Other", Email: "other@example.com", Age: 40},
+	)
+}
+
+func TestMergeUsersRejectsSelfMerge(t *testing.T) {
+	repo := mergeFixture()
+	h := NewMergeUsersHandler(repo, nil, nil)
+
+	_, err := h.Handle(context.Background(), MergeUsersCommand{PrimaryID: 1, DuplicateID: 1})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Field != "duplicate_id" {
+		t.Fatalf("err = %v, want a duplicate_id validation error", err)
+	}
+	if n := repo.Calls("WithinTransaction"); n != 0 {
+		t.Errorf("self-merge opened %d transactions, want 0", n)
+	}
+}
+
+func TestMergeUsersNonexistentIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  MergeUsersCommand
+	}{
+		{"missing primary", MergeUsersCommand{PrimaryID: 99, DuplicateID: 2}},
+		{"missing duplicate", MergeUsersCommand{PrimaryID: 1, DuplicateID: 99}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mergeFixture()
+			h := NewMergeUsersHandler(repo, nil, nil)
+
+			_, err := h.Handle(context.Background(), tt.cmd)
+
+			if !errors.Is(err, domain.ErrUserNotFound) {
+				t.Fatalf("err = %v, want ErrUserNotFound", err)
+			}
+			if repo.Deleted(2) {
+				t.Error("duplicate was deleted by a failed merge")
+			}
+			if events := repo.Events(); len(events) != 0 {
+				t.Errorf("failed merge recorded events %v", events)
+			}
+		})
+	}
+}
+
+func TestMergeUsersAlreadyMerged(t *testing.T) {
+	ctx := context.Background()
+	repo := mergeFixture()
+	if err := repo.MergeUsers(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	h := NewMergeUsersHandler(repo, nil, nil)
+
+	primary, err := h.Handle(ctx, MergeUsersCommand{PrimaryID: 1, DuplicateID: 2})
+
+	if err != nil {
+		t.Fatalf("repeating a merge failed: %v", err)
+	}
+	if primary.ID != 1 {
+		t.Errorf("returned user %d, want the primary", primary.ID)
+	}
+	if n := repo.Calls("MergeUsers"); n != 1 {
+		t.Errorf("MergeUsers called %d times, want only the original merge", n)
+	}
+	if events := repo.Events(); len(events) != 0 {
+		t.Errorf("repeated merge recorded events %v", events)
+	}
+}
+
+func TestMergeUsersDuplicateMergedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	repo := mergeFixture()
+	if err := repo.MergeUsers(ctx, 3, 2); err != nil {
+		t.Fatal(err)
+	}
+	h := NewMergeUsersHandler(repo, nil, nil)
+
+	_, err := h.Handle(ctx, MergeUsersCommand{PrimaryID: 1, DuplicateID: 2})
+
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("err = %v, want ErrUserNotFound for a duplicate merged into another user", err)
+	}
+}
+
+func TestMergeUsersIntoMergedPrimary(t *testing.T) {
+	ctx := context.Background()
+	repo := mergeFixture()
+	if err := repo.MergeUsers(ctx, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+	h := NewMergeUsersHandler(repo, nil, nil)
+
+	_, err := h.Handle(ctx, MergeUsersCommand{PrimaryID: 1, DuplicateID: 2})
+
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("err = %v, want ErrUserNotFound for a primary that was merged away", err)
+	}
+	if repo.Deleted(2) {
+		t.Error("duplicate was deleted although the primary no longer exists")
+	}
+}
+
+func TestMergeUsersConcurrentIdenticalMerge(t *testing.T) {
+	ctx := context.Background()
+	repo := mergeFixture()
+	// The same merge commits from another request between the GetMergedInto check and
+	// this request's MergeUsers
+	raced := false
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "MergeUsers" && !raced {
+			raced = true
+			return repo.MergeUsers(ctx, 1, 2)
+		}
+		return nil
+	}
+	h := NewMergeUsersHandler(repo, nil, nil)
+
+	primary, err := h.Handle(ctx, MergeUsersCommand{PrimaryID: 1, DuplicateID: 2})
+
+	if err != nil {
+		t.Fatalf("losing an identical merge race failed: %v", err)
+	}
+	if primary.ID != 1 {
+		t.Errorf("returned user %d, want the primary", primary.ID)
+	}
+	if events := repo.Events(); len(events) != 0 {
+		t.Errorf("merge that lost the race recorded events %v", events)
+	}
+}
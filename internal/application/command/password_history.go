@@ -0,0 +1,27 @@
+package command
+
+import (
+	"context"
+
+	"user-crud/internal/domain"
+)
+
+// checkPasswordReuse rejects newPassword if it matches one of the user's last reuseLimit
+// passwords: the one being replaced plus the most recent ones in the password history.
+// A reuseLimit of 0 disables the check.
+func checkPasswordReuse(ctx context.Context, repo domain.UserRepository, userID int64, currentHash, newPassword string, reuseLimit int) error {
+	if reuseLimit < 1 {
+		return nil
+	}
+
+	hashes := []string{currentHash}
+	if reuseLimit > 1 {
+		previous, err := repo.GetRecentPasswordHashes(ctx, userID, reuseLimit-1)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, previous...)
+	}
+
+	return domain.CheckPasswordReuse(newPassword, hashes)
+}
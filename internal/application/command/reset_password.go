@@ -0,0 +1,95 @@
+package command
+
+import (
+	"context"
+	"errors"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+type ResetPasswordCommand struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+	SourceIP    string `json:"-"` // Recorded in the password history
+}
+
+type ResetPasswordHandler struct {
+	repo       domain.UserRepository
+	cache      *cache.RedisCache
+	async      *cache.WorkerPool
+	reuseLimit int
+}
+
+// NewResetPasswordHandler creates a ResetPasswordHandler; the new password must not match
+// any of the user's last reuseLimit passwords (0 disables the check)
+func NewResetPasswordHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, reuseLimit int) *ResetPasswordHandler {
+	return &ResetPasswordHandler{repo: repo, cache: cache, async: async, reuseLimit: reuseLimit}
+}
+
+func (h *ResetPasswordHandler) Handle(ctx context.Context, cmd ResetPasswordCommand) error {
+	ctx, span := tracing.StartSpan(ctx, "ResetPasswordHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return err
+	}
+
+	userID, err := h.cache.GetPasswordResetToken(ctx, cmd.Token)
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return domain.ErrInvalidResetToken
+	}
+
+	// A token for a user deleted since it was issued is invalid; any other failure, such
+	// as the database being down, is not the token's fault
+	user, err := h.repo.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	previousHash := user.PasswordHash
+	if err := user.SetPassword(cmd.NewPassword); err != nil {
+		return err
+	}
+
+	if err := checkPasswordReuse(ctx, h.repo, user.ID, previousHash, cmd.NewPassword, h.reuseLimit); err != nil {
+		return err
+	}
+
+	// The token was only peeked at so a rejected password doesn't burn it; claim it now,
+	// atomically, so concurrent requests with the same token can't both reset the password
+	claimedID, err := h.cache.ConsumePasswordResetToken(ctx, cmd.Token)
+	if err != nil {
+		return err
+	}
+	if claimedID != userID {
+		return domain.ErrInvalidResetToken
+	}
+
+	err = h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		if err := repo.AddPasswordHistory(ctx, user.ID, previousHash, cmd.SourceIP); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserPasswordChanged, user.ID)
+	})
+	if err != nil {
+		return err
+	}
+
+	h.async.Submit(func(ctx context.Context) {
+		h.cache.DeleteUser(ctx, userID)
+	})
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+func TestResetPasswordLookupErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup error
+		want   error
+	}{
+		{"user deleted since the token was issued", domain.ErrUserNotFound, domain.ErrInvalidResetToken},
+		{"database unavailable", domain.ErrDatabaseUnavailable, domain.ErrDatabaseUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := domaintest.NewUserRepository(&domain.User{ID: 1, Email: "alice@example.com", Status: domain.UserStatusActive})
+			repo.Before = func(ctx context.Context, method string) error {
+				if method == "GetByID" {
+					return tt.lookup
+				}
+				return nil
+			}
+			redisCache, _ := cachetest.NewRedisCache(t, time.Minute)
+			async := cache.NewWorkerPool(1, 10, nil)
+			t.Cleanup(func() { async.Shutdown(context.Background()) })
+			if err := redisCache.SetPasswordResetToken(context.Background(), "token", 1, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			h := NewResetPasswordHandler(repo, redisCache, async, 0)
+
+			err := h.Handle(context.Background(), ResetPasswordCommand{Token: "token", NewPassword: "N3w!password"})
+
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Handle = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
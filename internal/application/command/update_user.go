@@ -2,52 +2,119 @@ package command
 
 import (
 	"context"
+	"strings"
+	"time"
+	"user-crud/internal/application/event"
 	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type UpdateUserCommand struct {
-	ID    int64  `json:"-"`
-	Name  string `json:"name" binding:"required"`
+	ID   int64  `json:"-"`
+	Name string `json:"name" binding:"required"`
+	// Username is optional; omit it to keep the current username, send "" to clear it
+	Username *string `json:"username"`
+	// Email must match the current email; use the email change endpoints to change it
 	Email string `json:"email" binding:"required,email"`
-	Age   int    `json:"age" binding:"required,min=0,max=150"`
+	// Age is a pointer so 0 is a valid age while a missing field is still rejected
+	Age *int `json:"age" binding:"required"`
+	// AvatarURL is optional; omit it to keep the current avatar, send "" to clear it
+	AvatarURL *string `json:"avatar_url"`
+	// UnmodifiedSince rejects the update with ErrPreconditionFailed if the user changed after it
+	UnmodifiedSince *time.Time `json:"-"`
 }
 
 type UpdateUserHandler struct {
 	repo  domain.UserRepository
 	cache *cache.RedisCache
+	async *cache.WorkerPool
 }
 
-func NewUpdateUserHandler(repo domain.UserRepository, cache *cache.RedisCache) *UpdateUserHandler {
-	return &UpdateUserHandler{repo: repo, cache: cache}
+func NewUpdateUserHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *UpdateUserHandler {
+	return &UpdateUserHandler{repo: repo, cache: cache, async: async}
 }
 
 func (h *UpdateUserHandler) Handle(ctx context.Context, cmd UpdateUserCommand) (*domain.User, error) {
 	ctx, span := tracing.StartSpan(ctx, "UpdateUserHandler.Handle")
 	defer span.End()
 
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
 	user, err := h.repo.GetByID(ctx, cmd.ID)
 	if err != nil {
-		return nil, domain.ErrUserNotFound
+		return nil, err
 	}
 
-	if user.Email != cmd.Email {
-		existingUser, _ := h.repo.GetByEmail(ctx, cmd.Email)
-		if existingUser != nil && existingUser.ID != cmd.ID {
-			return nil, domain.ErrUserAlreadyExists
-		}
+	if cmd.UnmodifiedSince != nil && user.ModifiedSince(*cmd.UnmodifiedSince) {
+		return nil, domain.ErrPreconditionFailed
 	}
 
-	if err := user.Update(cmd.Name, cmd.Email, cmd.Age); err != nil {
+	// Email changes must be verified through the email change flow; the same address
+	// in another case is not a change, and the stored spelling is kept
+	if !strings.EqualFold(user.Email, cmd.Email) {
+		return nil, domain.ErrEmailChangeRequiresVerification
+	}
+
+	before := *user
+
+	if err := user.Update(cmd.Name, *cmd.Age); err != nil {
 		return nil, err
 	}
 
-	if err := h.repo.Update(ctx, user); err != nil {
+	if cmd.Username != nil {
+		if err := user.SetUsername(*cmd.Username); err != nil {
+			return nil, err
+		}
+		if user.Username != "" {
+			existing, _ := h.repo.GetByUsername(ctx, user.Username)
+			if existing != nil && existing.ID != cmd.ID {
+				return nil, domain.ErrUsernameTaken
+			}
+		}
+	}
+
+	if cmd.AvatarURL != nil {
+		if err := user.SetAvatarURL(*cmd.AvatarURL); err != nil {
+			return nil, err
+		}
+	}
+
+	// A PUT that repeats the current values writes nothing, so updated_at, the cache
+	// and subscribers only see real changes
+	if sameProfile(&before, user) {
+		span.SetAttributes(attribute.Bool("user.unchanged", true))
+		return &before, nil
+	}
+
+	err = h.repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserUpdated, user.ID)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	go h.cache.DeleteUser(context.Background(), cmd.ID)
+	h.async.Submit(func(ctx context.Context) {
+		h.cache.DeleteUser(ctx, cmd.ID)
+	})
+	invalidateLists(ctx, h.cache)
 
 	return user, nil
-}
\ No newline at end of file
+}
+
+// sameProfile reports whether the fields an update can change are equal, after the
+// domain's normalization has been applied to b
+func sameProfile(a, b *domain.User) bool {
+	return a.Name == b.Name &&
+		a.Username == b.Username &&
+		a.Email == b.Email &&
+		a.Age == b.Age &&
+		a.AvatarURL == b.AvatarURL
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+func TestUpdateSkipsWriteWhenNothingChanged(t *testing.T) {
+	updatedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	age := 30
+	username, avatar := "alice", "https://example.com/alice.png"
+
+	tests := []struct {
+		name    string
+		cmd     UpdateUserCommand
+		changed bool
+	}{
+		{"same values", UpdateUserCommand{Name: "Alice Smith", Email: "alice@example.com", Age: &age}, false},
+		{"same values after normalization", UpdateUserCommand{Name: "  Alice   Smith ", Email: "ALICE@example.com", Age: &age}, false},
+		{"same optional fields", UpdateUserCommand{Name: "Alice Smith", Email: "alice@example.com", Age: &age, Username: &username, AvatarURL: &avatar}, false},
+		{"new name", UpdateUserCommand{Name: "Alice Jones", Email: "alice@example.com", Age: &age}, true},
+		{"new avatar", UpdateUserCommand{Name: "Alice Smith", Email: "alice@example.com", Age: &age, AvatarURL: new(string)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := domaintest.NewUserRepository(&domain.User{
+				ID: 1, Name: "Alice Smith", Username: username, Email: "alice@example.com",
+				Age: age, AvatarURL: avatar, Status: domain.UserStatusActive, UpdatedAt: updatedAt,
+			})
+			redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+			async := cache.NewWorkerPool(1, 10, nil)
+			h := NewUpdateUserHandler(repo, redisCache, async)
+
+			tt.cmd.ID = 1
+			user, err := h.Handle(context.Background(), tt.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			async.Shutdown(context.Background())
+
+			writes := repo.Calls("Update") + len(repo.Events()) + server.Calls("INCR") + server.Calls("DEL") + server.Calls("UNLINK")
+			if !tt.changed {
+				if writes != 0 {
+					t.Errorf("unchanged update made %d writes to the database or cache", writes)
+				}
+				if !user.UpdatedAt.Equal(updatedAt) {
+					t.Errorf("updated_at = %v, want it kept at %v", user.UpdatedAt, updatedAt)
+				}
+				return
+			}
+			if n := repo.Calls("Update"); n != 1 {
+				t.Errorf("changed update wrote %d times, want 1", n)
+			}
+			if n := server.Calls("INCR"); n != 1 {
+				t.Errorf("changed update bumped the list generation %d times, want 1", n)
+			}
+			if n := server.Calls("DEL"); n != 1 {
+				t.Errorf("changed update evicted the cached user %d times, want 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package command
+
+import (
+	"context"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+type ChangeUserStatusCommand struct {
+	ID int64
+}
+
+// SuspendUserHandler disables an account without deleting it
+type SuspendUserHandler struct {
+	repo  domain.UserRepository
+	cache *cache.RedisCache
+	async *cache.WorkerPool
+}
+
+func NewSuspendUserHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *SuspendUserHandler {
+	return &SuspendUserHandler{repo: repo, cache: cache, async: async}
+}
+
+func (h *SuspendUserHandler) Handle(ctx context.Context, cmd ChangeUserStatusCommand) (*domain.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "SuspendUserHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	return changeUserStatus(ctx, h.repo, h.cache, h.async, cmd.ID, (*domain.User).Suspend)
+}
+
+// ActivateUserHandler re-enables a suspended account
+type ActivateUserHandler struct {
+	repo  domain.UserRepository
+	cache *cache.RedisCache
+	async *cache.WorkerPool
+}
+
+func NewActivateUserHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool) *ActivateUserHandler {
+	return &ActivateUserHandler{repo: repo, cache: cache, async: async}
+}
+
+func (h *ActivateUserHandler) Handle(ctx context.Context, cmd ChangeUserStatusCommand) (*domain.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "ActivateUserHandler.Handle")
+	defer span.End()
+
+	if err := cmd.Validate(); err != nil {
+		return nil, err
+	}
+
+	return changeUserStatus(ctx, h.repo, h.cache, h.async, cmd.ID, (*domain.User).Activate)
+}
+
+// changeUserStatus applies a status transition, saves the user and invalidates its cache entry
+func changeUserStatus(ctx context.Context, repo domain.UserRepository, redisCache *cache.RedisCache, async *cache.WorkerPool, id int64, transition func(*domain.User) error) (*domain.User, error) {
+	user, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := transition(user); err != nil {
+		return nil, err
+	}
+
+	err = repo.WithinTransaction(ctx, func(repo domain.UserRepository) error {
+		if err := repo.Update(ctx, user); err != nil {
+			return err
+		}
+		return recordEvent(ctx, repo, event.UserUpdated, user.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	async.Submit(func(ctx context.Context) {
+		redisCache.DeleteUser(ctx, id)
+	})
+	invalidateLists(ctx, redisCache)
+
+	return user, nil
+}
@@ -0,0 +1,187 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"user-crud/internal/domain"
+)
+
+// ValidationError reports a command field that failed structural validation. Validate
+// only checks shape (required fields, positive ids); business rules such as the password
+// policy stay in the domain.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Unwrap lets callers treat validation failures like any other invalid user data
+func (e *ValidationError) Unwrap() error {
+	return domain.ErrInvalidUserData
+}
+
+// requireID fails when id is not a positive user id
+func requireID(field string, id int64) error {
+	if id <= 0 {
+		return &ValidationError{Field: field, Message: "must be a positive integer"}
+	}
+	return nil
+}
+
+// requireAge fails when the age is absent; its range is checked by the domain's age policy
+func requireAge(age *int) error {
+	if age == nil {
+		return &ValidationError{Field: "age", Message: "is required"}
+	}
+	return nil
+}
+
+// requireText fails when value is empty or only whitespace
+func requireText(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return &ValidationError{Field: field, Message: "is required"}
+	}
+	return nil
+}
+
+// firstError returns the first non-nil error
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the fields required to create a user
+func (cmd CreateUserCommand) Validate() error {
+	return firstError(
+		requireText("name", cmd.Name),
+		requireText("email", cmd.Email),
+		requireText("password", cmd.Password),
+		requireAge(cmd.Age),
+	)
+}
+
+// Validate checks the target id and the fields required to update a user
+func (cmd UpdateUserCommand) Validate() error {
+	return firstError(
+		requireID("id", cmd.ID),
+		requireText("name", cmd.Name),
+		requireText("email", cmd.Email),
+		requireAge(cmd.Age),
+	)
+}
+
+// Validate checks the target id
+func (cmd DeleteUserCommand) Validate() error {
+	return requireID("id", cmd.ID)
+}
+
+// Validate checks that at least one id is given and every id is positive
+func (cmd BatchDeleteUsersCommand) Validate() error {
+	if len(cmd.IDs) == 0 {
+		return &ValidationError{Field: "ids", Message: "must contain at least one id"}
+	}
+	for _, id := range cmd.IDs {
+		if err := requireID("ids", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the ids like a batch delete, and that the patch sets a known status
+// and at least one field
+func (cmd BatchUpdateUsersCommand) Validate() error {
+	if len(cmd.IDs) == 0 {
+		return &ValidationError{Field: "ids", Message: "must contain at least one id"}
+	}
+	if len(cmd.IDs) > maxBatchUpdateIDs {
+		return &ValidationError{Field: "ids", Message: fmt.Sprintf("must contain at most %d ids", maxBatchUpdateIDs)}
+	}
+	for _, id := range cmd.IDs {
+		if err := requireID("ids", id); err != nil {
+			return err
+		}
+	}
+
+	patch := cmd.Patch
+	if patch.Name == nil && patch.Age == nil && patch.AvatarURL == nil && patch.Status == nil {
+		return &ValidationError{Field: "patch", Message: "must set at least one of name, age, avatar_url or status"}
+	}
+	if patch.Status != nil && *patch.Status != domain.UserStatusActive && *patch.Status != domain.UserStatusSuspended {
+		return &ValidationError{Field: "patch.status", Message: "must be active or suspended"}
+	}
+	if cmd.Mode != "" && cmd.Mode != BatchModeAllOrNothing && cmd.Mode != BatchModeBestEffort {
+		return &ValidationError{Field: "mode", Message: "must be all_or_nothing or best_effort"}
+	}
+	return nil
+}
+
+// Validate checks both ids and that they name different users
+func (cmd MergeUsersCommand) Validate() error {
+	if err := firstError(requireID("primary_id", cmd.PrimaryID), requireID("duplicate_id", cmd.DuplicateID)); err != nil {
+		return err
+	}
+	if cmd.PrimaryID == cmd.DuplicateID {
+		return &ValidationError{Field: "duplicate_id", Message: "must differ from primary_id"}
+	}
+	return nil
+}
+
+// Validate checks the target id
+func (cmd ChangeUserStatusCommand) Validate() error {
+	return requireID("id", cmd.ID)
+}
+
+// Validate checks the user id and that both passwords are given
+func (cmd ChangePasswordCommand) Validate() error {
+	return firstError(
+		requireID("user_id", cmd.UserID),
+		requireText("old_password", cmd.OldPassword),
+		requireText("new_password", cmd.NewPassword),
+	)
+}
+
+// Validate checks that the email is given
+func (cmd ForgotPasswordCommand) Validate() error {
+	return requireText("email", cmd.Email)
+}
+
+// Validate checks that the token and new password are given
+func (cmd ResetPasswordCommand) Validate() error {
+	return firstError(
+		requireText("token", cmd.Token),
+		requireText("new_password", cmd.NewPassword),
+	)
+}
+
+// Validate checks that the credentials are given
+func (cmd LoginCommand) Validate() error {
+	return firstError(
+		requireText("email", cmd.Email),
+		requireText("password", cmd.Password),
+	)
+}
+
+// Validate checks the user id and that the new email is given
+func (cmd RequestEmailChangeCommand) Validate() error {
+	return firstError(
+		requireID("user_id", cmd.UserID),
+		requireText("new_email", cmd.NewEmail),
+	)
+}
+
+// Validate checks the user id and that the token is given
+func (cmd ConfirmEmailChangeCommand) Validate() error {
+	return firstError(
+		requireID("user_id", cmd.UserID),
+		requireText("token", cmd.Token),
+	)
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"context"
+	"strings"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/tracing"
+)
+
+// ValidateUserResult reports whether a CreateUserCommand would be accepted, with a message
+// for each invalid field keyed by its JSON name
+type ValidateUserResult struct {
+	Valid  bool              `json:"valid"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// ValidateUserHandler dry-runs user creation: it runs every check CreateUserHandler does,
+// including uniqueness, but never persists anything
+type ValidateUserHandler struct {
+	repo                domain.UserRepository
+	hideUserEnumeration bool
+}
+
+// NewValidateUserHandler creates a ValidateUserHandler; with hideUserEnumeration the email
+// is not checked for uniqueness, so the result reveals nothing about registered emails
+func NewValidateUserHandler(repo domain.UserRepository, hideUserEnumeration bool) *ValidateUserHandler {
+	return &ValidateUserHandler{repo: repo, hideUserEnumeration: hideUserEnumeration}
+}
+
+func (h *ValidateUserHandler) Handle(ctx context.Context, cmd CreateUserCommand) (*ValidateUserResult, error) {
+	ctx, span := tracing.StartSpan(ctx, "ValidateUserHandler.Handle")
+	defer span.End()
+
+	age := 0
+	if cmd.Age != nil {
+		age = *cmd.Age
+	}
+
+	fieldErrs := domain.ValidateNewUser(cmd.Name, cmd.Username, cmd.Email, cmd.Password, age, cmd.AvatarURL)
+	if err := requireAge(cmd.Age); err != nil {
+		fieldErrs["age"] = err
+	}
+
+	if _, invalid := fieldErrs["email"]; !invalid && !h.hideUserEnumeration {
+		existing, err := h.repo.GetByEmail(ctx, strings.TrimSpace(cmd.Email))
+		if err != nil && err != domain.ErrUserNotFound {
+			return nil, err
+		}
+		if existing != nil {
+			fieldErrs["email"] = domain.ErrUserAlreadyExists
+		}
+	}
+
+	// Usernames are public, so a taken one is reported even when hiding enumeration
+	if username := domain.NormalizeUsername(cmd.Username); username != "" {
+		if _, invalid := fieldErrs["username"]; !invalid {
+			existing, err := h.repo.GetByUsername(ctx, username)
+			if err != nil && err != domain.ErrUserNotFound {
+				return nil, err
+			}
+			if existing != nil {
+				fieldErrs["username"] = domain.ErrUsernameTaken
+			}
+		}
+	}
+
+	if len(fieldErrs) == 0 {
+		return &ValidateUserResult{Valid: true}, nil
+	}
+
+	result := &ValidateUserResult{Errors: make(map[string]string, len(fieldErrs))}
+	for field, err := range fieldErrs {
+		result.Errors[field] = err.Error()
+	}
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// User lifecycle event types
+const (
+	UserCreated         = "user.created"
+	UserUpdated         = "user.updated"
+	UserDeleted         = "user.deleted"
+	UserPasswordChanged = "user.password_changed"
+	UserEmailChanged    = "user.email_changed"
+)
+
+// Event describes a change to a user that other services may react to
+type Event struct {
+	Type      string    `json:"type"`
+	UserID    int64     `json:"user_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// New builds an event of the given type for the user, stamped with the current time
+func New(eventType string, userID int64) Event {
+	return Event{
+		Type:      eventType,
+		UserID:    userID,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// Publisher delivers events to interested consumers
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NoopPublisher discards all events; used when event publishing is disabled
+type NoopPublisher struct{}
+
+func (NoopPublisher) Publish(ctx context.Context, event Event) error {
+	return nil
+}
+
+// MultiPublisher publishes every event to each of its publishers, returning their joined errors
+type MultiPublisher []Publisher
+
+func (m MultiPublisher) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
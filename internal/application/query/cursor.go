@@ -0,0 +1,94 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"user-crud/internal/domain"
+)
+
+// Cursor is the position after the last user of a page in cursor pagination. It records
+// the sort it was issued for, since a position is meaningless under another ordering.
+type Cursor struct {
+	SortBy string `json:"s"`
+	Order  string `json:"o"`
+	Value  string `json:"v,omitempty"` // Sort field of the last user; empty when sorting by id
+	ID     int64  `json:"id"`
+}
+
+// cursorSortFields are the sort fields a cursor can be issued for
+var cursorSortFields = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"email":      true,
+	"age":        true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// newCursor returns the cursor positioned after user in the given sort
+func newCursor(user *domain.User, sortBy, order string) Cursor {
+	cursor := Cursor{SortBy: sortBy, Order: order, ID: user.ID}
+	switch sortBy {
+	case "name":
+		cursor.Value = user.Name
+	case "email":
+		cursor.Value = user.Email
+	case "age":
+		cursor.Value = strconv.Itoa(user.Age)
+	case "created_at":
+		cursor.Value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		cursor.Value = user.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, domain.ErrInvalidCursor
+	}
+	if _, err := cursor.SortValue(); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SortValue returns the cursor's sort field value typed for the column it is compared
+// with, or nil when sorting by id
+func (c Cursor) SortValue() (interface{}, error) {
+	switch c.SortBy {
+	case "id":
+		return nil, nil
+	case "name", "email":
+		return c.Value, nil
+	case "age":
+		age, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		return age, nil
+	case "created_at", "updated_at":
+		t, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, domain.ErrInvalidCursor
+		}
+		return t, nil
+	default:
+		return nil, domain.ErrInvalidCursor
+	}
+}
@@ -0,0 +1,66 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	user := &domain.User{ID: 7, Name: "Alice", Email: "alice@example.com", Age: 30, CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)}
+
+	for _, sortBy := range []string{"id", "name", "email", "age", "created_at", "updated_at"} {
+		encoded := newCursor(user, sortBy, "desc").Encode()
+		cursor, err := DecodeCursor(encoded)
+		if err != nil {
+			t.Errorf("DecodeCursor(%s cursor) = %v", sortBy, err)
+			continue
+		}
+		if *cursor != newCursor(user, sortBy, "desc") {
+			t.Errorf("%s cursor decoded to %+v", sortBy, cursor)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	for _, raw := range []string{
+		"not base64!",
+		Cursor{SortBy: "id", Order: "asc"}.Encode(),                               // no id
+		Cursor{SortBy: "age", Order: "asc", Value: "old", ID: 1}.Encode(),         // not an age
+		Cursor{SortBy: "created_at", Value: "yesterday", ID: 1}.Encode(),          // not a time
+		Cursor{SortBy: "password_hash", Order: "asc", Value: "x", ID: 1}.Encode(), // not sortable
+	} {
+		if _, err := DecodeCursor(raw); !errors.Is(err, domain.ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", raw, err)
+		}
+	}
+}
+
+func TestCursorModeSkipsCountAndRejectsOtherSorts(t *testing.T) {
+	users := []*domain.User{{ID: 1}, {ID: 2}, {ID: 3}}
+	var seen []ListUsersQuery
+	h := newListUsersHandler(capturingRepository(&seen, users, -1))
+
+	result, err := h.Handle(context.Background(), ListUsersQuery{CursorMode: true, Limit: 2, Page: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := seen[0]; !q.SkipTotal || q.Page != 1 || q.SortBy != "id" || q.Order != "asc" {
+		t.Errorf("repository query = %+v, want the first page by id without a count", q)
+	}
+	if len(result.Users) != 2 || result.NextCursor == "" {
+		t.Fatalf("got %d users and next cursor %q, want 2 and a cursor", len(result.Users), result.NextCursor)
+	}
+
+	after, err := DecodeCursor(result.NextCursor)
+	if err != nil || after.ID != 2 {
+		t.Fatalf("next cursor = %+v, %v; want one after user 2", after, err)
+	}
+	_, err = h.Handle(context.Background(), ListUsersQuery{CursorMode: true, After: after, SortBy: "name"})
+	if !errors.Is(err, domain.ErrInvalidCursor) {
+		t.Errorf("id cursor used for a name sort = %v, want ErrInvalidCursor", err)
+	}
+}
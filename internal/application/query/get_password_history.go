@@ -0,0 +1,60 @@
+package query
+
+import (
+	"context"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type GetPasswordHistoryQuery struct {
+	UserID int64
+	Page   int
+	Limit  int
+}
+
+// PasswordHistoryResult is a page of a user's password changes, newest first
+type PasswordHistoryResult struct {
+	Changes []*domain.PasswordChange `json:"changes"`
+	PageInfo
+}
+
+type GetPasswordHistoryHandler struct {
+	repo       domain.UserRepository
+	pagination Pagination
+}
+
+func NewGetPasswordHistoryHandler(repo domain.UserRepository, pagination Pagination) *GetPasswordHistoryHandler {
+	return &GetPasswordHistoryHandler{repo: repo, pagination: pagination}
+}
+
+func (h *GetPasswordHistoryHandler) Handle(ctx context.Context, query GetPasswordHistoryQuery) (*PasswordHistoryResult, error) {
+	ctx, span := tracing.StartSpan(ctx, "GetPasswordHistoryHandler.Handle")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("user.id", query.UserID))
+
+	exists, err := h.repo.Exists(ctx, query.UserID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrUserNotFound
+	}
+
+	page, limit := h.pagination.apply(query.Page, query.Limit)
+
+	changes, total, err := h.repo.GetPasswordHistory(ctx, query.UserID, page, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	return &PasswordHistoryResult{
+		Changes:  changes,
+		PageInfo: newPageInfo(total, page, limit),
+	}, nil
+}
@@ -0,0 +1,80 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Page size of GetRecentUsers
+const (
+	DefaultRecentUsersLimit = 10
+	MaxRecentUsersLimit     = 100
+)
+
+type RecentUsersQuery struct {
+	Limit int // Number of users; defaults to DefaultRecentUsersLimit, capped at MaxRecentUsersLimit
+}
+
+// RecentUsersHandler returns the most recently registered users, including suspended ones
+type RecentUsersHandler struct {
+	repo     domain.UserRepository
+	cache    *cache.RedisCache
+	async    *cache.WorkerPool
+	logger   *slog.Logger
+	cacheTTL time.Duration
+}
+
+// NewRecentUsersHandler creates a RecentUsersHandler; results are cached for cacheTTL since
+// the list backs a frequently polled dashboard widget
+func NewRecentUsersHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, cacheTTL time.Duration, logger *slog.Logger) *RecentUsersHandler {
+	return &RecentUsersHandler{
+		repo:     repo,
+		cache:    cache,
+		async:    async,
+		logger:   logger,
+		cacheTTL: cacheTTL,
+	}
+}
+
+func (h *RecentUsersHandler) Handle(ctx context.Context, query RecentUsersQuery) ([]*domain.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "RecentUsersHandler.Handle")
+	defer span.End()
+
+	limit := query.Limit
+	if limit < 1 {
+		limit = DefaultRecentUsersLimit
+	}
+	limit = min(limit, MaxRecentUsersLimit)
+	span.SetAttributes(attribute.Int("recent.limit", limit))
+
+	users, err := h.cache.GetRecentUsers(ctx, limit)
+	if err != nil {
+		span.RecordError(err)
+		h.logger.Warn("cache read failed", "recent_limit", limit, "error", err)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", users != nil))
+	if users != nil {
+		return users, nil
+	}
+
+	users, err = h.repo.GetRecentlyCreated(ctx, limit)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	h.async.Submit(func(ctx context.Context) {
+		if err := h.cache.SetRecentUsers(ctx, limit, users, h.cacheTTL); err != nil {
+			h.logger.Warn("cache write failed", "recent_limit", limit, "error", err)
+		}
+	})
+
+	return users, nil
+}
@@ -0,0 +1,102 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+// limitRecordingRepository records the limits GetRecentlyCreated is asked for
+type limitRecordingRepository struct {
+	*domaintest.UserRepository
+	limits []int
+}
+
+func (r *limitRecordingRepository) GetRecentlyCreated(ctx context.Context, limit int) ([]*domain.User, error) {
+	r.limits = append(r.limits, limit)
+	return r.UserRepository.GetRecentlyCreated(ctx, limit)
+}
+
+func newRecentUsersHandler(t *testing.T, repo domain.UserRepository) (*RecentUsersHandler, *cache.WorkerPool) {
+	t.Helper()
+	redisCache, _ := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	t.Cleanup(func() { async.Shutdown(context.Background()) })
+	return NewRecentUsersHandler(repo, redisCache, async, time.Minute, slog.New(slog.DiscardHandler)), async
+}
+
+func TestRecentUsersNewestFirst(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	repo := domaintest.NewUserRepository(
+		&domain.User{ID: 1, CreatedAt: day(3)},
+		&domain.User{ID: 2, CreatedAt: day(1)},
+		&domain.User{ID: 3, CreatedAt: day(3)},
+		&domain.User{ID: 4, CreatedAt: day(2)},
+	)
+	h, _ := newRecentUsersHandler(t, repo)
+
+	users, err := h.Handle(context.Background(), RecentUsersQuery{Limit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int64
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	if len(ids) != 3 || ids[0] != 3 || ids[1] != 1 || ids[2] != 4 {
+		t.Errorf("recent users = %v, want [3 1 4]: newest first, ties by id", ids)
+	}
+}
+
+func TestRecentUsersLimitIsCapped(t *testing.T) {
+	tests := []struct {
+		limit, want int
+	}{
+		{0, DefaultRecentUsersLimit},
+		{-5, DefaultRecentUsersLimit},
+		{25, 25},
+		{MaxRecentUsersLimit, MaxRecentUsersLimit},
+		{MaxRecentUsersLimit + 1, MaxRecentUsersLimit},
+		{10000, MaxRecentUsersLimit},
+	}
+
+	for _, tt := range tests {
+		repo := &limitRecordingRepository{UserRepository: domaintest.NewUserRepository()}
+		h, _ := newRecentUsersHandler(t, repo)
+
+		if _, err := h.Handle(context.Background(), RecentUsersQuery{Limit: tt.limit}); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.limits) != 1 || repo.limits[0] != tt.want {
+			t.Errorf("limit %d queried %v, want %d", tt.limit, repo.limits, tt.want)
+		}
+	}
+}
+
+func TestRecentUsersAreCached(t *testing.T) {
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "Alice"})
+	h, async := newRecentUsersHandler(t, repo)
+
+	if _, err := h.Handle(context.Background(), RecentUsersQuery{Limit: 5}); err != nil {
+		t.Fatal(err)
+	}
+	async.Shutdown(context.Background())
+
+	users, err := h.Handle(context.Background(), RecentUsersQuery{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.Calls("GetRecentlyCreated"); n != 1 {
+		t.Errorf("repository queried %d times, want the second call served from cache", n)
+	}
+	if len(users) != 1 || users[0].Name != "Alice" {
+		t.Errorf("cached recent users = %v, want Alice", users)
+	}
+}
@@ -2,11 +2,15 @@ package query
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"strconv"
 
 	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
 type GetUserQuery struct {
@@ -14,14 +18,25 @@ type GetUserQuery struct {
 }
 
 type GetUserHandler struct {
-	repo  domain.UserRepository
-	cache *cache.RedisCache
+	repo   domain.UserRepository
+	cache  *cache.RedisCache
+	async  *cache.WorkerPool
+	logger *slog.Logger
+
+	// misses coalesces concurrent database loads of the same user on a cache miss
+	misses       singleflight.Group
+	singleflight bool
 }
 
-func NewGetUserHandler(repo domain.UserRepository, cache *cache.RedisCache) *GetUserHandler {
+// NewGetUserHandler creates a GetUserHandler; with singleflight enabled, concurrent cache
+// misses for the same id share one database query instead of stampeding Postgres
+func NewGetUserHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, singleflight bool, logger *slog.Logger) *GetUserHandler {
 	return &GetUserHandler{
-		repo:  repo,
-		cache: cache,
+		repo:         repo,
+		cache:        cache,
+		async:        async,
+		logger:       logger,
+		singleflight: singleflight,
 	}
 }
 
@@ -29,37 +44,70 @@ func (h *GetUserHandler) Handle(ctx context.Context, query GetUserQuery) (*domai
 	ctx, span := tracing.StartSpan(ctx, "GetUserHandler.Handle")
 	defer span.End()
 
-	// Try cache first
-	ctx, cacheSpan := tracing.StartSpan(ctx, "cache.GetUser")
-	user, err := h.cache.GetUser(ctx, query.ID)
-	cacheSpan.End()
+	span.SetAttributes(attribute.Int64("user.id", query.ID))
 
+	// Try cache first
+	cacheCtx, cacheSpan := tracing.StartSpan(ctx, "cache.GetUser")
+	user, err := h.cache.GetUser(cacheCtx, query.ID)
 	if err != nil {
-		log.Printf("Cache error: %v", err)
+		cacheSpan.RecordError(err)
+		span.RecordError(err)
+		h.logger.Warn("cache read failed", "user_id", query.ID, "error", err)
 	}
+	cacheSpan.End()
 
-	if user != nil {
-		log.Printf("Cache HIT for user ID: %d", query.ID)
+	hit := user != nil
+	span.SetAttributes(attribute.Bool("cache.hit", hit))
+
+	if hit {
+		span.AddEvent("cache_hit")
+		h.logger.Debug("cache hit", "user_id", query.ID)
 		return user, nil
 	}
 
-	log.Printf("Cache MISS for user ID: %d", query.ID)
+	span.AddEvent("cache_miss")
+	h.logger.Debug("cache miss", "user_id", query.ID)
 
+	if !h.singleflight {
+		return h.load(ctx, query.ID)
+	}
+
+	// Only one caller per id queries the database; the load must not be canceled
+	// just because that caller goes away while others are still waiting
+	loadCtx := context.WithoutCancel(ctx)
+	result := h.misses.DoChan(strconv.FormatInt(query.ID, 10), func() (interface{}, error) {
+		return h.load(loadCtx, query.ID)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-result:
+		span.SetAttributes(attribute.Bool("singleflight.shared", res.Shared))
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*domain.User), nil
+	}
+}
+
+// load reads the user from the database and caches it asynchronously
+func (h *GetUserHandler) load(ctx context.Context, id int64) (*domain.User, error) {
 	// Get from database
-	ctx, dbSpan := tracing.StartSpan(ctx, "repository.GetByID")
-	user, err = h.repo.GetByID(ctx, query.ID)
+	dbCtx, dbSpan := tracing.StartSpan(ctx, "repository.GetByID")
+	user, err := h.repo.GetByID(dbCtx, id)
 	dbSpan.End()
 
 	if err != nil {
-		return nil, domain.ErrUserNotFound
+		return nil, err
 	}
 
 	// Store in cache (async)
-	go func() {
-		if err := h.cache.SetUser(context.Background(), user); err != nil {
-			log.Printf("Failed to cache user: %v", err)
+	h.async.Submit(func(ctx context.Context) {
+		if err := h.cache.SetUser(ctx, user); err != nil {
+			h.logger.Warn("cache write failed", "user_id", user.ID, "error", err)
 		}
-	}()
+	})
 
 	return user, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,39 @@
+package query
+
+import (
+	"context"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type GetUserActivityQuery struct {
+	ID int64
+}
+
+type GetUserActivityHandler struct {
+	repo domain.UserRepository
+}
+
+func NewGetUserActivityHandler(repo domain.UserRepository) *GetUserActivityHandler {
+	return &GetUserActivityHandler{repo: repo}
+}
+
+func (h *GetUserActivityHandler) Handle(ctx context.Context, query GetUserActivityQuery) (*domain.UserActivity, error) {
+	ctx, span := tracing.StartSpan(ctx, "GetUserActivityHandler.Handle")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("user.id", query.ID))
+
+	activity, err := h.repo.GetActivity(ctx, query.ID)
+	if err != nil {
+		if err != domain.ErrUserNotFound {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,40 @@
+package query
+
+import (
+	"context"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type GetUserByUsernameQuery struct {
+	Username string
+}
+
+type GetUserByUsernameHandler struct {
+	repo domain.UserRepository
+}
+
+func NewGetUserByUsernameHandler(repo domain.UserRepository) *GetUserByUsernameHandler {
+	return &GetUserByUsernameHandler{repo: repo}
+}
+
+func (h *GetUserByUsernameHandler) Handle(ctx context.Context, query GetUserByUsernameQuery) (*domain.User, error) {
+	ctx, span := tracing.StartSpan(ctx, "GetUserByUsernameHandler.Handle")
+	defer span.End()
+
+	username := domain.NormalizeUsername(query.Username)
+	span.SetAttributes(attribute.String("user.username", username))
+
+	user, err := h.repo.GetByUsername(ctx, username)
+	if err != nil {
+		if err != domain.ErrUserNotFound {
+			span.RecordError(err)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,68 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type UserStatsQuery struct {
+	Days int // Lookback window for signups per day
+}
+
+type UserStatsHandler struct {
+	repo     domain.UserRepository
+	cache    *cache.RedisCache
+	async    *cache.WorkerPool
+	logger   *slog.Logger
+	cacheTTL time.Duration
+}
+
+// NewUserStatsHandler creates a UserStatsHandler; results are cached for cacheTTL since
+// the aggregate queries scan the whole users table
+func NewUserStatsHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, cacheTTL time.Duration, logger *slog.Logger) *UserStatsHandler {
+	return &UserStatsHandler{
+		repo:     repo,
+		cache:    cache,
+		async:    async,
+		logger:   logger,
+		cacheTTL: cacheTTL,
+	}
+}
+
+func (h *UserStatsHandler) Handle(ctx context.Context, query UserStatsQuery) (*domain.UserStats, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserStatsHandler.Handle")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int("stats.days", query.Days))
+
+	stats, err := h.cache.GetUserStats(ctx, query.Days)
+	if err != nil {
+		span.RecordError(err)
+		h.logger.Warn("cache read failed", "stats_days", query.Days, "error", err)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", stats != nil))
+	if stats != nil {
+		return stats, nil
+	}
+
+	stats, err = h.repo.GetStats(ctx, query.Days)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	h.async.Submit(func(ctx context.Context) {
+		if err := h.cache.SetUserStats(ctx, stats, h.cacheTTL); err != nil {
+			h.logger.Warn("cache write failed", "stats_days", query.Days, "error", err)
+		}
+	})
+
+	return stats, nil
+}
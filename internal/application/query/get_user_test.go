@@ -0,0 +1,198 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+// concurrentGets runs n concurrent GetUser queries for user 1 while the database lookup
+// is held until every query has missed the cache, and returns how many lookups ran
+func concurrentGets(t *testing.T, n int, singleflight bool) int {
+	t.Helper()
+
+	release := make(chan struct{})
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "Alice", Email: "alice@example.com"})
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "GetByID" {
+			<-release
+		}
+		return nil
+	}
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	defer async.Shutdown(context.Background())
+	h := NewGetUserHandler(repo, redisCache, async, singleflight, slog.New(slog.DiscardHandler))
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := h.Handle(context.Background(), GetUserQuery{ID: 1})
+			if err == nil && (user == nil || user.Name != "Alice") {
+				t.Errorf("got user %+v, want Alice", user)
+			}
+			errs <- err
+		}()
+	}
+
+	// Every query reads the cache before it joins or starts a database lookup
+	deadline := time.Now().Add(5 * time.Second)
+	for server.Calls("GET") < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo.Calls("GetByID")
+}
+
+func TestGetUserSingleflightCoalescesMisses(t *testing.T) {
+	if calls := concurrentGets(t, 20, true); calls != 1 {
+		t.Errorf("20 concurrent misses made %d database calls, want 1", calls)
+	}
+}
+
+func TestGetUserWithoutSingleflight(t *testing.T) {
+	if calls := concurrentGets(t, 5, false); calls != 5 {
+		t.Errorf("5 concurrent misses made %d database calls, want one each", calls)
+	}
+}
+
+func TestGetUserWaiterCanGiveUp(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "Alice"})
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "GetByID" {
+			<-release
+		}
+		return nil
+	}
+	redisCache, _ := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	h := NewGetUserHandler(repo, redisCache, async, true, slog.New(slog.DiscardHandler))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := h.Handle(ctx, GetUserQuery{ID: 1}); err != context.DeadlineExceeded {
+		t.Errorf("Handle = %v, want the caller's deadline while the shared lookup continues", err)
+	}
+}
+
+func TestGetUserCachesLoadedUser(t *testing.T) {
+	ctx := context.Background()
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "Alice"})
+	redisCache, _ := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	h := NewGetUserHandler(repo, redisCache, async, true, slog.New(slog.DiscardHandler))
+
+	if _, err := h.Handle(ctx, GetUserQuery{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	async.Shutdown(ctx)
+	if _, err := h.Handle(ctx, GetUserQuery{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := repo.Calls("GetByID"); calls != 1 {
+		t.Errorf("two gets made %d database calls, want the second served from cache", calls)
+	}
+}
+
+// logRecord is a log record captured by recordingLogHandler
+type logRecord struct {
+	level   slog.Level
+	message string
+}
+
+// recordingLogHandler captures records at or above level
+type recordingLogHandler struct {
+	level   slog.Level
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (h *recordingLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, logRecord{level: r.Level, message: r.Message})
+	return nil
+}
+
+func (h *recordingLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler { return h }
+func (h *recordingLogHandler) WithGroup(name string) slog.Handler       { return h }
+
+// logged returns the captured records
+func (h *recordingLogHandler) logged() []logRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]logRecord(nil), h.records...)
+}
+
+func TestGetUserLogsCacheErrorsAsWarnings(t *testing.T) {
+	ctx := context.Background()
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "Alice"})
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	logs := &recordingLogHandler{level: slog.LevelDebug}
+	h := NewGetUserHandler(repo, redisCache, async, true, slog.New(logs))
+
+	server.Close()
+	user, err := h.Handle(ctx, GetUserQuery{ID: 1})
+	async.Shutdown(ctx)
+
+	if err != nil || user == nil {
+		t.Fatalf("Handle with Redis down = %v, %v; want the user from the database", user, err)
+	}
+	want := []logRecord{
+		{slog.LevelWarn, "cache read failed"},
+		{slog.LevelDebug, "cache miss"},
+		{slog.LevelWarn, "cache write failed"},
+	}
+	if got := logs.logged(); !reflect.DeepEqual(got, want) {
+		t.Errorf("logged %v, want %v", got, want)
+	}
+}
+
+func TestGetUserCacheNoiseIsDebugOnly(t *testing.T) {
+	ctx := context.Background()
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "Alice"})
+	redisCache, _ := cachetest.NewRedisCache(t, time.Minute)
+	async := cache.NewWorkerPool(1, 10, nil)
+	logs := &recordingLogHandler{level: slog.LevelInfo}
+	h := NewGetUserHandler(repo, redisCache, async, true, slog.New(logs))
+
+	for i := 0; i < 2; i++ {
+		if _, err := h.Handle(ctx, GetUserQuery{ID: 1}); err != nil {
+			t.Fatal(err)
+		}
+		async.Shutdown(ctx)
+	}
+
+	if got := logs.logged(); len(got) != 0 {
+		t.Errorf("a healthy cache logged %v at info level, want nothing", got)
+	}
+}
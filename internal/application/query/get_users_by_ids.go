@@ -0,0 +1,114 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetUsersByIDsQuery resolves several users at once
+type GetUsersByIDsQuery struct {
+	IDs []int64
+	// IncludeSuspended also returns suspended users, which are reported as not found
+	// otherwise, like the list leaves them out
+	IncludeSuspended bool
+}
+
+// GetUsersByIDsResult holds the found users in request order and the ids that don't exist
+type GetUsersByIDsResult struct {
+	Users    []*domain.User
+	NotFound []int64
+}
+
+type GetUsersByIDsHandler struct {
+	repo   domain.UserRepository
+	cache  *cache.RedisCache
+	async  *cache.WorkerPool
+	logger *slog.Logger
+}
+
+func NewGetUsersByIDsHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, logger *slog.Logger) *GetUsersByIDsHandler {
+	return &GetUsersByIDsHandler{
+		repo:   repo,
+		cache:  cache,
+		async:  async,
+		logger: logger,
+	}
+}
+
+// Handle reads cache hits with one MGET and loads only the misses from the database
+func (h *GetUsersByIDsHandler) Handle(ctx context.Context, query GetUsersByIDsQuery) (*GetUsersByIDsResult, error) {
+	ctx, span := tracing.StartSpan(ctx, "GetUsersByIDsHandler.Handle")
+	defer span.End()
+
+	ids := uniqueIDs(query.IDs)
+	span.SetAttributes(attribute.Int("user.count", len(ids)))
+
+	found, err := h.cache.GetUsers(ctx, ids)
+	if err != nil {
+		span.RecordError(err)
+		h.logger.Warn("cache read failed", "user_count", len(ids), "error", err)
+		found = make(map[int64]*domain.User, len(ids))
+	}
+	span.SetAttributes(attribute.Int("cache.hits", len(found)))
+
+	var misses []int64
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			misses = append(misses, id)
+		}
+	}
+
+	if len(misses) > 0 {
+		loaded, err := h.repo.GetByIDs(ctx, misses)
+		if err != nil {
+			return nil, err
+		}
+
+		for id, user := range loaded {
+			found[id] = user
+		}
+
+		// Store in cache (async)
+		h.async.Submit(func(ctx context.Context) {
+			for _, user := range loaded {
+				if err := h.cache.SetUser(ctx, user); err != nil {
+					h.logger.Warn("cache write failed", "user_id", user.ID, "error", err)
+				}
+			}
+		})
+	}
+
+	result := &GetUsersByIDsResult{
+		Users:    make([]*domain.User, 0, len(ids)),
+		NotFound: []int64{},
+	}
+	for _, id := range ids {
+		if user, ok := found[id]; ok && (query.IncludeSuspended || !user.IsSuspended()) {
+			result.Users = append(result.Users, user)
+		} else {
+			result.NotFound = append(result.NotFound, id)
+		}
+	}
+
+	return result, nil
+}
+
+// uniqueIDs drops duplicate ids while keeping the first occurrence order
+func uniqueIDs(ids []int64) []int64 {
+	seen := make(map[int64]struct{}, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,50 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"user-crud/internal/domain"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// QueryLimiter caps how many expensive queries (list and search) run at once, so a burst
+// of them can't take every pooled connection away from cheap point lookups
+type QueryLimiter struct {
+	sem  *semaphore.Weighted
+	wait time.Duration
+}
+
+// NewQueryLimiter allows up to maxConcurrent queries, each waiting at most wait for a slot;
+// maxConcurrent <= 0 returns nil, which never throttles
+func NewQueryLimiter(maxConcurrent int, wait time.Duration) *QueryLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &QueryLimiter{sem: semaphore.NewWeighted(int64(maxConcurrent)), wait: wait}
+}
+
+// acquire takes a slot and returns the function that frees it, or ErrTooManyQueries
+// when no slot frees up in time
+func (l *QueryLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, l.wait)
+	defer cancel()
+
+	if err := l.sem.Acquire(waitCtx, 1); err != nil {
+		// The caller's own cancellation is not overload
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, domain.ErrTooManyQueries
+		}
+		return nil, err
+	}
+	return func() { l.sem.Release(1) }, nil
+}
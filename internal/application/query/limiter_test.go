@@ -0,0 +1,135 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+)
+
+// blockingRepository holds every FindWithFilters call until release is closed, counting
+// how many are in flight at once
+type blockingRepository struct {
+	*domaintest.UserRepository
+	release  chan struct{}
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func newBlockingRepository() *blockingRepository {
+	r := &blockingRepository{UserRepository: domaintest.NewUserRepository(), release: make(chan struct{})}
+	r.Before = func(ctx context.Context, method string) error {
+		if method != "FindWithFilters" {
+			return nil
+		}
+		n := r.inFlight.Add(1)
+		defer r.inFlight.Add(-1)
+		for {
+			peak := r.peak.Load()
+			if n <= peak || r.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		<-r.release
+		return nil
+	}
+	return r
+}
+
+// waitInFlight waits until n queries are running
+func (r *blockingRepository) waitInFlight(t *testing.T, n int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for r.inFlight.Load() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d queries in flight, want %d", r.inFlight.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQueryLimiterCapsConcurrency(t *testing.T) {
+	repo := newBlockingRepository()
+	h := NewListUsersHandler(repo, nil, nil, testPagination, nil, NewQueryLimiter(2, time.Second), 0, nil)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for range 5 {
+		wg.Go(func() {
+			_, err := h.Handle(context.Background(), ListUsersQuery{})
+			errs <- err
+		})
+	}
+
+	repo.waitInFlight(t, 2)
+	time.Sleep(20 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("queued query failed: %v", err)
+		}
+	}
+	if peak := repo.peak.Load(); peak != 2 {
+		t.Errorf("peak concurrency = %d, want the cap of 2", peak)
+	}
+}
+
+func TestQueryLimiterRejectsAfterWait(t *testing.T) {
+	repo := newBlockingRepository()
+	limiter := NewQueryLimiter(1, 20*time.Millisecond)
+	list := NewListUsersHandler(repo, nil, nil, testPagination, nil, limiter, 0, nil)
+	search := NewSearchUsersHandler(repo, 0.3, KeywordLength{Min: 1, Max: 100}, testPagination, limiter)
+
+	done := make(chan error)
+	go func() {
+		_, err := list.Handle(context.Background(), ListUsersQuery{})
+		done <- err
+	}()
+	repo.waitInFlight(t, 1)
+
+	if _, err := list.Handle(context.Background(), ListUsersQuery{}); !errors.Is(err, domain.ErrTooManyQueries) {
+		t.Errorf("list over the cap = %v, want ErrTooManyQueries", err)
+	}
+	if _, err := search.Handle(context.Background(), SearchUsersQuery{Keyword: "user"}); !errors.Is(err, domain.ErrTooManyQueries) {
+		t.Errorf("search over the cap = %v, want ErrTooManyQueries", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := list.Handle(ctx, ListUsersQuery{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller = %v, want its own context error", err)
+	}
+
+	close(repo.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := list.Handle(context.Background(), ListUsersQuery{}); err != nil {
+		t.Errorf("query after the slot was freed = %v", err)
+	}
+}
+
+func TestNilQueryLimiterNeverThrottles(t *testing.T) {
+	if limiter := NewQueryLimiter(0, time.Second); limiter != nil {
+		t.Fatal("NewQueryLimiter(0) returned a limiter, want nil")
+	}
+
+	repo := newBlockingRepository()
+	h := NewListUsersHandler(repo, nil, nil, testPagination, nil, nil, 0, nil)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { h.Handle(context.Background(), ListUsersQuery{}) })
+	}
+	repo.waitInFlight(t, 10)
+	close(repo.release)
+	wg.Wait()
+}
@@ -2,126 +2,388 @@ package query
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
 	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
 )
 
 // ListUsersQuery represents the query to list users with filters
 type ListUsersQuery struct {
-	Search   string // Search by name or email
-	AgeMin   int    // Minimum age filter
-	AgeMax   int    // Maximum age filter
-	SortBy   string // Sort field: "name", "email", "age", "created_at"
-	Order    string // Sort order: "asc" or "desc"
-	Page     int    // Page number (starts from 1)
-	Limit    int    // Items per page
+	IDs    []int64 // Only these user ids, if set
+	Search string  // Search by name or email
+	AgeMin int     // Minimum age filter
+	AgeMax int     // Maximum age filter
+	Ages   []int   // Only these exact ages, if set; ANDed with AgeMin and AgeMax like every filter
+	// InactiveSince keeps users who have not logged in since this time
+	InactiveSince *time.Time
+	// IncludeSuspended also returns suspended users, which are left out by default
+	IncludeSuspended bool
+	SortBy           string // Sort field: "name", "email", "age", "created_at", "updated_at"
+	Order            string // Sort order: "asc" or "desc"; empty uses the field's default
+	Page             int    // Page number (starts from 1)
+	Limit            int    // Items per page
+	// SkipTotal skips the COUNT query; the result reports HasMore but no totals
+	SkipTotal bool
+	// CursorMode pages by position instead of page number: Page is ignored, there is no
+	// COUNT query, and the result's NextCursor continues the listing
+	CursorMode bool
+	// After is the cursor to continue from in cursor mode; nil starts at the beginning
+	After *Cursor
+}
+
+// PageInfo describes one page of a paginated result
+type PageInfo struct {
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	TotalPages int   `json:"total_pages"`  // At least 1, so an empty result is an empty first page
+	OutOfRange bool  `json:"out_of_range"` // Page is past the last page
+	HasMore    bool  `json:"has_more"`     // A later page has results
+}
+
+// unknownTotal marks Total and TotalPages when the count was skipped
+const unknownTotal = -1
+
+// newPageInfo calculates the total pages and flags pages past the last one
+func newPageInfo(total int64, page, limit int) PageInfo {
+	totalPages := int(total) / limit
+	if int(total)%limit > 0 {
+		totalPages++
+	}
+	if totalPages < 1 {
+		totalPages = 1
+	}
+
+	return PageInfo{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		OutOfRange: page > totalPages,
+		HasMore:    page < totalPages,
+	}
+}
+
+// newPageInfoWithoutTotal describes a page whose total count is unknown
+func newPageInfoWithoutTotal(page, limit int, empty, hasMore bool) PageInfo {
+	return PageInfo{
+		Total:      unknownTotal,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: unknownTotal,
+		OutOfRange: empty && page > 1,
+		HasMore:    hasMore,
+	}
 }
 
 // ListUsersResult represents paginated user list result
 type ListUsersResult struct {
-	Users      []*domain.User `json:"users"`
-	Total      int64          `json:"total"`
-	Page       int            `json:"page"`
-	Limit      int            `json:"limit"`
-	TotalPages int            `json:"total_pages"`
+	Users  []*domain.User `json:"users"`
+	Scores []float64      `json:"scores,omitempty"` // Relevance per user, set by fuzzy search only
+	// NextCursor continues a cursor-mode listing; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	PageInfo
 }
 
 // ListUsersHandler handles listing users with filters
 type ListUsersHandler struct {
-	repo domain.UserRepository
+	repo          domain.UserRepository
+	cache         *cache.RedisCache
+	async         *cache.WorkerPool
+	logger        *slog.Logger
+	pagination    Pagination
+	defaultOrders map[string]string
+	limiter       *QueryLimiter
+	cacheTTL      time.Duration
 }
 
-// NewListUsersHandler creates a new ListUsersHandler
-func NewListUsersHandler(repo domain.UserRepository) *ListUsersHandler {
-	return &ListUsersHandler{repo: repo}
+// Pagination controls the page size applied to list and search queries
+type Pagination struct {
+	DefaultLimit int // Used when the query doesn't specify a limit
+	MaxLimit     int // Larger limits are clamped to this
 }
 
-// Handle executes the list users query with filters
-func (h *ListUsersHandler) Handle(ctx context.Context, query ListUsersQuery) (*ListUsersResult, error) {
-	// Set defaults
-	if query.Page < 1 {
-		query.Page = 1
+// apply defaults the page and limit and clamps the limit to the maximum
+func (p Pagination) apply(page, limit int) (int, int) {
+	if page < 1 {
+		page = 1
 	}
-	if query.Limit < 1 {
-		query.Limit = 10
+	if limit < 1 {
+		limit = p.DefaultLimit
 	}
-	if query.Limit > 100 {
-		query.Limit = 100 // Maximum 100 items per page
+	if limit > p.MaxLimit {
+		limit = p.MaxLimit
+	}
+	return page, limit
+}
+
+// NewListUsersHandler creates a new ListUsersHandler; defaultOrders maps sort fields to the
+// order used when none is given, and fields not in it sort ascending; limiter may be nil.
+// Pages are cached for cacheTTL under the current list generation, which every user write
+// bumps; a cacheTTL of 0 disables caching.
+func NewListUsersHandler(repo domain.UserRepository, cache *cache.RedisCache, async *cache.WorkerPool, pagination Pagination, defaultOrders map[string]string, limiter *QueryLimiter, cacheTTL time.Duration, logger *slog.Logger) *ListUsersHandler {
+	return &ListUsersHandler{
+		repo:          repo,
+		cache:         cache,
+		async:         async,
+		logger:        logger,
+		pagination:    pagination,
+		defaultOrders: defaultOrders,
+		limiter:       limiter,
+		cacheTTL:      cacheTTL,
 	}
+}
+
+// Handle executes the list users query with filters
+func (h *ListUsersHandler) Handle(ctx context.Context, query ListUsersQuery) (*ListUsersResult, error) {
+	// Set defaults
+	query.Page, query.Limit = h.pagination.apply(query.Page, query.Limit)
 	if query.SortBy == "" {
 		query.SortBy = "id"
 	}
+	if query.Order == "" {
+		query.Order = h.defaultOrders[query.SortBy]
+	}
 	if query.Order == "" {
 		query.Order = "asc"
 	}
+	if query.CursorMode {
+		// Cursors record the sort they were issued for, so settle the repository's
+		// fallbacks for unknown fields and orders here
+		if !cursorSortFields[query.SortBy] {
+			query.SortBy = "id"
+		}
+		if query.Order != "desc" {
+			query.Order = "asc"
+		}
+		if query.After != nil && (query.After.SortBy != query.SortBy || query.After.Order != query.Order) {
+			return nil, domain.ErrInvalidCursor
+		}
+		query.Page = 1
+		query.SkipTotal = true
+	}
+
+	users, total, err := h.findUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.SkipTotal {
+		// The repository fetched one extra row to tell whether another page exists
+		hasMore := len(users) > query.Limit
+		if hasMore {
+			users = users[:query.Limit]
+		}
+		result := &ListUsersResult{
+			Users:    users,
+			PageInfo: newPageInfoWithoutTotal(query.Page, query.Limit, len(users) == 0, hasMore),
+		}
+		if query.CursorMode && hasMore {
+			result.NextCursor = newCursor(users[len(users)-1], query.SortBy, query.Order).Encode()
+		}
+		return result, nil
+	}
+
+	return newListUsersResult(users, nil, total, query.Page, query.Limit), nil
+}
+
+// Count returns the page info of query without loading any users, for clients that only
+// want the totals
+func (h *ListUsersHandler) Count(ctx context.Context, query ListUsersQuery) (PageInfo, error) {
+	query.Page, query.Limit = h.pagination.apply(query.Page, query.Limit)
+
+	release, err := h.limiter.acquire(ctx)
+	if err != nil {
+		return PageInfo{}, err
+	}
+	defer release()
+
+	total, err := h.repo.CountWithFilters(ctx, query)
+	if err != nil {
+		return PageInfo{}, err
+	}
+
+	return newPageInfo(total, query.Page, query.Limit), nil
+}
+
+// findUsers returns the repository's results for query, from the list cache when possible
+func (h *ListUsersHandler) findUsers(ctx context.Context, query ListUsersQuery) ([]*domain.User, int64, error) {
+	// InactiveSince is relative to the current time, so its results are never asked for twice
+	cacheable := h.cacheTTL > 0 && query.InactiveSince == nil
+
+	var gen int64
+	var fingerprint string
+	if cacheable {
+		var err error
+		gen, err = h.cache.ListGeneration(ctx)
+		if err != nil {
+			h.logger.Warn("list generation read failed", "error", err)
+			cacheable = false
+		}
+	}
+	if cacheable {
+		fingerprint = queryFingerprint(query)
+		list, err := h.cache.GetUserList(ctx, gen, fingerprint)
+		if err != nil {
+			h.logger.Warn("cache read failed", "list_generation", gen, "error", err)
+		}
+		if list != nil {
+			return list.Users, list.Total, nil
+		}
+	}
+
+	release, err := h.limiter.acquire(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer release()
 
 	// Get filtered users from repository
 	users, total, err := h.repo.FindWithFilters(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// Calculate total pages
-	totalPages := int(total) / query.Limit
-	if int(total)%query.Limit > 0 {
-		totalPages++
+	if cacheable {
+		// Stored under the generation read before the query, so a write that lands
+		// meanwhile orphans this entry instead of leaving it stale
+		h.async.Submit(func(ctx context.Context) {
+			list := &cache.UserList{Users: users, Total: total}
+			if err := h.cache.SetUserList(ctx, gen, fingerprint, list, h.cacheTTL); err != nil {
+				h.logger.Warn("cache write failed", "list_generation", gen, "error", err)
+			}
+		})
 	}
 
-	return &ListUsersResult{
-		Users:      users,
-		Total:      total,
-		Page:       query.Page,
-		Limit:      query.Limit,
-		TotalPages: totalPages,
-	}, nil
+	return users, total, nil
+}
+
+// queryFingerprint identifies a normalized list query in cache keys
+func queryFingerprint(query ListUsersQuery) string {
+	data, _ := json.Marshal(query)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
 }
 
 // SearchUsersQuery represents the query to search users
 type SearchUsersQuery struct {
-	Keyword string
-	Page    int
-	Limit   int
+	Keyword   string
+	Mode      string  // Search mode: "" or "exact" for substring match, "fuzzy" for trigram similarity
+	Threshold float64 // Minimum similarity in fuzzy mode
+	// WithScores returns the relevance of each user in exact mode; fuzzy mode always does
+	WithScores bool
+	Page       int
+	Limit      int
 }
 
+// Search modes
+const (
+	SearchModeExact = "exact"
+	SearchModeFuzzy = "fuzzy"
+)
+
 // SearchUsersHandler handles user search
 type SearchUsersHandler struct {
-	repo domain.UserRepository
+	repo           domain.UserRepository
+	fuzzyThreshold float64
+	keywordLength  KeywordLength
+	pagination     Pagination
+	limiter        *QueryLimiter
+}
+
+// KeywordLength bounds the length of search keywords, in characters
+type KeywordLength struct {
+	Min int // Shorter keywords match too much of the table to be worth scanning for
+	Max int
+}
+
+// check rejects keywords outside the bounds, ignoring surrounding whitespace
+func (l KeywordLength) check(keyword string) error {
+	n := utf8.RuneCountInString(strings.TrimSpace(keyword))
+	if n < l.Min {
+		return fmt.Errorf("%w: must be at least %d characters", domain.ErrSearchKeywordTooShort, l.Min)
+	}
+	if n > l.Max {
+		return fmt.Errorf("%w: must be at most %d characters", domain.ErrSearchKeywordTooLong, l.Max)
+	}
+	return nil
 }
 
-// NewSearchUsersHandler creates a new SearchUsersHandler
-func NewSearchUsersHandler(repo domain.UserRepository) *SearchUsersHandler {
-	return &SearchUsersHandler{repo: repo}
+// NewSearchUsersHandler creates a new SearchUsersHandler; fuzzyThreshold is the
+// default minimum similarity for fuzzy searches that don't specify one; limiter may be nil
+func NewSearchUsersHandler(repo domain.UserRepository, fuzzyThreshold float64, keywordLength KeywordLength, pagination Pagination, limiter *QueryLimiter) *SearchUsersHandler {
+	return &SearchUsersHandler{repo: repo, fuzzyThreshold: fuzzyThreshold, keywordLength: keywordLength, pagination: pagination, limiter: limiter}
 }
 
 // Handle executes the search users query
 func (h *SearchUsersHandler) Handle(ctx context.Context, query SearchUsersQuery) (*ListUsersResult, error) {
+	if err := h.keywordLength.check(query.Keyword); err != nil {
+		return nil, err
+	}
+
 	// Set defaults
-	if query.Page < 1 {
-		query.Page = 1
+	query.Page, query.Limit = h.pagination.apply(query.Page, query.Limit)
+	if query.Threshold <= 0 {
+		query.Threshold = h.fuzzyThreshold
 	}
-	if query.Limit < 1 {
-		query.Limit = 10
+
+	release, err := h.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if query.Limit > 100 {
-		query.Limit = 100
+	defer release()
+
+	if query.Mode == SearchModeFuzzy {
+		scored, total, err := h.repo.FuzzySearch(ctx, query.Keyword, query.Threshold, query.Page, query.Limit)
+		if err == nil {
+			users, scores := splitScored(scored)
+			return newListUsersResult(users, scores, total, query.Page, query.Limit), nil
+		}
+		if !errors.Is(err, domain.ErrFuzzySearchUnavailable) {
+			return nil, err
+		}
+		log.Printf("Fuzzy search unavailable, falling back to substring search: %v", err)
 	}
 
 	// Search users
-	users, total, err := h.repo.Search(ctx, query.Keyword, query.Page, query.Limit)
+	scored, total, err := h.repo.Search(ctx, query.Keyword, query.Page, query.Limit)
 	if err != nil {
 		return nil, err
 	}
 
-	// Calculate total pages
-	totalPages := int(total) / query.Limit
-	if int(total)%query.Limit > 0 {
-		totalPages++
+	users, scores := splitScored(scored)
+	if !query.WithScores {
+		scores = nil
 	}
+	return newListUsersResult(users, scores, total, query.Page, query.Limit), nil
+}
 
+// splitScored separates scored users into parallel user and score slices
+func splitScored(scored []*domain.ScoredUser) ([]*domain.User, []float64) {
+	users := make([]*domain.User, len(scored))
+	scores := make([]float64, len(scored))
+	for i, s := range scored {
+		users[i] = s.User
+		scores[i] = s.Score
+	}
+	return users, scores
+}
+
+// newListUsersResult builds a paginated result of users
+func newListUsersResult(users []*domain.User, scores []float64, total int64, page, limit int) *ListUsersResult {
 	return &ListUsersResult{
-		Users:      users,
-		Total:      total,
-		Page:       query.Page,
-		Limit:      query.Limit,
-		TotalPages: totalPages,
-	}, nil
-}
\ No newline at end of file
+		Users:    users,
+		Scores:   scores,
+		PageInfo: newPageInfo(total, page, limit),
+	}
+}
@@ -0,0 +1,230 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+)
+
+// testPagination is the paging applied by handlers built with newListUsersHandler
+var testPagination = Pagination{DefaultLimit: 7, MaxLimit: 20}
+
+// newListUsersHandler returns a list handler without caching, concurrency limit or
+// default sort orders
+func newListUsersHandler(repo domain.UserRepository) *ListUsersHandler {
+	return NewListUsersHandler(repo, nil, nil, testPagination, nil, nil, 0, nil)
+}
+
+// capturingRepository returns a repository whose FindWithFilters records each query
+// and answers with users and total
+func capturingRepository(seen *[]ListUsersQuery, users []*domain.User, total int64) *domaintest.UserRepository {
+	repo := domaintest.NewUserRepository()
+	repo.FindWithFiltersFunc = func(filters interface{}) ([]*domain.User, int64, error) {
+		*seen = append(*seen, filters.(ListUsersQuery))
+		return users, total, nil
+	}
+	return repo
+}
+
+func TestPaginationApply(t *testing.T) {
+	tests := []struct {
+		name                string
+		page, limit         int
+		wantPage, wantLimit int
+	}{
+		{"defaults", 0, 0, 1, 7},
+		{"negative limit uses default", 2, -5, 2, 7},
+		{"within bounds", 3, 15, 3, 15},
+		{"at max", 1, 20, 1, 20},
+		{"clamped to max", 1, 500, 1, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, limit := testPagination.apply(tt.page, tt.limit)
+			if page != tt.wantPage || limit != tt.wantLimit {
+				t.Errorf("apply(%d, %d) = %d, %d; want %d, %d", tt.page, tt.limit, page, limit, tt.wantPage, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestListUsersAppliesConfiguredPagination(t *testing.T) {
+	tests := []struct {
+		limit     int
+		wantLimit int
+	}{
+		{0, 7},
+		{5, 5},
+		{100, 20},
+	}
+
+	for _, tt := range tests {
+		var seen []ListUsersQuery
+		h := newListUsersHandler(capturingRepository(&seen, nil, 0))
+
+		result, err := h.Handle(context.Background(), ListUsersQuery{Limit: tt.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[0].Limit != tt.wantLimit || seen[0].Page != 1 {
+			t.Errorf("limit %d queried page %d with limit %d, want page 1 with limit %d", tt.limit, seen[0].Page, seen[0].Limit, tt.wantLimit)
+		}
+		if result.Limit != tt.wantLimit {
+			t.Errorf("limit %d reported limit %d, want %d", tt.limit, result.Limit, tt.wantLimit)
+		}
+	}
+}
+
+func TestSearchUsersAppliesConfiguredPagination(t *testing.T) {
+	h := NewSearchUsersHandler(domaintest.NewUserRepository(), 0.3, KeywordLength{Min: 1, Max: 100}, testPagination, nil)
+
+	for limit, want := range map[int]int{0: 7, 5: 5, 100: 20} {
+		result, err := h.Handle(context.Background(), SearchUsersQuery{Keyword: "alice", Limit: limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Limit != want || result.Page != 1 {
+			t.Errorf("limit %d searched page %d with limit %d, want page 1 with limit %d", limit, result.Page, result.Limit, want)
+		}
+	}
+}
+
+func TestNewPageInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int64
+		page, limit int
+		want        PageInfo
+	}{
+		{"empty result", 0, 1, 10, PageInfo{Total: 0, Page: 1, Limit: 10, TotalPages: 1}},
+		{"empty result past the end", 0, 2, 10, PageInfo{Total: 0, Page: 2, Limit: 10, TotalPages: 1, OutOfRange: true}},
+		{"partial last page", 25, 3, 10, PageInfo{Total: 25, Page: 3, Limit: 10, TotalPages: 3}},
+		{"first of several", 25, 1, 10, PageInfo{Total: 25, Page: 1, Limit: 10, TotalPages: 3, HasMore: true}},
+		{"exact multiple", 20, 2, 10, PageInfo{Total: 20, Page: 2, Limit: 10, TotalPages: 2}},
+		{"past the end", 20, 5, 10, PageInfo{Total: 20, Page: 5, Limit: 10, TotalPages: 2, OutOfRange: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newPageInfo(tt.total, tt.page, tt.limit); got != tt.want {
+				t.Errorf("newPageInfo(%d, %d, %d) = %+v, want %+v", tt.total, tt.page, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchUsersEmptyAndPastTheEnd(t *testing.T) {
+	repo := domaintest.NewUserRepository(
+		&domain.User{ID: 1, Name: "Alice", Email: "alice@example.com"},
+		&domain.User{ID: 2, Name: "Alicia", Email: "alicia@example.com"},
+	)
+	h := NewSearchUsersHandler(repo, 0.3, KeywordLength{Min: 1, Max: 100}, testPagination, nil)
+
+	empty, err := h.Handle(context.Background(), SearchUsersQuery{Keyword: "nobody"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(empty.Users) != 0 || empty.TotalPages != 1 || empty.OutOfRange {
+		t.Errorf("search without matches = %+v, want an empty first page", empty.PageInfo)
+	}
+
+	past, err := h.Handle(context.Background(), SearchUsersQuery{Keyword: "ali", Page: 3, Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(past.Users) != 0 || past.Total != 2 || past.TotalPages != 2 || !past.OutOfRange {
+		t.Errorf("search past the last page = %+v, want no users flagged out of range", past.PageInfo)
+	}
+}
+
+func TestListUsersHasMoreWithoutTotal(t *testing.T) {
+	users := make([]*domain.User, 8)
+	for i := range users {
+		users[i] = &domain.User{ID: int64(i + 1)}
+	}
+
+	tests := []struct {
+		name        string
+		rows        int
+		wantUsers   int
+		wantHasMore bool
+	}{
+		{"extra row fetched", 8, 7, true},
+		{"exactly a page", 7, 7, false},
+		{"short page", 3, 3, false},
+		{"empty", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen []ListUsersQuery
+			h := newListUsersHandler(capturingRepository(&seen, users[:tt.rows], -1))
+
+			result, err := h.Handle(context.Background(), ListUsersQuery{SkipTotal: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result.Users) != tt.wantUsers || result.HasMore != tt.wantHasMore {
+				t.Errorf("got %d users with has_more %t, want %d with %t", len(result.Users), result.HasMore, tt.wantUsers, tt.wantHasMore)
+			}
+			if result.Total != unknownTotal || result.TotalPages != unknownTotal {
+				t.Errorf("total = %d, total_pages = %d; want both unknown", result.Total, result.TotalPages)
+			}
+			if !seen[0].SkipTotal {
+				t.Error("repository was asked for a total")
+			}
+		})
+	}
+}
+
+func TestSearchUsersScoresAreOptional(t *testing.T) {
+	repo := domaintest.NewUserRepository(&domain.User{ID: 1, Name: "John", Email: "john@example.com"})
+	h := NewSearchUsersHandler(repo, 0.3, KeywordLength{Min: 1, Max: 100}, testPagination, nil)
+
+	plain, err := h.Handle(context.Background(), SearchUsersQuery{Keyword: "john"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain.Scores != nil {
+		t.Errorf("scores = %v without with_score, want none", plain.Scores)
+	}
+
+	scored, err := h.Handle(context.Background(), SearchUsersQuery{Keyword: "john", WithScores: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scored.Scores) != len(scored.Users) || len(scored.Users) != 1 {
+		t.Errorf("got %d scores for %d users, want one each", len(scored.Scores), len(scored.Users))
+	}
+}
+
+func TestSearchKeywordLength(t *testing.T) {
+	repo := domaintest.NewUserRepository()
+	h := NewSearchUsersHandler(repo, 0.3, KeywordLength{Min: 2, Max: 5}, testPagination, nil)
+
+	tests := []struct {
+		keyword string
+		want    error
+	}{
+		{"a", domain.ErrSearchKeywordTooShort},
+		{"  a  ", domain.ErrSearchKeywordTooShort},
+		{"ab", nil},
+		{"ééééé", nil},
+		{"abcdef", domain.ErrSearchKeywordTooLong},
+	}
+
+	for _, tt := range tests {
+		_, err := h.Handle(context.Background(), SearchUsersQuery{Keyword: tt.keyword})
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("Handle(%q) = %v, want %v", tt.keyword, err, tt.want)
+		}
+	}
+	if n := repo.Calls("Search"); n != 2 {
+		t.Errorf("repository searched %d times, want only for the 2 valid keywords", n)
+	}
+}
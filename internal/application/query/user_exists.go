@@ -0,0 +1,56 @@
+package query
+
+import (
+	"context"
+	"log/slog"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type UserExistsQuery struct {
+	ID int64
+}
+
+type UserExistsHandler struct {
+	repo   domain.UserRepository
+	cache  *cache.RedisCache
+	logger *slog.Logger
+}
+
+func NewUserExistsHandler(repo domain.UserRepository, cache *cache.RedisCache, logger *slog.Logger) *UserExistsHandler {
+	return &UserExistsHandler{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+func (h *UserExistsHandler) Handle(ctx context.Context, query UserExistsQuery) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "UserExistsHandler.Handle")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("user.id", query.ID))
+
+	// A cached user is known to exist; a miss says nothing, so fall through to the database
+	cached, err := h.cache.HasUser(ctx, query.ID)
+	if err != nil {
+		span.RecordError(err)
+		h.logger.Warn("cache read failed", "user_id", query.ID, "error", err)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", cached))
+	if cached {
+		return true, nil
+	}
+
+	exists, err := h.repo.Exists(ctx, query.ID)
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -14,20 +20,392 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	ServerPort string
+
+	// Read replica; an empty host sends reads to the primary
+	DBReplicaHost     string
+	DBReplicaPort     string
+	DBReplicaUser     string
+	DBReplicaPassword string
+	DBReplicaName     string
+
+	// Database TLS
+	DBSSLMode     string
+	DBSSLRootCert string
+
+	// HTTP server timeouts
+	ServerReadTimeout       time.Duration
+	ServerReadHeaderTimeout time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+
+	// ShutdownTimeout is the total time allowed for draining requests and closing dependencies
+	ShutdownTimeout time.Duration
+
+	// StartupTimeout bounds how long startup waits for the database and Redis
+	StartupTimeout time.Duration
+
+	// Database connection pool
+	DBMaxConns        int
+	DBMinConns        int
+	DBMaxConnLifetime time.Duration
+
+	// Password policy
+	PasswordMinLength       int
+	PasswordRequireUpper    bool
+	PasswordRequireLower    bool
+	PasswordRequireDigit    bool
+	PasswordRequireSymbol   bool
+	PasswordDenylistEnabled bool
+	PasswordDenylistFile    string
+	// PasswordHasher is the scheme for new password hashes, "bcrypt" or "argon2id"; hashes
+	// of the other scheme still verify and are upgraded at login
+	PasswordHasher string
+
+	PasswordResetTokenTTL time.Duration
+	EmailChangeTokenTTL   time.Duration
+
+	// BlockedEmailDomains rejects signups and email changes to these domains ("*.example.com" matches subdomains)
+	BlockedEmailDomains []string
+
+	// PasswordReuseLimit is how many of a user's most recent passwords, including the
+	// current one, a new password must differ from; 0 disables the check
+	PasswordReuseLimit int
+
+	// Accepted user age range (inclusive)
+	MinAge int
+	MaxAge int
+
+	// Circuit breaker (applied per route)
+	CircuitBreakerMaxRequests  int
+	CircuitBreakerMinRequests  int
+	CircuitBreakerFailureRatio float64
+	CircuitBreakerTimeout      time.Duration
+
+	// IdempotencyTTL is how long responses to requests with an Idempotency-Key are kept
+	IdempotencyTTL time.Duration
+
+	// Page size used when a list or search request doesn't specify a limit, and the largest allowed
+	PaginationDefaultLimit int
+	PaginationMaxLimit     int
+	// PaginationMaxDepth caps page*limit so deep pages can't force huge OFFSET scans; 0 disables it
+	PaginationMaxDepth int
+
+	// SortDefaultOrders maps sort fields to the order used when a request omits one
+	SortDefaultOrders map[string]string
+
+	// FuzzySearchThreshold is the default minimum trigram similarity for fuzzy search
+	FuzzySearchThreshold float64
+	// SearchKeywordMinLength and SearchKeywordMaxLength bound /users/search keywords, in characters
+	SearchKeywordMinLength int
+	SearchKeywordMaxLength int
+
+	// UserStatsCacheTTL is how long GET /users/stats results are cached
+	UserStatsCacheTTL time.Duration
+	// RecentUsersCacheTTL is how long GET /users/recent results are cached
+	RecentUsersCacheTTL time.Duration
+	// ListCacheTTL is how long GET /users pages are cached; 0 disables the list cache
+	ListCacheTTL time.Duration
+
+	// CacheSingleflight coalesces concurrent database loads of the same user on a cache miss
+	CacheSingleflight bool
+
+	// Requests and queries slower than these thresholds are logged; 0 disables
+	SlowRequestThreshold time.Duration
+	SlowQueryThreshold   time.Duration
+
+	// MaxInFlight caps concurrently served requests; extra ones get 503. 0 disables the cap
+	MaxInFlight int
+
+	// PrettyJSON indents JSON responses for reading in a terminal; compact by default
+	PrettyJSON bool
+
+	// DebugBodyLog logs redacted request and response bodies, capped at DebugBodyLogMaxBytes each
+	DebugBodyLog         bool
+	DebugBodyLogMaxBytes int
+
+	// TracingEnabled turns the trace and metric export pipelines on; spans are no-ops when off
+	TracingEnabled bool
+	// TracingQueueSize is how many spans may wait for export before new ones are dropped
+	TracingQueueSize int
+	// TelemetryErrorLogInterval is how often failed telemetry exports are summarized in the log
+	TelemetryErrorLogInterval time.Duration
+
+	// ListQueryConcurrency caps concurrent list and search queries (0 disables the cap);
+	// requests that wait longer than ListQueryWait for a slot get 503
+	ListQueryConcurrency int
+	ListQueryWait        time.Duration
+
+	// CacheLogLevel is the minimum level logged by the cache layer; hits and misses are debug
+	CacheLogLevel slog.Level
+
+	// CacheWarmOnStart preloads users into Redis in the background after startup: the ids in
+	// CacheWarmIDs when set, otherwise the CacheWarmLimit most recently active users
+	CacheWarmOnStart bool
+	CacheWarmLimit   int
+	CacheWarmIDs     []int64
+
+	// Redis connection pool
+	RedisPoolSize     int
+	RedisDialTimeout  time.Duration
+	RedisReadTimeout  time.Duration
+	RedisWriteTimeout time.Duration
+	RedisMaxRetries   int
+	// CacheOpTimeout bounds every Redis command, including background cache writes
+	CacheOpTimeout time.Duration
+
+	// Domain events published to Redis Pub/Sub
+	EventsEnabled bool
+	EventsChannel string
+
+	// Transactional outbox relay
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration
+
+	// Webhook receiving user events; disabled when WebhookURL is empty
+	WebhookURL         string
+	WebhookSecret      string
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int
+	WebhookQueueSize   int
+
+	// TrustedProxies are the proxy IPs or CIDRs whose X-Forwarded-For header is believed
+	// when resolving the client IP; empty trusts none and uses the connection address
+	TrustedProxies []string
+
+	// Maintenance mode rejects writes with 503; MaintenanceMode forces it on at startup,
+	// otherwise it is toggled at runtime through the admin endpoint
+	MaintenanceMode       bool
+	MaintenanceRetryAfter time.Duration
+	MaintenanceAllowlist  []string
+
+	// AdminToken authorizes admin endpoints; they are disabled when empty
+	AdminToken string
+
+	// APIKeys authenticate internal services via X-API-Key; batch endpoints require one when set
+	APIKeys []string
+
+	// RateLimitMode is "enforce" to reject excess requests or "monitor" to only report them
+	RateLimitMode string
+	// Per-IP limits for reads (GET, HEAD, OPTIONS) and for writes, which are limited separately
+	RateLimitReadRPS    float64
+	RateLimitReadBurst  int
+	RateLimitWriteRPS   float64
+	RateLimitWriteBurst int
+
+	// HideUserEnumeration makes create and login responses reveal nothing about registered emails
+	HideUserEnumeration bool
 }
 
+const (
+	defaultDBMaxConns        = 10
+	defaultDBMinConns        = 2
+	defaultDBMaxConnLifetime = time.Hour
+
+	defaultServerReadTimeout       = 15 * time.Second
+	defaultServerReadHeaderTimeout = 5 * time.Second
+	defaultServerWriteTimeout      = 15 * time.Second
+	defaultServerIdleTimeout       = 60 * time.Second
+	defaultShutdownTimeout         = 10 * time.Second
+
+	defaultRedisPoolSize     = 10
+	defaultRedisDialTimeout  = 5 * time.Second
+	defaultRedisReadTimeout  = 3 * time.Second
+	defaultRedisWriteTimeout = 3 * time.Second
+	defaultRedisMaxRetries   = 3
+	defaultCacheOpTimeout    = time.Second
+
+	defaultPasswordHasher = "bcrypt"
+
+	defaultMinAge = 0
+	defaultMaxAge = 150
+
+	defaultPaginationLimit    = 10
+	defaultPaginationMaxLimit = 100
+	defaultPaginationMaxDepth = 10000
+
+	defaultSearchKeywordMinLength = 2
+	defaultSearchKeywordMaxLength = 100
+
+	defaultRateLimitReadRPS    = 10
+	defaultRateLimitReadBurst  = 20
+	defaultRateLimitWriteRPS   = 5
+	defaultRateLimitWriteBurst = 10
+
+	defaultListQueryConcurrency = 5
+	defaultListQueryWait        = 250 * time.Millisecond
+
+	defaultCacheWarmLimit = 1000
+
+	defaultWebhookTimeout     = 5 * time.Second
+	defaultWebhookMaxAttempts = 3
+	defaultWebhookQueueSize   = 1000
+
+	defaultSlowRequestThreshold = time.Second
+	defaultSlowQueryThreshold   = 250 * time.Millisecond
+
+	defaultTracingQueueSize          = 2048
+	defaultTelemetryErrorLogInterval = time.Minute
+)
+
 func Load() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
 	cfg := &Config{
-		DBHost:     getEnv("DB_HOST", "postgres"),      // ✅ GANTI: "localhost" → "postgres"
+		DBHost:     getEnv("DB_HOST", "postgres"), // ✅ GANTI: "localhost" → "postgres"
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "userdb"),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+
+		DBSSLMode:     getEnv("DB_SSLMODE", "disable"),
+		DBSSLRootCert: getEnv("DB_SSLROOTCERT", ""),
+
+		ServerReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", defaultServerReadTimeout),
+		ServerReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", defaultServerReadHeaderTimeout),
+		ServerWriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", defaultServerWriteTimeout),
+		ServerIdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", defaultServerIdleTimeout),
+
+		StartupTimeout:  getEnvDuration("STARTUP_TIMEOUT", 2*time.Minute),
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout),
+
+		DBMaxConns:        getEnvInt("DB_MAX_CONNS", defaultDBMaxConns),
+		DBMinConns:        getEnvInt("DB_MIN_CONNS", defaultDBMinConns),
+		DBMaxConnLifetime: getEnvDuration("DB_MAX_CONN_LIFETIME", defaultDBMaxConnLifetime),
+
+		PasswordMinLength:       getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:    getEnvBool("PASSWORD_REQUIRE_UPPER", true),
+		PasswordRequireLower:    getEnvBool("PASSWORD_REQUIRE_LOWER", true),
+		PasswordRequireDigit:    getEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol:   getEnvBool("PASSWORD_REQUIRE_SYMBOL", true),
+		PasswordDenylistEnabled: getEnvBool("PASSWORD_DENYLIST_ENABLED", true),
+		PasswordDenylistFile:    getEnv("PASSWORD_DENYLIST_FILE", ""),
+		PasswordHasher:          getEnv("PASSWORD_HASHER", defaultPasswordHasher),
+
+		PasswordResetTokenTTL: getEnvDuration("PASSWORD_RESET_TOKEN_TTL", 15*time.Minute),
+		EmailChangeTokenTTL:   getEnvDuration("EMAIL_CHANGE_TOKEN_TTL", time.Hour),
+
+		BlockedEmailDomains: getEnvList("BLOCKED_EMAIL_DOMAINS", ""),
+
+		PasswordReuseLimit: getEnvInt("PASSWORD_REUSE_LIMIT", 0),
+
+		MinAge: getEnvInt("MIN_AGE", defaultMinAge),
+		MaxAge: getEnvInt("MAX_AGE", defaultMaxAge),
+
+		CircuitBreakerMaxRequests:  getEnvInt("CB_MAX_REQUESTS", 3),
+		CircuitBreakerMinRequests:  getEnvInt("CB_MIN_REQUESTS", 3),
+		CircuitBreakerFailureRatio: getEnvFloat("CB_FAILURE_RATIO", 0.6),
+		CircuitBreakerTimeout:      getEnvDuration("CB_TIMEOUT", 60*time.Second),
+
+		IdempotencyTTL: getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		HideUserEnumeration: getEnvBool("HIDE_USER_ENUMERATION", false),
+
+		RateLimitMode:       getEnv("RATE_LIMIT_MODE", "enforce"),
+		RateLimitReadRPS:    getEnvFloat("RATE_LIMIT_READ_RPS", defaultRateLimitReadRPS),
+		RateLimitReadBurst:  getEnvInt("RATE_LIMIT_READ_BURST", defaultRateLimitReadBurst),
+		RateLimitWriteRPS:   getEnvFloat("RATE_LIMIT_WRITE_RPS", defaultRateLimitWriteRPS),
+		RateLimitWriteBurst: getEnvInt("RATE_LIMIT_WRITE_BURST", defaultRateLimitWriteBurst),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", ""),
+
+		MaintenanceMode:       getEnvBool("MAINTENANCE_MODE", false),
+		MaintenanceRetryAfter: getEnvDuration("MAINTENANCE_RETRY_AFTER", 60*time.Second),
+		MaintenanceAllowlist:  getEnvList("MAINTENANCE_ALLOWLIST", "/health,/ready"),
+
+		// Read directly rather than via getEnv so the secret is never logged
+		AdminToken: os.Getenv("ADMIN_TOKEN"),
+		APIKeys:    getEnvList("API_KEYS", ""),
+
+		UserStatsCacheTTL:   getEnvDuration("USER_STATS_CACHE_TTL", time.Minute),
+		RecentUsersCacheTTL: getEnvDuration("RECENT_USERS_CACHE_TTL", 30*time.Second),
+		ListCacheTTL:        getEnvDuration("LIST_CACHE_TTL", 30*time.Second),
+
+		CacheSingleflight: getEnvBool("CACHE_SINGLEFLIGHT", true),
+		CacheLogLevel:     getEnvLogLevel("CACHE_LOG_LEVEL", slog.LevelInfo),
+
+		CacheWarmOnStart: getEnvBool("CACHE_WARM_ON_START", false),
+		CacheWarmLimit:   getEnvInt("CACHE_WARM_LIMIT", defaultCacheWarmLimit),
+		CacheWarmIDs:     parseCacheWarmIDs(getEnvList("CACHE_WARM_IDS", "")),
+
+		TracingEnabled:            getEnvBool("TRACING_ENABLED", true),
+		TracingQueueSize:          getEnvInt("TRACING_QUEUE_SIZE", defaultTracingQueueSize),
+		TelemetryErrorLogInterval: getEnvDuration("TELEMETRY_ERROR_LOG_INTERVAL", defaultTelemetryErrorLogInterval),
+
+		SlowRequestThreshold: getEnvDuration("SLOW_REQUEST_THRESHOLD", defaultSlowRequestThreshold),
+		SlowQueryThreshold:   getEnvDuration("SLOW_QUERY_THRESHOLD", defaultSlowQueryThreshold),
+
+		MaxInFlight: getEnvInt("MAX_IN_FLIGHT", 0),
+
+		PrettyJSON: getEnvBool("PRETTY_JSON", false),
+
+		DebugBodyLog:         getEnvBool("DEBUG_BODY_LOG", false),
+		DebugBodyLogMaxBytes: getEnvInt("DEBUG_BODY_LOG_MAX_BYTES", 4096),
+
+		RedisPoolSize:     getEnvInt("REDIS_POOL_SIZE", defaultRedisPoolSize),
+		RedisDialTimeout:  getEnvDuration("REDIS_DIAL_TIMEOUT", defaultRedisDialTimeout),
+		RedisReadTimeout:  getEnvDuration("REDIS_READ_TIMEOUT", defaultRedisReadTimeout),
+		RedisWriteTimeout: getEnvDuration("REDIS_WRITE_TIMEOUT", defaultRedisWriteTimeout),
+		RedisMaxRetries:   getEnvInt("REDIS_MAX_RETRIES", defaultRedisMaxRetries),
+		CacheOpTimeout:    getEnvDuration("CACHE_OP_TIMEOUT", defaultCacheOpTimeout),
+
+		EventsEnabled: getEnvBool("EVENTS_ENABLED", false),
+		EventsChannel: getEnv("EVENTS_CHANNEL", "user-events"),
+
+		OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxRetention:    getEnvDuration("OUTBOX_RETENTION", 24*time.Hour),
+
+		WebhookURL:         getEnv("WEBHOOK_URL", ""),
+		WebhookSecret:      os.Getenv("WEBHOOK_SECRET"),
+		WebhookTimeout:     getEnvDuration("WEBHOOK_TIMEOUT", defaultWebhookTimeout),
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts),
+		WebhookQueueSize:   getEnvInt("WEBHOOK_QUEUE_SIZE", defaultWebhookQueueSize),
+
+		PaginationDefaultLimit: getEnvInt("PAGINATION_DEFAULT_LIMIT", defaultPaginationLimit),
+		PaginationMaxLimit:     getEnvInt("PAGINATION_MAX_LIMIT", defaultPaginationMaxLimit),
+		PaginationMaxDepth:     getEnvInt("PAGINATION_MAX_DEPTH", defaultPaginationMaxDepth),
+
+		ListQueryConcurrency: getEnvInt("LIST_QUERY_CONCURRENCY", defaultListQueryConcurrency),
+		ListQueryWait:        getEnvDuration("LIST_QUERY_WAIT", defaultListQueryWait),
+
+		SortDefaultOrders: parseSortDefaultOrders(getEnvList("SORT_DEFAULT_ORDERS", "created_at:desc,updated_at:desc")),
+
+		FuzzySearchThreshold: getEnvFloat("FUZZY_SEARCH_THRESHOLD", 0.3),
+
+		SearchKeywordMinLength: getEnvInt("SEARCH_KEYWORD_MIN_LENGTH", defaultSearchKeywordMinLength),
+		SearchKeywordMaxLength: getEnvInt("SEARCH_KEYWORD_MAX_LENGTH", defaultSearchKeywordMaxLength),
+	}
+
+	// Unset replica settings default to the primary's
+	cfg.DBReplicaHost = getEnv("DB_REPLICA_HOST", "")
+	cfg.DBReplicaPort = getEnv("DB_REPLICA_PORT", cfg.DBPort)
+	cfg.DBReplicaUser = getEnv("DB_REPLICA_USER", cfg.DBUser)
+	cfg.DBReplicaPassword = getEnv("DB_REPLICA_PASSWORD", cfg.DBPassword)
+	cfg.DBReplicaName = getEnv("DB_REPLICA_NAME", cfg.DBName)
+
+	cfg.validateDBPool()
+	cfg.validateAgeBounds()
+	cfg.validatePasswordHasher()
+	cfg.validateServerTimeouts()
+	cfg.validatePagination()
+	cfg.validateRedisPool()
+	cfg.validateTelemetry()
+
+	if cfg.RateLimitMode != "enforce" && cfg.RateLimitMode != "monitor" {
+		log.Printf("⚠️  Invalid RATE_LIMIT_MODE %q, using default: enforce", cfg.RateLimitMode)
+		cfg.RateLimitMode = "enforce"
+	}
+	cfg.validateRateLimits()
+
+	cfg.validateTrustedProxies()
+	cfg.validateWebhook()
+	cfg.validateSearchKeywordLength()
+
+	if cfg.FuzzySearchThreshold <= 0 || cfg.FuzzySearchThreshold > 1 {
+		log.Printf("⚠️  FUZZY_SEARCH_THRESHOLD must be in (0, 1], got %g, using default: 0.3", cfg.FuzzySearchThreshold)
+		cfg.FuzzySearchThreshold = 0.3
 	}
 
 	// Log configuration untuk debugging
@@ -35,11 +413,284 @@ func Load() *Config {
 	log.Printf("   DB Host: %s", cfg.DBHost)
 	log.Printf("   DB Port: %s", cfg.DBPort)
 	log.Printf("   DB Name: %s", cfg.DBName)
+	if cfg.DBReplicaHost != "" {
+		log.Printf("   DB Replica: %s:%s", cfg.DBReplicaHost, cfg.DBReplicaPort)
+	}
 	log.Printf("   Server Port: %s", cfg.ServerPort)
 
 	return cfg
 }
 
+// validSSLModes lists the sslmode values accepted for database connections
+var validSSLModes = map[string]bool{
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// ValidateSSLMode returns an error if DBSSLMode is not a supported sslmode
+func (c *Config) ValidateSSLMode() error {
+	if !validSSLModes[c.DBSSLMode] {
+		return fmt.Errorf("invalid DB_SSLMODE %q: must be one of disable, require, verify-ca, verify-full", c.DBSSLMode)
+	}
+	return nil
+}
+
+// ReplicaConfig returns a copy of the configuration that connects to the read replica,
+// or nil when no replica is configured. Pool and TLS settings are shared with the primary.
+func (c *Config) ReplicaConfig() *Config {
+	if c.DBReplicaHost == "" {
+		return nil
+	}
+	replica := *c
+	replica.DBHost = c.DBReplicaHost
+	replica.DBPort = c.DBReplicaPort
+	replica.DBUser = c.DBReplicaUser
+	replica.DBPassword = c.DBReplicaPassword
+	replica.DBName = c.DBReplicaName
+	return &replica
+}
+
+// validateDBPool falls back to defaults when the pool settings are inconsistent
+func (c *Config) validateDBPool() {
+	if c.DBMaxConns <= 0 {
+		log.Printf("⚠️  DB_MAX_CONNS must be positive, got %d, using default: %d", c.DBMaxConns, defaultDBMaxConns)
+		c.DBMaxConns = defaultDBMaxConns
+	}
+	if c.DBMinConns <= 0 {
+		log.Printf("⚠️  DB_MIN_CONNS must be positive, got %d, using default: %d", c.DBMinConns, defaultDBMinConns)
+		c.DBMinConns = defaultDBMinConns
+	}
+	if c.DBMinConns > c.DBMaxConns {
+		log.Printf("⚠️  DB_MIN_CONNS (%d) exceeds DB_MAX_CONNS (%d), using DB_MIN_CONNS = %d", c.DBMinConns, c.DBMaxConns, c.DBMaxConns)
+		c.DBMinConns = c.DBMaxConns
+	}
+	if c.DBMaxConnLifetime <= 0 {
+		log.Printf("⚠️  DB_MAX_CONN_LIFETIME must be positive, got %v, using default: %v", c.DBMaxConnLifetime, defaultDBMaxConnLifetime)
+		c.DBMaxConnLifetime = defaultDBMaxConnLifetime
+	}
+}
+
+// validateAgeBounds falls back to defaults when the age range is inconsistent
+func (c *Config) validateAgeBounds() {
+	if c.MinAge < 0 {
+		log.Printf("⚠️  MIN_AGE must not be negative, got %d, using default: %d", c.MinAge, defaultMinAge)
+		c.MinAge = defaultMinAge
+	}
+	if c.MaxAge < c.MinAge {
+		log.Printf("⚠️  MAX_AGE (%d) is below MIN_AGE (%d), using defaults: %d-%d", c.MaxAge, c.MinAge, defaultMinAge, defaultMaxAge)
+		c.MinAge = defaultMinAge
+		c.MaxAge = defaultMaxAge
+	}
+}
+
+// validatePasswordHasher falls back to bcrypt for an unknown PASSWORD_HASHER
+func (c *Config) validatePasswordHasher() {
+	if c.PasswordHasher != "bcrypt" && c.PasswordHasher != "argon2id" {
+		log.Printf("⚠️  PASSWORD_HASHER must be bcrypt or argon2id, got %q, using default: %s", c.PasswordHasher, defaultPasswordHasher)
+		c.PasswordHasher = defaultPasswordHasher
+	}
+}
+
+// validateServerTimeouts falls back to defaults for non-positive server and shutdown timeouts
+func (c *Config) validateServerTimeouts() {
+	timeouts := []struct {
+		name     string
+		value    *time.Duration
+		fallback time.Duration
+	}{
+		{"SERVER_READ_TIMEOUT", &c.ServerReadTimeout, defaultServerReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", &c.ServerReadHeaderTimeout, defaultServerReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", &c.ServerWriteTimeout, defaultServerWriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", &c.ServerIdleTimeout, defaultServerIdleTimeout},
+		{"SHUTDOWN_TIMEOUT", &c.ShutdownTimeout, defaultShutdownTimeout},
+	}
+	for _, t := range timeouts {
+		if *t.value <= 0 {
+			log.Printf("⚠️  %s must be positive, got %v, using default: %v", t.name, *t.value, t.fallback)
+			*t.value = t.fallback
+		}
+	}
+}
+
+// validateTelemetry falls back to defaults for non-positive tracing queue and log settings
+func (c *Config) validateTelemetry() {
+	if c.TracingQueueSize <= 0 {
+		log.Printf("⚠️  TRACING_QUEUE_SIZE must be positive, got %d, using default: %d", c.TracingQueueSize, defaultTracingQueueSize)
+		c.TracingQueueSize = defaultTracingQueueSize
+	}
+	if c.TelemetryErrorLogInterval <= 0 {
+		log.Printf("⚠️  TELEMETRY_ERROR_LOG_INTERVAL must be positive, got %v, using default: %v", c.TelemetryErrorLogInterval, defaultTelemetryErrorLogInterval)
+		c.TelemetryErrorLogInterval = defaultTelemetryErrorLogInterval
+	}
+}
+
+// validateRedisPool falls back to defaults for non-positive pool settings
+func (c *Config) validateRedisPool() {
+	if c.RedisPoolSize <= 0 {
+		log.Printf("⚠️  REDIS_POOL_SIZE must be positive, got %d, using default: %d", c.RedisPoolSize, defaultRedisPoolSize)
+		c.RedisPoolSize = defaultRedisPoolSize
+	}
+	timeouts := []struct {
+		name     string
+		value    *time.Duration
+		fallback time.Duration
+	}{
+		{"REDIS_DIAL_TIMEOUT", &c.RedisDialTimeout, defaultRedisDialTimeout},
+		{"REDIS_READ_TIMEOUT", &c.RedisReadTimeout, defaultRedisReadTimeout},
+		{"REDIS_WRITE_TIMEOUT", &c.RedisWriteTimeout, defaultRedisWriteTimeout},
+		{"CACHE_OP_TIMEOUT", &c.CacheOpTimeout, defaultCacheOpTimeout},
+	}
+	for _, t := range timeouts {
+		if *t.value <= 0 {
+			log.Printf("⚠️  %s must be positive, got %v, using default: %v", t.name, *t.value, t.fallback)
+			*t.value = t.fallback
+		}
+	}
+	if c.RedisMaxRetries < 0 {
+		log.Printf("⚠️  REDIS_MAX_RETRIES must not be negative, got %d, using default: %d", c.RedisMaxRetries, defaultRedisMaxRetries)
+		c.RedisMaxRetries = defaultRedisMaxRetries
+	}
+	if c.CacheWarmLimit <= 0 {
+		log.Printf("⚠️  CACHE_WARM_LIMIT must be positive, got %d, using default: %d", c.CacheWarmLimit, defaultCacheWarmLimit)
+		c.CacheWarmLimit = defaultCacheWarmLimit
+	}
+}
+
+// validateSearchKeywordLength falls back to defaults when the keyword bounds are inconsistent
+func (c *Config) validateSearchKeywordLength() {
+	if c.SearchKeywordMinLength < 1 {
+		log.Printf("⚠️  SEARCH_KEYWORD_MIN_LENGTH must be positive, got %d, using default: %d", c.SearchKeywordMinLength, defaultSearchKeywordMinLength)
+		c.SearchKeywordMinLength = defaultSearchKeywordMinLength
+	}
+	if c.SearchKeywordMaxLength < c.SearchKeywordMinLength {
+		log.Printf("⚠️  SEARCH_KEYWORD_MAX_LENGTH (%d) is below SEARCH_KEYWORD_MIN_LENGTH (%d), using default: %d", c.SearchKeywordMaxLength, c.SearchKeywordMinLength, defaultSearchKeywordMaxLength)
+		c.SearchKeywordMaxLength = max(defaultSearchKeywordMaxLength, c.SearchKeywordMinLength)
+	}
+}
+
+// validatePagination falls back to defaults when the page size limits are inconsistent
+func (c *Config) validatePagination() {
+	if c.PaginationMaxLimit <= 0 {
+		log.Printf("⚠️  PAGINATION_MAX_LIMIT must be positive, got %d, using default: %d", c.PaginationMaxLimit, defaultPaginationMaxLimit)
+		c.PaginationMaxLimit = defaultPaginationMaxLimit
+	}
+	if c.PaginationDefaultLimit <= 0 {
+		log.Printf("⚠️  PAGINATION_DEFAULT_LIMIT must be positive, got %d, using default: %d", c.PaginationDefaultLimit, defaultPaginationLimit)
+		c.PaginationDefaultLimit = defaultPaginationLimit
+	}
+	if c.PaginationDefaultLimit > c.PaginationMaxLimit {
+		log.Printf("⚠️  PAGINATION_DEFAULT_LIMIT (%d) exceeds PAGINATION_MAX_LIMIT (%d), using PAGINATION_DEFAULT_LIMIT = %d", c.PaginationDefaultLimit, c.PaginationMaxLimit, c.PaginationMaxLimit)
+		c.PaginationDefaultLimit = c.PaginationMaxLimit
+	}
+	if c.PaginationMaxDepth < 0 {
+		log.Printf("⚠️  PAGINATION_MAX_DEPTH must not be negative, got %d, using default: %d", c.PaginationMaxDepth, defaultPaginationMaxDepth)
+		c.PaginationMaxDepth = defaultPaginationMaxDepth
+	}
+	if c.ListQueryConcurrency < 0 {
+		log.Printf("⚠️  LIST_QUERY_CONCURRENCY must not be negative, got %d, using default: %d", c.ListQueryConcurrency, defaultListQueryConcurrency)
+		c.ListQueryConcurrency = defaultListQueryConcurrency
+	}
+	if c.ListQueryWait <= 0 {
+		log.Printf("⚠️  LIST_QUERY_WAIT must be positive, got %v, using default: %v", c.ListQueryWait, defaultListQueryWait)
+		c.ListQueryWait = defaultListQueryWait
+	}
+}
+
+// validateRateLimits falls back to the defaults for non-positive rates and bursts
+func (c *Config) validateRateLimits() {
+	rates := []struct {
+		name     string
+		value    *float64
+		fallback float64
+	}{
+		{"RATE_LIMIT_READ_RPS", &c.RateLimitReadRPS, defaultRateLimitReadRPS},
+		{"RATE_LIMIT_WRITE_RPS", &c.RateLimitWriteRPS, defaultRateLimitWriteRPS},
+	}
+	for _, r := range rates {
+		if *r.value <= 0 {
+			log.Printf("⚠️  %s must be positive, got %v, using default: %v", r.name, *r.value, r.fallback)
+			*r.value = r.fallback
+		}
+	}
+	bursts := []struct {
+		name     string
+		value    *int
+		fallback int
+	}{
+		{"RATE_LIMIT_READ_BURST", &c.RateLimitReadBurst, defaultRateLimitReadBurst},
+		{"RATE_LIMIT_WRITE_BURST", &c.RateLimitWriteBurst, defaultRateLimitWriteBurst},
+	}
+	for _, b := range bursts {
+		if *b.value <= 0 {
+			log.Printf("⚠️  %s must be positive, got %d, using default: %d", b.name, *b.value, b.fallback)
+			*b.value = b.fallback
+		}
+	}
+}
+
+// parseSortDefaultOrders parses field:order pairs, skipping entries whose order isn't asc or desc
+func parseSortDefaultOrders(pairs []string) map[string]string {
+	orders := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		field, order, ok := strings.Cut(pair, ":")
+		field = strings.TrimSpace(field)
+		order = strings.ToLower(strings.TrimSpace(order))
+		if !ok || field == "" || (order != "asc" && order != "desc") {
+			log.Printf("⚠️  Ignoring invalid SORT_DEFAULT_ORDERS entry %q, expected field:asc or field:desc", pair)
+			continue
+		}
+		orders[field] = order
+	}
+	return orders
+}
+
+// parseCacheWarmIDs converts CACHE_WARM_IDS entries to user ids, skipping invalid ones
+func parseCacheWarmIDs(entries []string) []int64 {
+	var ids []int64
+	for _, entry := range entries {
+		id, err := strconv.ParseInt(entry, 10, 64)
+		if err != nil || id <= 0 {
+			log.Printf("⚠️  Ignoring invalid CACHE_WARM_IDS entry %q", entry)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// validateWebhook falls back to defaults for non-positive webhook settings
+func (c *Config) validateWebhook() {
+	if c.WebhookTimeout <= 0 {
+		log.Printf("⚠️  WEBHOOK_TIMEOUT must be positive, got %v, using default: %v", c.WebhookTimeout, defaultWebhookTimeout)
+		c.WebhookTimeout = defaultWebhookTimeout
+	}
+	if c.WebhookMaxAttempts <= 0 {
+		log.Printf("⚠️  WEBHOOK_MAX_ATTEMPTS must be positive, got %d, using default: %d", c.WebhookMaxAttempts, defaultWebhookMaxAttempts)
+		c.WebhookMaxAttempts = defaultWebhookMaxAttempts
+	}
+	if c.WebhookQueueSize <= 0 {
+		log.Printf("⚠️  WEBHOOK_QUEUE_SIZE must be positive, got %d, using default: %d", c.WebhookQueueSize, defaultWebhookQueueSize)
+		c.WebhookQueueSize = defaultWebhookQueueSize
+	}
+}
+
+// validateTrustedProxies drops entries that are neither an IP address nor a CIDR range
+func (c *Config) validateTrustedProxies() {
+	valid := c.TrustedProxies[:0]
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				log.Printf("⚠️  Ignoring invalid TRUSTED_PROXIES entry %q", proxy)
+				continue
+			}
+		}
+		valid = append(valid, proxy)
+	}
+	c.TrustedProxies = valid
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		log.Printf("✅ Environment variable %s = %s", key, value)
@@ -47,4 +698,66 @@ func getEnv(key, defaultValue string) string {
 	}
 	log.Printf("⚠️  Environment variable %s not set, using default: %s", key, defaultValue)
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := getEnv(key, strconv.Itoa(defaultValue))
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid integer for %s: %q, using default: %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value := getEnv(key, strconv.FormatFloat(defaultValue, 'f', -1, 64))
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("⚠️  Invalid number for %s: %q, using default: %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value := getEnv(key, strconv.FormatBool(defaultValue))
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid boolean for %s: %q, using default: %t", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvLogLevel parses a slog level name (debug, info, warn, error)
+func getEnvLogLevel(key string, defaultValue slog.Level) slog.Level {
+	value := getEnv(key, defaultValue.String())
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		log.Printf("⚠️  Invalid log level for %s: %q, using default: %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return level
+}
+
+// getEnvList splits a comma-separated variable, ignoring empty entries
+func getEnvList(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key, defaultValue.String())
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid duration for %s: %q, using default: %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
@@ -0,0 +1,53 @@
+package config
+
+import "testing"
+
+func TestValidateAgeBounds(t *testing.T) {
+	tests := []struct {
+		name             string
+		minAge, maxAge   int
+		wantMin, wantMax int
+	}{
+		{"custom bounds kept", 18, 65, 18, 65},
+		{"single age kept", 21, 21, 21, 21},
+		{"negative min", -1, 65, defaultMinAge, 65},
+		{"max below min", 30, 20, defaultMinAge, defaultMaxAge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MinAge: tt.minAge, MaxAge: tt.maxAge}
+			cfg.validateAgeBounds()
+			if cfg.MinAge != tt.wantMin || cfg.MaxAge != tt.wantMax {
+				t.Errorf("bounds = %d-%d, want %d-%d", cfg.MinAge, cfg.MaxAge, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestReplicaConfig(t *testing.T) {
+	cfg := Config{DBHost: "primary", DBPort: "5432", DBUser: "app", DBName: "users", DBMaxConns: 10}
+	if replica := cfg.ReplicaConfig(); replica != nil {
+		t.Errorf("ReplicaConfig() = %+v without a replica host, want nil so the primary is used", replica)
+	}
+
+	cfg.DBReplicaHost, cfg.DBReplicaPort, cfg.DBReplicaUser, cfg.DBReplicaName = "replica", "5433", "reader", "users"
+	replica := cfg.ReplicaConfig()
+	if replica == nil || replica.DBHost != "replica" || replica.DBPort != "5433" || replica.DBUser != "reader" {
+		t.Fatalf("ReplicaConfig() = %+v, want the replica connection", replica)
+	}
+	if replica.DBMaxConns != cfg.DBMaxConns || cfg.DBHost != "primary" {
+		t.Error("replica config does not share pool settings or changed the primary")
+	}
+}
+
+func TestPrettyJSONDefaultsToCompact(t *testing.T) {
+	t.Setenv("PRETTY_JSON", "")
+	if Load().PrettyJSON {
+		t.Error("PrettyJSON is on by default")
+	}
+	t.Setenv("PRETTY_JSON", "true")
+	if !Load().PrettyJSON {
+		t.Error("PRETTY_JSON=true did not enable PrettyJSON")
+	}
+}
@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidAge is returned when an age falls outside the active age bounds
+var ErrInvalidAge = errors.New("invalid age")
+
+// AgePolicy describes the inclusive range of accepted user ages
+type AgePolicy struct {
+	Min int
+	Max int
+}
+
+var (
+	agePolicyMu      sync.RWMutex
+	currentAgePolicy = DefaultAgePolicy()
+)
+
+// DefaultAgePolicy returns the bounds used when none are configured
+func DefaultAgePolicy() AgePolicy {
+	return AgePolicy{Min: 0, Max: 150}
+}
+
+// SetAgePolicy replaces the bounds applied by NewUser and Update
+func SetAgePolicy(policy AgePolicy) {
+	agePolicyMu.Lock()
+	defer agePolicyMu.Unlock()
+	currentAgePolicy = policy
+}
+
+// CurrentAgePolicy returns the active age bounds
+func CurrentAgePolicy() AgePolicy {
+	agePolicyMu.RLock()
+	defer agePolicyMu.RUnlock()
+	return currentAgePolicy
+}
+
+// Validate checks the age against the policy bounds
+func (p AgePolicy) Validate(age int) error {
+	if age < p.Min || age > p.Max {
+		return fmt.Errorf("%w: age must be between %d and %d", ErrInvalidAge, p.Min, p.Max)
+	}
+	return nil
+}
+
+// validateAge checks the age against the active policy
+func validateAge(age int) error {
+	return CurrentAgePolicy().Validate(age)
+}
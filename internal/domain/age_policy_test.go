@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// useAgePolicy makes policy active for the rest of the test
+func useAgePolicy(t *testing.T, policy AgePolicy) {
+	t.Helper()
+	previous := CurrentAgePolicy()
+	SetAgePolicy(policy)
+	t.Cleanup(func() { SetAgePolicy(previous) })
+}
+
+func TestAgePolicyCustomBounds(t *testing.T) {
+	useAgePolicy(t, AgePolicy{Min: 18, Max: 65})
+
+	tests := []struct {
+		name  string
+		age   int
+		valid bool
+	}{
+		{"below min", 17, false},
+		{"at min", 18, true},
+		{"at max", 65, true},
+		{"above max", 66, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewUser("Alice", "alice@example.com", "Str0ng!pass", tt.age)
+			if tt.valid && err != nil {
+				t.Errorf("NewUser with age %d = %v, want nil", tt.age, err)
+			}
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidAge) {
+					t.Fatalf("NewUser with age %d = %v, want ErrInvalidAge", tt.age, err)
+				}
+				if !strings.Contains(err.Error(), "between 18 and 65") {
+					t.Errorf("error %q does not name the bounds", err)
+				}
+			}
+
+			user := &User{Name: "Alice", Age: 30}
+			if err := user.Update("Alice", tt.age); (err == nil) != tt.valid {
+				t.Errorf("Update with age %d = %v, want valid=%v", tt.age, err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDefaultAgePolicy(t *testing.T) {
+	policy := DefaultAgePolicy()
+
+	for age, valid := range map[int]bool{-1: false, 0: true, 150: true, 151: false} {
+		if err := policy.Validate(age); (err == nil) != valid {
+			t.Errorf("Validate(%d) = %v, want valid=%v", age, err, valid)
+		}
+	}
+}
@@ -0,0 +1,636 @@
+// Package domaintest provides an in-memory domain.UserRepository for tests.
+package domaintest
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"user-crud/internal/domain"
+)
+
+// Event is an outbox event recorded by EnqueueEvent
+type Event struct {
+	Type   string
+	UserID int64
+}
+
+var _ domain.UserRepository = (*UserRepository)(nil)
+
+// UserRepository is an in-memory domain.UserRepository. It counts calls per method,
+// hands out copies so callers must Update to persist changes, and undoes everything a
+// failed WithinTransaction did. Transactions are not isolated from concurrent callers.
+type UserRepository struct {
+	// Before, when set, runs at the start of every method with the method's name; a
+	// non-nil error is returned from the method without touching the data
+	Before func(ctx context.Context, method string) error
+	// FindWithFiltersFunc, when set, answers FindWithFilters and CountWithFilters;
+	// by default every user is returned in id order
+	FindWithFiltersFunc func(filters interface{}) ([]*domain.User, int64, error)
+
+	mu    sync.Mutex
+	state state
+	calls map[string]int
+}
+
+type state struct {
+	users   map[int64]*domain.User
+	deleted map[int64]bool
+	merges  map[int64]int64
+	history map[int64][]passwordChange
+	events  []Event
+	nextID  int64
+}
+
+type passwordChange struct {
+	hash     string
+	sourceIP string
+	at       time.Time
+}
+
+// NewUserRepository returns a repository holding copies of users; users without an ID
+// are numbered after the highest given ID
+func NewUserRepository(users ...*domain.User) *UserRepository {
+	r := &UserRepository{
+		state: state{
+			users:   make(map[int64]*domain.User),
+			deleted: make(map[int64]bool),
+			merges:  make(map[int64]int64),
+			history: make(map[int64][]passwordChange),
+		},
+		calls: make(map[string]int),
+	}
+	for _, u := range users {
+		if u.ID > r.state.nextID {
+			r.state.nextID = u.ID
+		}
+	}
+	for _, u := range users {
+		c := copyUser(u)
+		if c.ID == 0 {
+			r.state.nextID++
+			c.ID = r.state.nextID
+		}
+		if c.Status == "" {
+			c.Status = domain.UserStatusActive
+		}
+		r.state.users[c.ID] = c
+	}
+	return r
+}
+
+// Calls returns how many times method was called
+func (r *UserRepository) Calls(method string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[method]
+}
+
+// User returns a copy of the stored user, or nil if it does not exist or was deleted
+func (r *UserRepository) User(id int64) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.live(id)
+}
+
+// Deleted reports whether the user was soft-deleted
+func (r *UserRepository) Deleted(id int64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.deleted[id]
+}
+
+// Events returns the events enqueued so far, in order
+func (r *UserRepository) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.state.events...)
+}
+
+// enter counts the call and runs Before
+func (r *UserRepository) enter(ctx context.Context, method string) error {
+	r.mu.Lock()
+	r.calls[method]++
+	r.mu.Unlock()
+
+	if r.Before != nil {
+		return r.Before(ctx, method)
+	}
+	return nil
+}
+
+// live returns a copy of a user that exists and is not deleted; the caller holds mu
+func (r *UserRepository) live(id int64) *domain.User {
+	u, ok := r.state.users[id]
+	if !ok || r.state.deleted[id] {
+		return nil
+	}
+	return copyUser(u)
+}
+
+// sortedLive returns copies of every live user in id order; the caller holds mu
+func (r *UserRepository) sortedLive() []*domain.User {
+	users := make([]*domain.User, 0, len(r.state.users))
+	for id := range r.state.users {
+		if u := r.live(id); u != nil {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// checkUnique rejects a taken email or username; soft-deleted users keep theirs
+func (r *UserRepository) checkUnique(user *domain.User) error {
+	for id, other := range r.state.users {
+		if id == user.ID {
+			continue
+		}
+		if other.Email == user.Email {
+			return domain.ErrUserAlreadyExists
+		}
+		if user.Username != "" && other.Username == user.Username {
+			return domain.ErrUsernameTaken
+		}
+	}
+	return nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	if err := r.enter(ctx, "Create"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	r.state.nextID++
+	user.ID = r.state.nextID
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	r.state.users[user.ID] = copyUser(user)
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	if err := r.enter(ctx, "GetByID"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u := r.live(id); u != nil {
+		return u, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.User, error) {
+	if err := r.enter(ctx, "GetByIDs"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := make(map[int64]*domain.User, len(ids))
+	for _, id := range ids {
+		if u := r.live(id); u != nil {
+			users[id] = u
+		}
+	}
+	return users, nil
+}
+
+func (r *UserRepository) GetRecentlyActive(ctx context.Context, limit int) ([]*domain.User, error) {
+	if err := r.enter(ctx, "GetRecentlyActive"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active := func(u *domain.User) time.Time {
+		if u.LastLoginAt != nil && u.LastLoginAt.After(u.UpdatedAt) {
+			return *u.LastLoginAt
+		}
+		return u.UpdatedAt
+	}
+	users := r.sortedLive()
+	sort.SliceStable(users, func(i, j int) bool {
+		if ai, aj := active(users[i]), active(users[j]); !ai.Equal(aj) {
+			return ai.After(aj)
+		}
+		return users[i].ID > users[j].ID
+	})
+	return truncate(users, limit), nil
+}
+
+func (r *UserRepository) GetRecentlyCreated(ctx context.Context, limit int) ([]*domain.User, error) {
+	if err := r.enter(ctx, "GetRecentlyCreated"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.sortedLive()
+	sort.SliceStable(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	return truncate(users, limit), nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	if err := r.enter(ctx, "GetByEmail"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.sortedLive() {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	if err := r.enter(ctx, "GetByUsername"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	username = domain.NormalizeUsername(username)
+	for _, u := range r.sortedLive() {
+		if u.Username != "" && u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (r *UserRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	if err := r.enter(ctx, "Exists"); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.live(id) != nil, nil
+}
+
+func (r *UserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
+	if err := r.enter(ctx, "GetAll"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.sortedLive(), nil
+}
+
+func (r *UserRepository) GetAllPaged(ctx context.Context, limit, offset int) ([]*domain.User, error) {
+	if err := r.enter(ctx, "GetAllPaged"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return page(r.sortedLive(), limit, offset), nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	if err := r.enter(ctx, "Update"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.live(user.ID) == nil {
+		return domain.ErrUserNotFound
+	}
+	if err := r.checkUnique(user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+	r.state.users[user.ID] = copyUser(user)
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.enter(ctx, "Delete"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.live(id) == nil {
+		return domain.ErrUserNotFound
+	}
+	r.remove(id)
+	return nil
+}
+
+func (r *UserRepository) DeleteMany(ctx context.Context, ids []int64) ([]int64, error) {
+	if err := r.enter(ctx, "DeleteMany"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted []int64
+	for _, id := range ids {
+		if r.live(id) != nil {
+			r.remove(id)
+			deleted = append(deleted, id)
+		}
+	}
+	return deleted, nil
+}
+
+// remove hard-deletes a user along with its password history; the caller holds mu
+func (r *UserRepository) remove(id int64) {
+	delete(r.state.users, id)
+	delete(r.state.history, id)
+}
+
+func (r *UserRepository) MergeUsers(ctx context.Context, primaryID, duplicateID int64) error {
+	if err := r.enter(ctx, "MergeUsers"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.live(duplicateID) == nil {
+		return domain.ErrUserNotFound
+	}
+	r.state.deleted[duplicateID] = true
+	r.state.merges[duplicateID] = primaryID
+	return nil
+}
+
+func (r *UserRepository) GetMergedInto(ctx context.Context, id int64) (int64, error) {
+	if err := r.enter(ctx, "GetMergedInto"); err != nil {
+		return 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.state.merges[id], nil
+}
+
+func (r *UserRepository) GetActivity(ctx context.Context, id int64) (*domain.UserActivity, error) {
+	if err := r.enter(ctx, "GetActivity"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u := r.live(id)
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return &domain.UserActivity{
+		UserID:            u.ID,
+		Status:            u.Status,
+		CreatedAt:         u.CreatedAt,
+		UpdatedAt:         u.UpdatedAt,
+		LastLoginAt:       u.LastLoginAt,
+		PasswordChangedAt: u.PasswordChangedAt,
+	}, nil
+}
+
+func (r *UserRepository) GetStats(ctx context.Context, days int) (*domain.UserStats, error) {
+	if err := r.enter(ctx, "GetStats"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.sortedLive()
+	stats := &domain.UserStats{Days: days, TotalUsers: int64(len(users))}
+	if len(users) > 0 {
+		var sum int
+		for _, u := range users {
+			sum += u.Age
+		}
+		stats.AverageAge = float64(sum) / float64(len(users))
+	}
+	return stats, nil
+}
+
+func (r *UserRepository) TouchLastLogin(ctx context.Context, id int64) (time.Time, error) {
+	if err := r.enter(ctx, "TouchLastLogin"); err != nil {
+		return time.Time{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.live(id) == nil {
+		return time.Time{}, domain.ErrUserNotFound
+	}
+	now := time.Now()
+	r.state.users[id].LastLoginAt = &now
+	return now, nil
+}
+
+func (r *UserRepository) UpdatePasswordHash(ctx context.Context, id int64, oldHash, newHash string) (bool, error) {
+	if err := r.enter(ctx, "UpdatePasswordHash"); err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.live(id) == nil || r.state.users[id].PasswordHash != oldHash {
+		return false, nil
+	}
+	r.state.users[id].PasswordHash = newHash
+	return true, nil
+}
+
+func (r *UserRepository) Search(ctx context.Context, keyword string, page, limit int) ([]*domain.ScoredUser, int64, error) {
+	if err := r.enter(ctx, "Search"); err != nil {
+		return nil, 0, err
+	}
+	return r.match(keyword, page, limit)
+}
+
+func (r *UserRepository) FuzzySearch(ctx context.Context, keyword string, threshold float64, page, limit int) ([]*domain.ScoredUser, int64, error) {
+	if err := r.enter(ctx, "FuzzySearch"); err != nil {
+		return nil, 0, err
+	}
+	return r.match(keyword, page, limit)
+}
+
+// match returns a page of users whose name or email contains keyword, case-insensitively
+func (r *UserRepository) match(keyword string, pageNum, limit int) ([]*domain.ScoredUser, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keyword = strings.ToLower(keyword)
+	var matches []*domain.User
+	for _, u := range r.sortedLive() {
+		if strings.Contains(strings.ToLower(u.Name), keyword) || strings.Contains(strings.ToLower(u.Email), keyword) {
+			matches = append(matches, u)
+		}
+	}
+
+	var scored []*domain.ScoredUser
+	for _, u := range page(matches, limit, (pageNum-1)*limit) {
+		scored = append(scored, &domain.ScoredUser{User: u, Score: 1})
+	}
+	return scored, int64(len(matches)), nil
+}
+
+func (r *UserRepository) FindWithFilters(ctx context.Context, filters interface{}) ([]*domain.User, int64, error) {
+	if err := r.enter(ctx, "FindWithFilters"); err != nil {
+		return nil, 0, err
+	}
+	if r.FindWithFiltersFunc != nil {
+		return r.FindWithFiltersFunc(filters)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := r.sortedLive()
+	return users, int64(len(users)), nil
+}
+
+func (r *UserRepository) CountWithFilters(ctx context.Context, filters interface{}) (int64, error) {
+	if err := r.enter(ctx, "CountWithFilters"); err != nil {
+		return 0, err
+	}
+	if r.FindWithFiltersFunc != nil {
+		_, total, err := r.FindWithFiltersFunc(filters)
+		return total, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return int64(len(r.sortedLive())), nil
+}
+
+func (r *UserRepository) AddPasswordHistory(ctx context.Context, userID int64, previousHash, sourceIP string) error {
+	if err := r.enter(ctx, "AddPasswordHistory"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change := passwordChange{hash: previousHash, sourceIP: sourceIP, at: time.Now()}
+	r.state.history[userID] = append([]passwordChange{change}, r.state.history[userID]...)
+	return nil
+}
+
+func (r *UserRepository) GetRecentPasswordHashes(ctx context.Context, userID int64, n int) ([]string, error) {
+	if err := r.enter(ctx, "GetRecentPasswordHashes"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var hashes []string
+	for i, change := range r.state.history[userID] {
+		if i == n {
+			break
+		}
+		hashes = append(hashes, change.hash)
+	}
+	return hashes, nil
+}
+
+func (r *UserRepository) GetPasswordHistory(ctx context.Context, userID int64, pageNum, limit int) ([]*domain.PasswordChange, int64, error) {
+	if err := r.enter(ctx, "GetPasswordHistory"); err != nil {
+		return nil, 0, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := r.state.history[userID]
+	changes := []*domain.PasswordChange{}
+	for i := (pageNum - 1) * limit; i >= 0 && i < len(history) && len(changes) < limit; i++ {
+		changes = append(changes, &domain.PasswordChange{ChangedAt: history[i].at, SourceIP: history[i].sourceIP})
+	}
+	return changes, int64(len(history)), nil
+}
+
+func (r *UserRepository) EnqueueEvent(ctx context.Context, eventType string, userID int64, payload []byte) error {
+	if err := r.enter(ctx, "EnqueueEvent"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.state.events = append(r.state.events, Event{Type: eventType, UserID: userID})
+	return nil
+}
+
+// WithinTransaction runs fn against the repository itself and restores the data as it
+// was before fn if fn fails. Nested calls behave like savepoints.
+func (r *UserRepository) WithinTransaction(ctx context.Context, fn func(repo domain.UserRepository) error) error {
+	if err := r.enter(ctx, "WithinTransaction"); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	saved := r.state.clone()
+	r.mu.Unlock()
+
+	if err := fn(r); err != nil {
+		r.mu.Lock()
+		r.state = saved
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s state) clone() state {
+	c := state{
+		users:   make(map[int64]*domain.User, len(s.users)),
+		deleted: make(map[int64]bool, len(s.deleted)),
+		merges:  make(map[int64]int64, len(s.merges)),
+		history: make(map[int64][]passwordChange, len(s.history)),
+		events:  append([]Event(nil), s.events...),
+		nextID:  s.nextID,
+	}
+	for id, u := range s.users {
+		c.users[id] = copyUser(u)
+	}
+	for id, d := range s.deleted {
+		c.deleted[id] = d
+	}
+	for id, p := range s.merges {
+		c.merges[id] = p
+	}
+	for id, h := range s.history {
+		c.history[id] = append([]passwordChange(nil), h...)
+	}
+	return c
+}
+
+func copyUser(u *domain.User) *domain.User {
+	c := *u
+	return &c
+}
+
+func page(users []*domain.User, limit, offset int) []*domain.User {
+	if offset < 0 || offset >= len(users) {
+		return nil
+	}
+	return truncate(users[offset:], limit)
+}
+
+func truncate(users []*domain.User, limit int) []*domain.User {
+	if limit >= 0 && len(users) > limit {
+		return users[:limit]
+	}
+	return users
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrEmailDomainBlocked is returned when an email's domain is on the denylist
+var ErrEmailDomainBlocked = errors.New("email domain is not allowed")
+
+var (
+	blockedDomainsMu sync.RWMutex
+	blockedDomains   []string
+)
+
+// SetBlockedEmailDomains replaces the email domain denylist. Entries are exact domains
+// ("example.com") or wildcards matching any subdomain ("*.example.com"); an empty list
+// allows every domain.
+func SetBlockedEmailDomains(domains []string) {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			normalized = append(normalized, domain)
+		}
+	}
+
+	blockedDomainsMu.Lock()
+	defer blockedDomainsMu.Unlock()
+	blockedDomains = normalized
+}
+
+// ValidateEmailDomain returns ErrEmailDomainBlocked if the email's domain is on the denylist
+func ValidateEmailDomain(email string) error {
+	blockedDomainsMu.RLock()
+	defer blockedDomainsMu.RUnlock()
+
+	if len(blockedDomains) == 0 {
+		return nil
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return nil
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+
+	for _, blocked := range blockedDomains {
+		if suffix, ok := strings.CutPrefix(blocked, "*"); ok {
+			// "*.example.com" matches "mail.example.com" but not "example.com"
+			if strings.HasSuffix(domain, suffix) {
+				return ErrEmailDomainBlocked
+			}
+		} else if domain == blocked {
+			return ErrEmailDomainBlocked
+		}
+	}
+
+	return nil
+}
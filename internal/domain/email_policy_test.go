@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// useBlockedEmailDomains makes domains the denylist for the rest of the test
+func useBlockedEmailDomains(t *testing.T, domains ...string) {
+	t.Helper()
+	SetBlockedEmailDomains(domains)
+	t.Cleanup(func() { SetBlockedEmailDomains(nil) })
+}
+
+func TestValidateEmailDomain(t *testing.T) {
+	useBlockedEmailDomains(t, " Mailinator.com ", "*.disposable.io", "")
+
+	tests := []struct {
+		email   string
+		blocked bool
+	}{
+		{"alice@mailinator.com", true},
+		{"alice@MAILINATOR.COM", true},
+		{"alice@example.com", false},
+		{"alice@notmailinator.com", false},
+		{"alice@sub.mailinator.com", false},
+		{"alice@mail.disposable.io", true},
+		{"alice@a.b.disposable.io", true},
+		{"alice@disposable.io", false},
+		{"alice@evildisposable.io", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateEmailDomain(tt.email)
+		if tt.blocked && !errors.Is(err, ErrEmailDomainBlocked) {
+			t.Errorf("ValidateEmailDomain(%q) = %v, want ErrEmailDomainBlocked", tt.email, err)
+		}
+		if !tt.blocked && err != nil {
+			t.Errorf("ValidateEmailDomain(%q) = %v, want nil", tt.email, err)
+		}
+	}
+}
+
+func TestEmptyDenylistAllowsEveryDomain(t *testing.T) {
+	useBlockedEmailDomains(t)
+
+	if err := ValidateEmailDomain("alice@mailinator.com"); err != nil {
+		t.Errorf("empty denylist rejected an email: %v", err)
+	}
+}
+
+func TestBlockedDomainAppliesToUsers(t *testing.T) {
+	useBlockedEmailDomains(t, "mailinator.com")
+
+	if _, err := NewUser("Alice", "alice@mailinator.com", "Str0ng!pass", 30); !errors.Is(err, ErrEmailDomainBlocked) {
+		t.Errorf("NewUser with a blocked domain = %v, want ErrEmailDomainBlocked", err)
+	}
+
+	user, err := NewUser("Alice", "alice@example.com", "Str0ng!pass", 30)
+	if err != nil {
+		t.Fatalf("NewUser: %v", err)
+	}
+	if err := user.ChangeEmail("alice@mailinator.com"); !errors.Is(err, ErrEmailDomainBlocked) {
+		t.Errorf("ChangeEmail to a blocked domain = %v, want ErrEmailDomainBlocked", err)
+	}
+	if user.Email != "alice@example.com" {
+		t.Errorf("email = %q, want it unchanged", user.Email)
+	}
+}
@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing schemes
+const (
+	HashSchemeBcrypt   = "bcrypt"
+	HashSchemeArgon2id = "argon2id"
+)
+
+// errUnknownHashScheme is returned when a stored hash was made by no known scheme
+var errUnknownHashScheme = errors.New("unknown password hash scheme")
+
+// Hasher hashes and verifies passwords under one scheme. Hashes carry the scheme's
+// identifier ($2a$ for bcrypt, $argon2id$ for argon2id), so a stored hash is verified
+// by the scheme that made it whichever hasher is configured.
+type Hasher interface {
+	// Scheme returns the scheme identifier, one of the HashScheme constants
+	Scheme() string
+	Hash(password string) (string, error)
+	// Compare returns nil when password matches hash
+	Compare(hash, password string) error
+}
+
+// BcryptHasher hashes passwords with bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Scheme() string {
+	return HashSchemeBcrypt
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h BcryptHasher) Compare(hash, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// Argon2idHasher hashes passwords with argon2id, encoding the parameters and salt in the
+// hash as $argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	SaltLen int
+	KeyLen  uint32
+}
+
+// DefaultArgon2idHasher uses the OWASP recommended minimum: 19 MiB, 2 passes, 1 thread
+func DefaultArgon2idHasher() Argon2idHasher {
+	return Argon2idHasher{Memory: 19 * 1024, Time: 2, Threads: 1, SaltLen: 16, KeyLen: 32}
+}
+
+func (h Argon2idHasher) Scheme() string {
+	return HashSchemeArgon2id
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Compare verifies password with the parameters encoded in hash, not the hasher's own
+func (h Argon2idHasher) Compare(hash, password string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return errors.New("unsupported argon2id version")
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return errors.New("malformed argon2id parameters")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return errors.New("malformed argon2id salt")
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return errors.New("malformed argon2id key")
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
+// NewPasswordHasher returns the default hasher of a scheme
+func NewPasswordHasher(scheme string) (Hasher, error) {
+	switch scheme {
+	case HashSchemeBcrypt:
+		return BcryptHasher{Cost: bcrypt.DefaultCost}, nil
+	case HashSchemeArgon2id:
+		return DefaultArgon2idHasher(), nil
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q: must be bcrypt or argon2id", scheme)
+	}
+}
+
+var (
+	hasherMu      sync.RWMutex
+	currentHasher Hasher = BcryptHasher{Cost: bcrypt.DefaultCost}
+)
+
+// SetPasswordHasher replaces the hasher used for new passwords. Existing hashes of other
+// schemes still verify and are upgraded by RehashPassword.
+func SetPasswordHasher(h Hasher) {
+	hasherMu.Lock()
+	defer hasherMu.Unlock()
+	currentHasher = h
+}
+
+func getPasswordHasher() Hasher {
+	hasherMu.RLock()
+	defer hasherMu.RUnlock()
+	return currentHasher
+}
+
+// HashPassword hashes password with the configured hasher
+func HashPassword(password string) (string, error) {
+	return getPasswordHasher().Hash(password)
+}
+
+// ComparePasswordHash verifies password against a hash of any known scheme
+func ComparePasswordHash(hash, password string) error {
+	h := hasherFor(hash)
+	if h == nil {
+		return errUnknownHashScheme
+	}
+	return h.Compare(hash, password)
+}
+
+// hasherFor returns the hasher that verifies hash: the configured one when the schemes
+// match, so its settings apply, and otherwise the scheme's default
+func hasherFor(hash string) Hasher {
+	scheme := hashScheme(hash)
+	if scheme == "" {
+		return nil
+	}
+	if current := getPasswordHasher(); current.Scheme() == scheme {
+		return current
+	}
+	h, _ := NewPasswordHasher(scheme)
+	return h
+}
+
+// hashScheme identifies the scheme of a hash from its prefix, or returns "" if unknown
+func hashScheme(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return HashSchemeArgon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return HashSchemeBcrypt
+	default:
+		return ""
+	}
+}
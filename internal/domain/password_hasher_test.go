@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap settings keep the tests fast; the schemes behave the same at any cost
+var (
+	testBcrypt   = BcryptHasher{Cost: bcrypt.MinCost}
+	testArgon2id = Argon2idHasher{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}
+)
+
+// usePasswordHasher makes h the configured hasher for the rest of the test
+func usePasswordHasher(t *testing.T, h Hasher) {
+	t.Helper()
+	previous := getPasswordHasher()
+	SetPasswordHasher(h)
+	t.Cleanup(func() { SetPasswordHasher(previous) })
+}
+
+func TestHashers(t *testing.T) {
+	for _, h := range []Hasher{testBcrypt, testArgon2id} {
+		t.Run(h.Scheme(), func(t *testing.T) {
+			hash, err := h.Hash("Str0ng!pass")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hashScheme(hash) != h.Scheme() {
+				t.Errorf("hash %q is not identified as %s", hash, h.Scheme())
+			}
+			if err := h.Compare(hash, "Str0ng!pass"); err != nil {
+				t.Errorf("Compare with the right password = %v", err)
+			}
+			if err := h.Compare(hash, "str0ng!pass"); err == nil {
+				t.Error("Compare accepted the wrong password")
+			}
+
+			again, _ := h.Hash("Str0ng!pass")
+			if again == hash {
+				t.Error("two hashes of the same password are equal, want a fresh salt each")
+			}
+		})
+	}
+}
+
+func TestArgon2idCompareUsesEncodedParameters(t *testing.T) {
+	hash, err := testArgon2id.Hash("Str0ng!pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("hash %q does not encode its parameters", hash)
+	}
+
+	// A hasher with other settings still verifies it
+	if err := DefaultArgon2idHasher().Compare(hash, "Str0ng!pass"); err != nil {
+		t.Errorf("Compare with different settings = %v", err)
+	}
+	if err := testArgon2id.Compare(hash, "wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("Compare with the wrong password = %v, want ErrInvalidPassword", err)
+	}
+
+	for _, malformed := range []string{"$argon2id$v=19$m=64,t=1,p=1$salt", "$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=x$c2FsdA$a2V5"} {
+		if err := testArgon2id.Compare(malformed, "Str0ng!pass"); err == nil {
+			t.Errorf("malformed hash %q verified", malformed)
+		}
+	}
+}
+
+func TestComparePasswordHashRoutesByScheme(t *testing.T) {
+	bcryptHash, _ := testBcrypt.Hash("Str0ng!pass")
+	argonHash, _ := testArgon2id.Hash("Str0ng!pass")
+
+	for _, configured := range []Hasher{testBcrypt, testArgon2id} {
+		usePasswordHasher(t, configured)
+		for _, hash := range []string{bcryptHash, argonHash} {
+			if err := ComparePasswordHash(hash, "Str0ng!pass"); err != nil {
+				t.Errorf("with %s configured, %s hash = %v", configured.Scheme(), hashScheme(hash), err)
+			}
+		}
+	}
+
+	if err := ComparePasswordHash("plaintext", "plaintext"); !errors.Is(err, errUnknownHashScheme) {
+		t.Errorf("unknown scheme = %v, want errUnknownHashScheme", err)
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	for _, scheme := range []string{HashSchemeBcrypt, HashSchemeArgon2id} {
+		if h, err := NewPasswordHasher(scheme); err != nil || h.Scheme() != scheme {
+			t.Errorf("NewPasswordHasher(%q) = %v, %v", scheme, h, err)
+		}
+	}
+	if _, err := NewPasswordHasher("md5"); err == nil {
+		t.Error("NewPasswordHasher accepted an unknown scheme")
+	}
+}
+
+func TestRehashPasswordUpgradesScheme(t *testing.T) {
+	usePasswordHasher(t, testBcrypt)
+	user, err := NewUser("Alice", "alice@example.com", "Str0ng!pass", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.PasswordNeedsRehash() {
+		t.Error("hash of the configured scheme needs a rehash")
+	}
+
+	usePasswordHasher(t, testArgon2id)
+	if !user.PasswordNeedsRehash() {
+		t.Fatal("bcrypt hash does not need a rehash with argon2id configured")
+	}
+	updated := user.UpdatedAt
+	if err := user.RehashPassword("Str0ng!pass"); err != nil {
+		t.Fatal(err)
+	}
+
+	if hashScheme(user.PasswordHash) != HashSchemeArgon2id || user.PasswordNeedsRehash() {
+		t.Errorf("rehashed hash %q is not argon2id", user.PasswordHash)
+	}
+	if err := user.ComparePassword("Str0ng!pass"); err != nil {
+		t.Errorf("password does not verify after the rehash: %v", err)
+	}
+	if user.PasswordChangedAt != nil || !user.UpdatedAt.Equal(updated) {
+		t.Error("rehash was recorded as a password change")
+	}
+}
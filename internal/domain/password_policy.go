@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrWeakPassword is returned when a password does not satisfy the active password policy
+var ErrWeakPassword = errors.New("password is too weak")
+
+// PasswordPolicy describes the rules a password must satisfy
+type PasswordPolicy struct {
+	MinLength       int
+	RequireUpper    bool
+	RequireLower    bool
+	RequireDigit    bool
+	RequireSymbol   bool
+	DenylistEnabled bool
+	Denylist        map[string]struct{}
+}
+
+// defaultDenylist contains common passwords rejected when no denylist file is configured
+var defaultDenylist = []string{
+	"password", "password1", "password123", "passw0rd", "p@ssw0rd", "p@ssword1",
+	"12345678", "123456789", "1234567890", "qwerty123", "qwertyuiop",
+	"iloveyou", "sunshine1", "princess1", "football1", "baseball1",
+	"welcome1", "welcome123", "admin123", "letmein1", "trustno1",
+	"abc12345", "11111111", "00000000", "monkey123", "dragon123",
+}
+
+var (
+	policyMu      sync.RWMutex
+	currentPolicy = DefaultPasswordPolicy()
+)
+
+// DefaultPasswordPolicy returns the policy used when none is configured
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:       8,
+		RequireUpper:    true,
+		RequireLower:    true,
+		RequireDigit:    true,
+		RequireSymbol:   true,
+		DenylistEnabled: true,
+		Denylist:        NewPasswordDenylist(defaultDenylist),
+	}
+}
+
+// SetPasswordPolicy replaces the policy applied by NewUser, UpdatePassword and SetPassword
+func SetPasswordPolicy(policy PasswordPolicy) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	currentPolicy = policy
+}
+
+// CurrentPasswordPolicy returns the active password policy
+func CurrentPasswordPolicy() PasswordPolicy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return currentPolicy
+}
+
+// PasswordRequirements is the public description of a password policy, for clients that
+// show the rules before a password is submitted
+type PasswordRequirements struct {
+	MinLength int `json:"min_length"`
+	// RequiredClasses lists the character classes a password needs: uppercase, lowercase,
+	// digit and symbol
+	RequiredClasses []string `json:"required_classes"`
+	DenylistEnabled bool     `json:"denylist_enabled"`
+}
+
+// Requirements describes the rules Validate enforces, leaving out the denylist itself
+func (p PasswordPolicy) Requirements() PasswordRequirements {
+	classes := []string{}
+	if p.RequireUpper {
+		classes = append(classes, "uppercase")
+	}
+	if p.RequireLower {
+		classes = append(classes, "lowercase")
+	}
+	if p.RequireDigit {
+		classes = append(classes, "digit")
+	}
+	if p.RequireSymbol {
+		classes = append(classes, "symbol")
+	}
+
+	return PasswordRequirements{
+		MinLength:       p.MinLength,
+		RequiredClasses: classes,
+		DenylistEnabled: p.DenylistEnabled,
+	}
+}
+
+// NewPasswordDenylist builds a case-insensitive denylist from the given passwords
+func NewPasswordDenylist(passwords []string) map[string]struct{} {
+	denylist := make(map[string]struct{}, len(passwords))
+	for _, p := range passwords {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" {
+			denylist[p] = struct{}{}
+		}
+	}
+	return denylist
+}
+
+// LoadPasswordDenylist reads a denylist file with one password per line (# starts a comment)
+func LoadPasswordDenylist(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open password denylist: %w", err)
+	}
+	defer file.Close()
+
+	var passwords []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords = append(passwords, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read password denylist: %w", err)
+	}
+
+	return NewPasswordDenylist(passwords), nil
+}
+
+// Validate checks the password against the policy and describes the first violated rule
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, p.MinLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return fmt.Errorf("%w: must contain an uppercase letter", ErrWeakPassword)
+	}
+	if p.RequireLower && !hasLower {
+		return fmt.Errorf("%w: must contain a lowercase letter", ErrWeakPassword)
+	}
+	if p.RequireDigit && !hasDigit {
+		return fmt.Errorf("%w: must contain a digit", ErrWeakPassword)
+	}
+	if p.RequireSymbol && !hasSymbol {
+		return fmt.Errorf("%w: must contain a symbol", ErrWeakPassword)
+	}
+
+	if p.DenylistEnabled {
+		if _, denied := p.Denylist[strings.ToLower(password)]; denied {
+			return fmt.Errorf("%w: password is too common", ErrWeakPassword)
+		}
+	}
+
+	return nil
+}
+
+// validatePassword checks the password against the active policy
+func validatePassword(password string) error {
+	return CurrentPasswordPolicy().Validate(password)
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// usePasswordPolicy makes policy active for the rest of the test
+func usePasswordPolicy(t *testing.T, policy PasswordPolicy) {
+	t.Helper()
+	previous := CurrentPasswordPolicy()
+	SetPasswordPolicy(policy)
+	t.Cleanup(func() { SetPasswordPolicy(previous) })
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := DefaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"strong", "Str0ng!pass", true},
+		{"too short", "S0!a", false},
+		{"no uppercase", "str0ng!pass", false},
+		{"no lowercase", "STR0NG!PASS", false},
+		{"no digit", "Strong!pass", false},
+		{"no symbol", "Str0ngpass1", false},
+		{"denylisted in another case", "P@ssw0rd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if tt.valid && err != nil {
+				t.Errorf("Validate(%q) = %v, want nil", tt.password, err)
+			}
+			if !tt.valid && !errors.Is(err, ErrWeakPassword) {
+				t.Errorf("Validate(%q) = %v, want ErrWeakPassword", tt.password, err)
+			}
+		})
+	}
+}
+
+func TestRelaxedPasswordPolicy(t *testing.T) {
+	relaxed := PasswordPolicy{MinLength: 4}
+
+	for _, password := range []string{"abcd", "password", "1234"} {
+		if err := relaxed.Validate(password); err != nil {
+			t.Errorf("relaxed policy rejected %q: %v", password, err)
+		}
+	}
+	if err := relaxed.Validate("abc"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("relaxed policy accepted a password below its minimum length")
+	}
+}
+
+func TestPasswordPolicyAppliesToUsers(t *testing.T) {
+	usePasswordPolicy(t, PasswordPolicy{MinLength: 8, RequireDigit: true})
+
+	if _, err := NewUser("Alice", "alice@example.com", "nodigitshere", 30); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("NewUser with a weak password = %v, want ErrWeakPassword", err)
+	}
+
+	user, err := NewUser("Alice", "alice@example.com", "digits123", 30)
+	if err != nil {
+		t.Fatalf("NewUser: %v", err)
+	}
+	if err := user.SetPassword("nodigitshere"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("SetPassword with a weak password = %v, want ErrWeakPassword", err)
+	}
+	if err := user.UpdatePassword("digits123", "nodigitshere"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("UpdatePassword with a weak password = %v, want ErrWeakPassword", err)
+	}
+}
+
+func TestLoadPasswordDenylist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	content := "# common passwords\nHunter2!\n\n  Correct-Horse-9  \n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	denylist, err := LoadPasswordDenylist(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := PasswordPolicy{MinLength: 1, DenylistEnabled: true, Denylist: denylist}
+	for _, password := range []string{"hunter2!", "CORRECT-HORSE-9"} {
+		if err := policy.Validate(password); !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("denylisted %q was accepted", password)
+		}
+	}
+	if len(denylist) != 2 {
+		t.Errorf("loaded %d entries, want comments and blank lines skipped", len(denylist))
+	}
+
+	policy.DenylistEnabled = false
+	if err := policy.Validate("hunter2!"); err != nil {
+		t.Errorf("disabled denylist still rejected a password: %v", err)
+	}
+}
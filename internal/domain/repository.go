@@ -2,18 +2,63 @@ package domain
 
 import (
 	"context"
+	"time"
 )
 
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id int64) (*User, error)
+	// GetByIDs loads several users in one query; ids that don't exist are absent from the map
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*User, error)
+	// GetRecentlyActive returns up to limit users, most recently logged in or updated first
+	GetRecentlyActive(ctx context.Context, limit int) ([]*User, error)
+	// GetRecentlyCreated returns up to limit users, newest registration first
+	GetRecentlyCreated(ctx context.Context, limit int) ([]*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	Exists(ctx context.Context, id int64) (bool, error)
+	// Deprecated: GetAll loads every user into memory; use GetAllPaged instead.
 	GetAll(ctx context.Context) ([]*User, error)
+	GetAllPaged(ctx context.Context, limit, offset int) ([]*User, error)
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id int64) error
-	
+	DeleteMany(ctx context.Context, ids []int64) ([]int64, error)
+	// MergeUsers soft-deletes the duplicate and records that it was merged into primaryID,
+	// returning ErrUserNotFound if the duplicate does not exist
+	MergeUsers(ctx context.Context, primaryID, duplicateID int64) error
+	// GetMergedInto returns the id the user was merged into, or 0 if it never was
+	GetMergedInto(ctx context.Context, id int64) (int64, error)
+	GetActivity(ctx context.Context, id int64) (*UserActivity, error)
+	GetStats(ctx context.Context, days int) (*UserStats, error)
+	// TouchLastLogin sets the user's last login time to the database clock and returns it
+	TouchLastLogin(ctx context.Context, id int64) (time.Time, error)
+	// UpdatePasswordHash replaces the password hash only while it is still oldHash, so a
+	// rehash never overwrites a concurrent password change; it reports whether it did
+	UpdatePasswordHash(ctx context.Context, id int64, oldHash, newHash string) (bool, error)
+
 	// Search & Filter methods
-	Search(ctx context.Context, keyword string, page, limit int) ([]*User, int64, error)
+	// Search matches keyword as a substring of name or email, most relevant first: name
+	// before email and prefix before substring. Scores are in (0, 1].
+	Search(ctx context.Context, keyword string, page, limit int) ([]*ScoredUser, int64, error)
 	FindWithFilters(ctx context.Context, filters interface{}) ([]*User, int64, error)
-}
\ No newline at end of file
+	// CountWithFilters counts the users FindWithFilters matches, without loading them
+	CountWithFilters(ctx context.Context, filters interface{}) (int64, error)
+	// FuzzySearch ranks users by trigram similarity of name or email to keyword,
+	// returning ErrFuzzySearchUnavailable when pg_trgm is not installed
+	FuzzySearch(ctx context.Context, keyword string, threshold float64, page, limit int) ([]*ScoredUser, int64, error)
+
+	// AddPasswordHistory records a password change, keeping the hash being replaced
+	AddPasswordHistory(ctx context.Context, userID int64, previousHash, sourceIP string) error
+	// GetRecentPasswordHashes returns up to n replaced password hashes, newest first
+	GetRecentPasswordHashes(ctx context.Context, userID int64, n int) ([]string, error)
+	GetPasswordHistory(ctx context.Context, userID int64, page, limit int) ([]*PasswordChange, int64, error)
+
+	// EnqueueEvent stores an event in the outbox for later publishing; call it inside
+	// WithinTransaction so the event is only kept if the change it describes commits
+	EnqueueEvent(ctx context.Context, eventType string, userID int64, payload []byte) error
+
+	// WithinTransaction runs fn against a repository bound to a single transaction,
+	// committing if fn returns nil and rolling back otherwise
+	WithinTransaction(ctx context.Context, fn func(repo UserRepository) error) error
+}
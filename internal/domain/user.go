@@ -2,83 +2,253 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxAvatarURLLength matches the avatar_url column size
+const maxAvatarURLLength = 2048
+
+// maxNameLength matches the name column size, in characters
+const maxNameLength = 255
+
+// maxEmailLength matches the email column size, in characters
+const maxEmailLength = 255
 
-	"golang.org/x/crypto/bcrypt"
+// Account statuses
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
 )
 
 // User represents the user domain entity
 type User struct {
-	ID           int64     `json:"id"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"` // Never expose password in JSON
-	Age          int       `json:"age"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int64      `json:"id"`
+	Name         string     `json:"name"`
+	Username     string     `json:"username,omitempty"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"-"` // Never expose password in JSON
+	Age          int        `json:"age"`
+	AvatarURL    string     `json:"avatar_url,omitempty"`
+	Status       string     `json:"status"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	// PasswordChangedAt is nil until the password is first changed or reset
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 // NewUser creates a new user with validation and password hashing
 func NewUser(name, email, password string, age int) (*User, error) {
 	// Trim whitespace
-	name = strings.TrimSpace(name)
 	email = strings.TrimSpace(email)
 	password = strings.TrimSpace(password)
-	
-	if name == "" {
-		return nil, errors.New("name cannot be empty")
-	}
-	if email == "" {
-		return nil, errors.New("email cannot be empty")
+
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
 	}
-	if password == "" {
-		return nil, errors.New("password cannot be empty")
+	if err := validateNewEmail(email); err != nil {
+		return nil, err
 	}
-	if len(password) < 8 {
-		return nil, errors.New("password must be at least 8 characters")
+	if err := validateNewPassword(password); err != nil {
+		return nil, err
 	}
-	if age < 0 || age > 150 {
-		return nil, errors.New("age must be between 0 and 150")
+	if err := validateAge(age); err != nil {
+		return nil, err
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := HashPassword(password)
 	if err != nil {
 		return nil, errors.New("failed to hash password")
 	}
 
+	// Provisional timestamps; the repository replaces them with the database clock on insert
 	now := time.Now()
 	return &User{
 		Name:         name,
 		Email:        email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Age:          age,
+		Status:       UserStatusActive,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}, nil
 }
 
-// Update updates user fields with validation
-func (u *User) Update(name, email string, age int) error {
-	if name == "" {
-		return errors.New("name cannot be empty")
-	}
+// validateNewEmail checks an already trimmed email for a new account
+func validateNewEmail(email string) error {
 	if email == "" {
 		return errors.New("email cannot be empty")
 	}
-	if age < 0 || age > 150 {
-		return errors.New("age must be between 0 and 150")
+	if err := ValidateEmailLength(email); err != nil {
+		return err
+	}
+	return ValidateEmailDomain(email)
+}
+
+// ValidateEmailLength rejects emails longer than the email column allows
+func ValidateEmailLength(email string) error {
+	if utf8.RuneCountInString(email) > maxEmailLength {
+		return fmt.Errorf("%w: must be at most %d characters", ErrInvalidEmail, maxEmailLength)
+	}
+	return nil
+}
+
+// validateNewPassword checks an already trimmed password against the password policy
+func validateNewPassword(password string) error {
+	if password == "" {
+		return errors.New("password cannot be empty")
+	}
+	return validatePassword(password)
+}
+
+// ValidateNewUser runs the checks of NewUser, SetUsername and SetAvatarURL without hashing
+// the password or creating anything, returning the error of each invalid field keyed by
+// its JSON name. It does not check uniqueness.
+func ValidateNewUser(name, username, email, password string, age int, avatarURL string) map[string]error {
+	errs := make(map[string]error)
+	var scratch User
+
+	if _, err := normalizeName(name); err != nil {
+		errs["name"] = err
+	}
+	if err := scratch.SetUsername(username); err != nil {
+		errs["username"] = err
+	}
+	if err := validateNewEmail(strings.TrimSpace(email)); err != nil {
+		errs["email"] = err
+	}
+	if err := validateNewPassword(strings.TrimSpace(password)); err != nil {
+		errs["password"] = err
+	}
+	if err := validateAge(age); err != nil {
+		errs["age"] = err
+	}
+	if err := scratch.SetAvatarURL(avatarURL); err != nil {
+		errs["avatar_url"] = err
+	}
+
+	return errs
+}
+
+// Update updates the profile fields with validation; the email only changes through
+// ChangeEmail, after the new address is verified
+func (u *User) Update(name string, age int) error {
+	name, err := normalizeName(name)
+	if err != nil {
+		return err
+	}
+	if err := validateAge(age); err != nil {
+		return err
 	}
 
 	u.Name = name
-	u.Email = email
 	u.Age = age
 	u.UpdatedAt = time.Now()
 
 	return nil
 }
 
+// ChangeEmail replaces the email with a verified new address
+func (u *User) ChangeEmail(email string) error {
+	if err := validateNewEmail(email); err != nil {
+		return err
+	}
+
+	u.Email = email
+	u.UpdatedAt = time.Now()
+
+	return nil
+}
+
+// normalizeName trims the name and collapses inner runs of whitespace to a single space.
+// It rejects empty names, names longer than the column allows and control characters.
+func normalizeName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return "", fmt.Errorf("%w: name cannot be empty", ErrInvalidName)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return "", fmt.Errorf("%w: must be at most %d characters", ErrInvalidName, maxNameLength)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", fmt.Errorf("%w: must not contain control characters", ErrInvalidName)
+	}
+	return name, nil
+}
+
+// usernamePattern allows 3-30 lowercase letters, digits and underscores
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// NormalizeUsername trims and lowercases a username
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// SetUsername normalizes and validates the username; an empty string clears it
+func (u *User) SetUsername(username string) error {
+	username = NormalizeUsername(username)
+	if username != "" && !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: must be 3-30 characters of letters, digits or underscores", ErrInvalidUsername)
+	}
+
+	u.Username = username
+	return nil
+}
+
+// SetAvatarURL sets the avatar URL; an empty string clears it
+func (u *User) SetAvatarURL(avatarURL string) error {
+	avatarURL = strings.TrimSpace(avatarURL)
+	if avatarURL != "" {
+		if len(avatarURL) > maxAvatarURLLength {
+			return fmt.Errorf("%w: must be at most %d characters", ErrInvalidAvatarURL, maxAvatarURLLength)
+		}
+		parsed, err := url.Parse(avatarURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: must be an absolute http or https URL", ErrInvalidAvatarURL)
+		}
+	}
+
+	u.AvatarURL = avatarURL
+	return nil
+}
+
+// ModifiedSince reports whether the user was updated after t. HTTP dates only carry
+// whole seconds, so UpdatedAt is truncated before comparing.
+func (u *User) ModifiedSince(t time.Time) bool {
+	return u.UpdatedAt.Truncate(time.Second).After(t)
+}
+
+// IsSuspended reports whether the account is suspended
+func (u *User) IsSuspended() bool {
+	return u.Status == UserStatusSuspended
+}
+
+// Suspend disables the account; only active accounts can be suspended
+func (u *User) Suspend() error {
+	if u.Status != UserStatusActive {
+		return fmt.Errorf("%w: cannot suspend a %s account", ErrInvalidStatusTransition, u.Status)
+	}
+	u.Status = UserStatusSuspended
+	return nil
+}
+
+// Activate re-enables a suspended account
+func (u *User) Activate() error {
+	if u.Status != UserStatusSuspended {
+		return fmt.Errorf("%w: cannot activate a %s account", ErrInvalidStatusTransition, u.Status)
+	}
+	u.Status = UserStatusActive
+	return nil
+}
+
 // UpdatePassword updates user password with validation
 func (u *User) UpdatePassword(oldPassword, newPassword string) error {
 	// Verify old password
@@ -90,18 +260,20 @@ func (u *User) UpdatePassword(oldPassword, newPassword string) error {
 	if newPassword == "" {
 		return errors.New("new password cannot be empty")
 	}
-	if len(newPassword) < 8 {
-		return errors.New("new password must be at least 8 characters")
+	if err := validatePassword(newPassword); err != nil {
+		return err
 	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := HashPassword(newPassword)
 	if err != nil {
 		return errors.New("failed to hash new password")
 	}
 
-	u.PasswordHash = string(hashedPassword)
-	u.UpdatedAt = time.Now()
+	now := time.Now()
+	u.PasswordHash = hashedPassword
+	u.PasswordChangedAt = &now
+	u.UpdatedAt = now
 
 	return nil
 }
@@ -111,52 +283,133 @@ func (u *User) SetPassword(newPassword string) error {
 	if newPassword == "" {
 		return errors.New("password cannot be empty")
 	}
-	if len(newPassword) < 8 {
-		return errors.New("password must be at least 8 characters")
+	if err := validatePassword(newPassword); err != nil {
+		return err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := HashPassword(newPassword)
 	if err != nil {
 		return errors.New("failed to hash password")
 	}
 
-	u.PasswordHash = string(hashedPassword)
-	u.UpdatedAt = time.Now()
+	now := time.Now()
+	u.PasswordHash = hashedPassword
+	u.PasswordChangedAt = &now
+	u.UpdatedAt = now
 
 	return nil
 }
 
 // ComparePassword compares given password with stored hash
 func (u *User) ComparePassword(password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
+	return ComparePasswordHash(u.PasswordHash, password)
+}
+
+// PasswordNeedsRehash reports whether the password hash was made by a scheme other than
+// the configured one
+func (u *User) PasswordNeedsRehash() bool {
+	return hashScheme(u.PasswordHash) != getPasswordHasher().Scheme()
+}
+
+// RehashPassword hashes an already verified password with the configured scheme. The
+// password itself is unchanged, so PasswordChangedAt and UpdatedAt are left alone.
+func (u *User) RehashPassword(password string) error {
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	u.PasswordHash = hashedPassword
+	return nil
+}
+
+// CheckPasswordReuse returns ErrPasswordReused if password matches any of the given hashes
+func CheckPasswordReuse(password string, hashes []string) error {
+	for _, hash := range hashes {
+		if ComparePasswordHash(hash, password) == nil {
+			return ErrPasswordReused
+		}
+	}
+	return nil
 }
 
 // ToPublicUser returns user without sensitive information
 func (u *User) ToPublicUser() *PublicUser {
 	return &PublicUser{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Age:       u.Age,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:          u.ID,
+		Name:        u.Name,
+		Username:    u.Username,
+		Email:       u.Email,
+		Age:         u.Age,
+		AvatarURL:   u.AvatarURL,
+		Status:      u.Status,
+		LastLoginAt: u.LastLoginAt,
+		CreatedAt:   u.CreatedAt,
+		UpdatedAt:   u.UpdatedAt,
 	}
 }
 
 // PublicUser represents user data for public API responses
 type PublicUser struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Age       int       `json:"age"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Username    string     `json:"username,omitempty"`
+	Email       string     `json:"email"`
+	Age         int        `json:"age"`
+	AvatarURL   string     `json:"avatar_url,omitempty"`
+	Status      string     `json:"status"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ScoredUser is a user paired with its relevance score from a ranked search
+type ScoredUser struct {
+	User  *User
+	Score float64
 }
 
 // Common domain errors
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrInvalidUserData   = errors.New("invalid user data")
-	ErrInvalidPassword   = errors.New("invalid password")
-)
\ No newline at end of file
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrInvalidUserData    = errors.New("invalid user data")
+	ErrInvalidPassword    = errors.New("invalid password")
+	ErrInvalidResetToken  = errors.New("invalid or expired reset token")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+
+	ErrFuzzySearchUnavailable = errors.New("fuzzy search is unavailable")
+	ErrTooManyQueries         = errors.New("too many concurrent queries")
+	ErrInvalidCursor          = errors.New("invalid or mismatched cursor")
+	ErrSearchKeywordTooShort  = errors.New("search keyword is too short")
+	ErrSearchKeywordTooLong   = errors.New("search keyword is too long")
+	ErrDatabaseUnavailable    = errors.New("database is unavailable")
+	ErrInvalidAvatarURL       = errors.New("invalid avatar url")
+	ErrInvalidName            = errors.New("invalid name")
+	ErrInvalidEmail           = errors.New("invalid email")
+	ErrInvalidUsername        = errors.New("invalid username")
+	ErrUsernameTaken          = errors.New("username is already taken")
+
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrAccountSuspended        = errors.New("account is suspended")
+	ErrPreconditionFailed      = errors.New("user was modified since the given time")
+	ErrPasswordReused          = errors.New("password was used recently")
+
+	ErrInvalidEmailChangeToken         = errors.New("invalid or expired email change token")
+	ErrEmailChangeRequiresVerification = errors.New("email can only be changed through the email change flow")
+)
+
+// UserActivity summarizes a user's account timestamps and status
+type UserActivity struct {
+	UserID            int64      `json:"user_id"`
+	Status            string     `json:"status,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	LastLoginAt       *time.Time `json:"last_login_at"`
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+}
+
+// PasswordChange is one entry of a user's password history; the hash is never exposed
+type PasswordChange struct {
+	ChangedAt time.Time `json:"changed_at"`
+	SourceIP  string    `json:"source_ip,omitempty"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// UserStats aggregates user counts for dashboards
+type UserStats struct {
+	TotalUsers    int64        `json:"total_users"`
+	AverageAge    float64      `json:"average_age"`
+	AgeBuckets    []AgeBucket  `json:"age_buckets"`
+	SignupsPerDay []DailyCount `json:"signups_per_day"`
+	Days          int          `json:"days"` // Lookback window of SignupsPerDay
+}
+
+// AgeBucket counts users whose age falls in [Min, Max]; Max is nil for the open-ended last bucket
+type AgeBucket struct {
+	Label string `json:"label"`
+	Min   int    `json:"min"`
+	Max   *int   `json:"max"`
+	Count int64  `json:"count"`
+}
+
+// DailyCount counts events on a single day
+type DailyCount struct {
+	Date  time.Time `json:"date"`
+	Count int64     `json:"count"`
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModifiedSince(t *testing.T) {
+	updated := time.Date(2024, 1, 1, 12, 0, 0, 500_000_000, time.UTC)
+	u := &User{UpdatedAt: updated}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"before the update", updated.Add(-time.Second), true},
+		{"same second as the update", updated.Truncate(time.Second), false},
+		{"after the update", updated.Add(time.Second), false},
+	}
+
+	for _, tt := range tests {
+		if got := u.ModifiedSince(tt.t); got != tt.want {
+			t.Errorf("%s: ModifiedSince(%v) = %t, want %t", tt.name, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestNameNormalization(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		valid bool
+	}{
+		{"plain", "Alice Smith", "Alice Smith", true},
+		{"surrounding whitespace", "  Alice Smith \t", "Alice Smith", true},
+		{"inner whitespace runs", "Alice \t\n  Smith", "Alice Smith", true},
+		{"empty", "", "", false},
+		{"whitespace only", " \t\n ", "", false},
+		{"at the length limit", strings.Repeat("é", maxNameLength), strings.Repeat("é", maxNameLength), true},
+		{"over the length limit", strings.Repeat("a", maxNameLength+1), "", false},
+		{"limit reached only after trimming", "  " + strings.Repeat("a", maxNameLength) + "  ", strings.Repeat("a", maxNameLength), true},
+		{"embedded NUL", "Alice\x00Smith", "", false},
+		{"embedded bell", "Alice\aSmith", "", false},
+		{"embedded DEL", "Alice\x7fSmith", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, createErr := NewUser(tt.input, "alice@example.com", "Str0ng!pass", 30)
+			updated := &User{Name: "Before", Age: 30}
+			updateErr := updated.Update(tt.input, 30)
+
+			if !tt.valid {
+				if !errors.Is(createErr, ErrInvalidName) || !errors.Is(updateErr, ErrInvalidName) {
+					t.Errorf("NewUser = %v, Update = %v; want ErrInvalidName from both", createErr, updateErr)
+				}
+				return
+			}
+			if createErr != nil || updateErr != nil {
+				t.Fatalf("NewUser = %v, Update = %v; want both to accept the name", createErr, updateErr)
+			}
+			if created.Name != tt.want || updated.Name != tt.want {
+				t.Errorf("NewUser stored %q and Update stored %q, want %q", created.Name, updated.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmailLength(t *testing.T) {
+	const suffix = "@example.com"
+	atLimit := strings.Repeat("a", maxEmailLength-len(suffix)) + suffix
+	overLimit := "a" + atLimit
+
+	if _, err := NewUser("Alice", atLimit, "Str0ng!pass", 30); err != nil {
+		t.Errorf("NewUser with a %d character email = %v, want nil", len(atLimit), err)
+	}
+	if _, err := NewUser("Alice", overLimit, "Str0ng!pass", 30); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("NewUser with a %d character email = %v, want ErrInvalidEmail", len(overLimit), err)
+	}
+	if err := (&User{Email: "alice@example.com"}).ChangeEmail(overLimit); !errors.Is(err, ErrInvalidEmail) {
+		t.Errorf("ChangeEmail with a %d character email = %v, want ErrInvalidEmail", len(overLimit), err)
+	}
+}
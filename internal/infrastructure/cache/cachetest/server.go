@@ -0,0 +1,505 @@
+// Package cachetest runs an in-memory stand-in for Redis, so code built on
+// cache.RedisCache can be tested without a Redis server.
+package cachetest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"user-crud/internal/infrastructure/cache"
+)
+
+// Server speaks enough of the Redis protocol for cache.RedisCache: strings with
+// expiry, counters, deletes and SCAN. It is not a general-purpose Redis.
+type Server struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	data  map[string]entry
+	calls map[string]int
+	delay time.Duration
+	conns map[net.Conn]struct{}
+
+	wg sync.WaitGroup
+}
+
+type entry struct {
+	value     string
+	expiresAt time.Time // zero means no expiry
+}
+
+// NewServer starts a server on a random local port and stops it when the test ends
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cachetest: listen: %v", err)
+	}
+
+	s := &Server{
+		ln:    ln,
+		data:  make(map[string]entry),
+		calls: make(map[string]int),
+		conns: make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewRedisCache starts a server and returns a cache connected to it; both are closed
+// when the test ends
+func NewRedisCache(t testing.TB, ttl time.Duration) (*cache.RedisCache, *Server) {
+	t.Helper()
+
+	s := NewServer(t)
+	host, port, _ := net.SplitHostPort(s.Addr())
+	c, err := cache.NewRedisCache(host, port, ttl, cache.RedisOptions{PoolSize: 4})
+	if err != nil {
+		t.Fatalf("cachetest: connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, s
+}
+
+// Addr returns the host:port the server listens on
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops the server and drops open connections
+func (s *Server) Close() {
+	s.ln.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// SetDelay makes every later command wait d before it is answered, like a slow or
+// hung Redis
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// Calls returns how many times a command, such as "SET", was received
+func (s *Server) Calls(command string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[strings.ToUpper(command)]
+}
+
+// Get returns the value stored at key
+func (s *Server) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.lookup(key)
+	return e.value, ok
+}
+
+// Set stores value at key without expiry
+func (s *Server) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = entry{value: value}
+}
+
+// TTL returns the time left before key expires, or 0 if it never does
+func (s *Server) TTL(key string) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.lookup(key)
+	if !ok || e.expiresAt.IsZero() {
+		return 0
+	}
+	return time.Until(e.expiresAt)
+}
+
+// Keys returns the keys matching a glob pattern, sorted
+func (s *Server) Keys(pattern string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.keys(pattern)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		delay := s.delay
+		s.mu.Unlock()
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+
+		s.mu.Lock()
+		reply := s.exec(args)
+		s.mu.Unlock()
+
+		if _, err := w.WriteString(reply); err != nil {
+			return
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readCommand reads one command sent as an array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(line, "$") {
+			return nil, fmt.Errorf("unexpected %q", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+// exec runs one command and returns the encoded reply; the caller holds mu
+func (s *Server) exec(args []string) string {
+	if len(args) == 0 {
+		return errReply("empty command")
+	}
+	name := strings.ToUpper(args[0])
+	s.calls[name]++
+	args = args[1:]
+
+	switch name {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		if len(args) != 1 {
+			return arityError(name)
+		}
+		if e, ok := s.lookup(args[0]); ok {
+			return bulk(e.value)
+		}
+		return nilReply
+	case "GETDEL":
+		if len(args) != 1 {
+			return arityError(name)
+		}
+		if e, ok := s.lookup(args[0]); ok {
+			delete(s.data, args[0])
+			return bulk(e.value)
+		}
+		return nilReply
+	case "MGET":
+		values := make([]string, len(args))
+		for i, key := range args {
+			if e, ok := s.lookup(key); ok {
+				values[i] = bulk(e.value)
+			} else {
+				values[i] = nilReply
+			}
+		}
+		return fmt.Sprintf("*%d\r\n%s", len(values), strings.Join(values, ""))
+	case "SET":
+		return s.set(args)
+	case "SETNX":
+		if len(args) != 2 {
+			return arityError(name)
+		}
+		if _, ok := s.lookup(args[0]); ok {
+			return integer(0)
+		}
+		s.data[args[0]] = entry{value: args[1]}
+		return integer(1)
+	case "DEL", "UNLINK":
+		var n int64
+		for _, key := range args {
+			if _, ok := s.lookup(key); ok {
+				delete(s.data, key)
+				n++
+			}
+		}
+		return integer(n)
+	case "EXISTS":
+		var n int64
+		for _, key := range args {
+			if _, ok := s.lookup(key); ok {
+				n++
+			}
+		}
+		return integer(n)
+	case "INCR":
+		if len(args) != 1 {
+			return arityError(name)
+		}
+		e, _ := s.lookup(args[0])
+		n := int64(0)
+		if e.value != "" {
+			var err error
+			if n, err = strconv.ParseInt(e.value, 10, 64); err != nil {
+				return errReply("value is not an integer or out of range")
+			}
+		}
+		n++
+		e.value = strconv.FormatInt(n, 10)
+		s.data[args[0]] = e
+		return integer(n)
+	case "EXPIRE":
+		if len(args) != 2 {
+			return arityError(name)
+		}
+		secs, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errReply("value is not an integer or out of range")
+		}
+		e, ok := s.lookup(args[0])
+		if !ok {
+			return integer(0)
+		}
+		e.expiresAt = time.Now().Add(time.Duration(secs) * time.Second)
+		s.data[args[0]] = e
+		return integer(1)
+	case "TTL":
+		if len(args) != 1 {
+			return arityError(name)
+		}
+		e, ok := s.lookup(args[0])
+		switch {
+		case !ok:
+			return integer(-2)
+		case e.expiresAt.IsZero():
+			return integer(-1)
+		}
+		return integer(int64(time.Until(e.expiresAt).Round(time.Second) / time.Second))
+	case "SCAN":
+		return s.scan(args)
+	case "FLUSHDB", "FLUSHALL":
+		s.data = make(map[string]entry)
+		return "+OK\r\n"
+	default:
+		// HELLO and CLIENT land here, so clients fall back to RESP2 without extras
+		return errReply(fmt.Sprintf("unknown command '%s'", name))
+	}
+}
+
+// set handles SET key value [EX s | PX ms | KEEPTTL] [NX | XX] [GET]
+func (s *Server) set(args []string) string {
+	if len(args) < 2 {
+		return arityError("SET")
+	}
+	key := args[0]
+	next := entry{value: args[1]}
+	var nx, xx, keepTTL, get bool
+
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "GET":
+			get = true
+		case "KEEPTTL":
+			keepTTL = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return errReply("syntax error")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return errReply("invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+			next.expiresAt = time.Now().Add(time.Duration(n) * unit)
+			i++
+		default:
+			return errReply("syntax error")
+		}
+	}
+
+	current, exists := s.lookup(key)
+	if (nx && exists) || (xx && !exists) {
+		if get && exists {
+			return bulk(current.value)
+		}
+		return nilReply
+	}
+	if keepTTL && exists {
+		next.expiresAt = current.expiresAt
+	}
+	s.data[key] = next
+
+	if get {
+		if exists {
+			return bulk(current.value)
+		}
+		return nilReply
+	}
+	return "+OK\r\n"
+}
+
+// scan handles SCAN cursor [MATCH pattern] [COUNT n]; the cursor is an offset into the
+// sorted key list
+func (s *Server) scan(args []string) string {
+	if len(args) < 1 {
+		return arityError("SCAN")
+	}
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errReply("invalid cursor")
+	}
+	pattern, count := "*", 10
+	for i := 1; i+1 < len(args); i += 2 {
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			if count, err = strconv.Atoi(args[i+1]); err != nil || count < 1 {
+				return errReply("syntax error")
+			}
+		}
+	}
+
+	keys := s.keys("*")
+	end := min(cursor+count, len(keys))
+	var batch []string
+	if cursor < len(keys) {
+		for _, key := range keys[cursor:end] {
+			if ok, _ := path.Match(pattern, key); ok {
+				batch = append(batch, bulk(key))
+			}
+		}
+	}
+	nextCursor := end
+	if end >= len(keys) {
+		nextCursor = 0
+	}
+	return fmt.Sprintf("*2\r\n%s*%d\r\n%s", bulk(strconv.Itoa(nextCursor)), len(batch), strings.Join(batch, ""))
+}
+
+// lookup returns the live entry at key, dropping it if it has expired; the caller holds mu
+func (s *Server) lookup(key string) (entry, bool) {
+	e, ok := s.data[key]
+	if ok && !e.expiresAt.IsZero() && !time.Now().Before(e.expiresAt) {
+		delete(s.data, key)
+		return entry{}, false
+	}
+	return e, ok
+}
+
+// keys returns the sorted live keys matching pattern; the caller holds mu
+func (s *Server) keys(pattern string) []string {
+	var keys []string
+	for key := range s.data {
+		if _, ok := s.lookup(key); !ok {
+			continue
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+const nilReply = "$-1\r\n"
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
+
+func integer(n int64) string {
+	return fmt.Sprintf(":%d\r\n", n)
+}
+
+func errReply(msg string) string {
+	return "-ERR " + msg + "\r\n"
+}
+
+func arityError(command string) string {
+	return errReply(fmt.Sprintf("wrong number of arguments for '%s' command", strings.ToLower(command)))
+}
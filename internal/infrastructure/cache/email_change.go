@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PendingEmailChange is an email change waiting for the user to confirm it
+type PendingEmailChange struct {
+	UserID   int64  `json:"user_id"`
+	NewEmail string `json:"new_email"`
+}
+
+// SetEmailChange stores a pending email change under its confirmation token
+func (c *RedisCache) SetEmailChange(ctx context.Context, token string, change PendingEmailChange, ttl time.Duration) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, emailChangeKey(token), data, ttl).Err()
+}
+
+// GetEmailChange returns the pending email change for a token (nil if not found or expired)
+func (c *RedisCache) GetEmailChange(ctx context.Context, token string) (*PendingEmailChange, error) {
+	val, err := c.client.Get(ctx, emailChangeKey(token)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var change PendingEmailChange
+	if err := json.Unmarshal([]byte(val), &change); err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// DeleteEmailChange deletes a pending email change
+func (c *RedisCache) DeleteEmailChange(ctx context.Context, token string) error {
+	return c.client.Del(ctx, emailChangeKey(token)).Err()
+}
+
+// emailChangeKey hashes the token so raw tokens are never stored in Redis
+func emailChangeKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "email_change:" + hex.EncodeToString(sum[:])
+}
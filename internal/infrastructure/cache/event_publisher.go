@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+
+	"user-crud/internal/application/event"
+)
+
+// RedisEventPublisher publishes events as JSON on a Redis Pub/Sub channel
+type RedisEventPublisher struct {
+	cache   *RedisCache
+	channel string
+}
+
+// NewRedisEventPublisher creates a publisher that reuses the cache's Redis client
+func NewRedisEventPublisher(cache *RedisCache, channel string) *RedisEventPublisher {
+	return &RedisEventPublisher{cache: cache, channel: channel}
+}
+
+// Publish sends the event to all current subscribers of the channel
+func (p *RedisEventPublisher) Publish(ctx context.Context, e event.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return p.cache.client.Publish(ctx, p.channel, data).Err()
+}
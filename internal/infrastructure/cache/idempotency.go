@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyRecord is the stored state of a request made with an Idempotency-Key
+type IdempotencyRecord struct {
+	Completed bool `json:"completed"`
+	// BodyHash fingerprints the request body the key was first used with
+	BodyHash    string `json:"body_hash,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// StartIdempotentRequest claims an idempotency key for a request whose body hashes to
+// bodyHash. If the key is already claimed, it returns false together with the stored
+// record (nil if it expired meanwhile).
+func (c *RedisCache) StartIdempotentRequest(ctx context.Context, key, bodyHash string, ttl time.Duration) (bool, *IdempotencyRecord, error) {
+	data, err := json.Marshal(IdempotencyRecord{Completed: false, BodyHash: bodyHash})
+	if err != nil {
+		return false, nil, err
+	}
+
+	acquired, err := c.client.SetNX(ctx, idempotencyKey(key), data, ttl).Result()
+	if err != nil {
+		return false, nil, err
+	}
+	if acquired {
+		return true, nil, nil
+	}
+
+	val, err := c.client.Get(ctx, idempotencyKey(key)).Bytes()
+	if err == redis.Nil {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	var record IdempotencyRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		return false, nil, err
+	}
+
+	return false, &record, nil
+}
+
+// CompleteIdempotentRequest stores the final response for an idempotency key
+func (c *RedisCache) CompleteIdempotentRequest(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	record.Completed = true
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, idempotencyKey(key), data, ttl).Err()
+}
+
+// ReleaseIdempotentRequest removes an idempotency key so the request can be retried
+func (c *RedisCache) ReleaseIdempotentRequest(ctx context.Context, key string) error {
+	return c.client.Del(ctx, idempotencyKey(key)).Err()
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
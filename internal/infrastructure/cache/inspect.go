@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// UserEntry describes the cached copy of a user, for operators
+type UserEntry struct {
+	Cached bool
+	TTL    time.Duration // Remaining lifetime; negative when the key has no expiry
+}
+
+// InspectUser reports whether a user is cached and how long until the entry expires
+func (c *RedisCache) InspectUser(ctx context.Context, id int64) (UserEntry, error) {
+	ttl, err := c.client.TTL(ctx, userKey(id)).Result()
+	if err != nil {
+		return UserEntry{}, err
+	}
+	// Redis reports -2 for a missing key and -1 for a key without expiry
+	if ttl == -2 {
+		return UserEntry{}, nil
+	}
+	return UserEntry{Cached: true, TTL: ttl}, nil
+}
+
+// EvictUser removes a user's cache entry and returns how many keys were deleted
+func (c *RedisCache) EvictUser(ctx context.Context, id int64) (int64, error) {
+	return c.client.Del(ctx, userKey(id)).Result()
+}
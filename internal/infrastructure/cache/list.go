@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"user-crud/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// listGenerationKey holds a counter bumped on every user write. List cache keys embed the
+// generation they were read under, so a bump orphans every cached page at once; the
+// orphans are never read again and expire through their TTL.
+const listGenerationKey = "users:list:gen"
+
+// listKey returns the cache key for one list query, identified by fingerprint, at gen
+func listKey(gen int64, fingerprint string) string {
+	return fmt.Sprintf("users:list:v%d:g%d:%s", UserCacheVersion, gen, fingerprint)
+}
+
+// UserList is a cached page of list results as returned by the repository
+type UserList struct {
+	Users []*domain.User `json:"users"`
+	Total int64          `json:"total"`
+}
+
+// ListGeneration returns the current list generation; 0 until the first write
+func (c *RedisCache) ListGeneration(ctx context.Context) (int64, error) {
+	gen, err := c.client.Get(ctx, listGenerationKey).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return gen, err
+}
+
+// BumpListGeneration invalidates every cached list page and returns the new generation
+func (c *RedisCache) BumpListGeneration(ctx context.Context) (int64, error) {
+	return c.client.Incr(ctx, listGenerationKey).Result()
+}
+
+// GetUserList gets the cached list for fingerprint at gen (nil on a miss)
+func (c *RedisCache) GetUserList(ctx context.Context, gen int64, fingerprint string) (*UserList, error) {
+	key := listKey(gen, fingerprint)
+
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil // Cache miss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list UserList
+	if err := json.Unmarshal([]byte(val), &list); err != nil {
+		c.evictCorrupt(ctx, key, err)
+		return nil, nil
+	}
+
+	return &list, nil
+}
+
+// SetUserList caches the list for fingerprint at gen
+func (c *RedisCache) SetUserList(ctx context.Context, gen int64, fingerprint string, list *UserList, ttl time.Duration) error {
+	// An empty page is cached as [] rather than null so it reads back as a hit
+	if list.Users == nil {
+		list = &UserList{Users: []*domain.User{}, Total: list.Total}
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, listKey(gen, fingerprint), data, ttl).Err()
+}
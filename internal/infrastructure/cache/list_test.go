@@ -0,0 +1,39 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+func TestBumpListGenerationOrphansCachedPages(t *testing.T) {
+	ctx := context.Background()
+	redisCache, _ := cachetest.NewRedisCache(t, time.Minute)
+
+	gen, err := redisCache.ListGeneration(ctx)
+	if err != nil || gen != 0 {
+		t.Fatalf("ListGeneration before any write = %d, %v; want 0", gen, err)
+	}
+	page := &cache.UserList{Users: []*domain.User{{ID: 1, Name: "Alice"}}, Total: 1}
+	if err := redisCache.SetUserList(ctx, gen, "page=1", page, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	bumped, err := redisCache.BumpListGeneration(ctx)
+	if err != nil || bumped != 1 {
+		t.Fatalf("BumpListGeneration = %d, %v; want 1", bumped, err)
+	}
+	if gen, _ := redisCache.ListGeneration(ctx); gen != bumped {
+		t.Errorf("ListGeneration after a bump = %d, want %d", gen, bumped)
+	}
+	if list, err := redisCache.GetUserList(ctx, bumped, "page=1"); list != nil || err != nil {
+		t.Errorf("page cached under the old generation was read: %v, %v", list, err)
+	}
+	if list, _ := redisCache.GetUserList(ctx, 0, "page=1"); list == nil || list.Total != 1 {
+		t.Errorf("page under its own generation = %v, want it untouched", list)
+	}
+}
@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"context"
+)
+
+// maintenanceKey is set while maintenance mode is toggled on at runtime
+const maintenanceKey = "maintenance:enabled"
+
+// SetMaintenance turns the runtime maintenance flag on or off
+func (c *RedisCache) SetMaintenance(ctx context.Context, enabled bool) error {
+	if !enabled {
+		return c.client.Del(ctx, maintenanceKey).Err()
+	}
+	return c.client.Set(ctx, maintenanceKey, 1, 0).Err()
+}
+
+// MaintenanceEnabled reports whether the runtime maintenance flag is set
+func (c *RedisCache) MaintenanceEnabled(ctx context.Context) (bool, error) {
+	n, err := c.client.Exists(ctx, maintenanceKey).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
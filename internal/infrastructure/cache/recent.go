@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"user-crud/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// recentUsersKey returns the cache key for the newest users, per requested limit
+func recentUsersKey(limit int) string {
+	return fmt.Sprintf("recent:v%d:%d", UserCacheVersion, limit)
+}
+
+// GetRecentUsers gets the cached newest users for limit (nil on a miss)
+func (c *RedisCache) GetRecentUsers(ctx context.Context, limit int) ([]*domain.User, error) {
+	key := recentUsersKey(limit)
+
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil // Cache miss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var users []*domain.User
+	if err := json.Unmarshal([]byte(val), &users); err != nil {
+		c.evictCorrupt(ctx, key, err)
+		return nil, nil
+	}
+
+	return users, nil
+}
+
+// SetRecentUsers caches the newest users for limit
+func (c *RedisCache) SetRecentUsers(ctx context.Context, limit int, users []*domain.User, ttl time.Duration) error {
+	// An empty list is cached as [] rather than null so it reads back as a hit
+	if users == nil {
+		users = []*domain.User{}
+	}
+
+	data, err := json.Marshal(users)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, recentUsersKey(limit), data, ttl).Err()
+}
@@ -2,8 +2,11 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"user-crud/internal/domain"
@@ -11,39 +14,79 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// UserCacheVersion is part of every user cache key. Bump it whenever domain.User's JSON
+// shape changes so entries written by older deploys are ignored instead of misread.
+const UserCacheVersion = 2
+
+// userKey returns the versioned cache key for a user
+func userKey(id int64) string {
+	return fmt.Sprintf("user:v%d:%d", UserCacheVersion, id)
+}
+
 type RedisCache struct {
 	client *redis.Client
 	ttl    time.Duration
+	logger *slog.Logger
+}
+
+// RedisOptions tunes the Redis connection pool
+type RedisOptions struct {
+	PoolSize     int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	MaxRetries   int           // Retries per command; 0 disables retries
+	OpTimeout    time.Duration // Upper bound on every command, even without a caller deadline; 0 disables
+	Logger       *slog.Logger  // Receives cache errors; nil uses slog.Default()
 }
 
-func NewRedisCache(host, port string, ttl time.Duration) (*RedisCache, error) {
+func NewRedisCache(host, port string, ttl time.Duration, opts RedisOptions) (*RedisCache, error) {
+	// go-redis treats 0 as "use the default" and -1 as "no retries"
+	maxRetries := opts.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = -1
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:         fmt.Sprintf("%s:%s", host, port),
 		Password:     "", // no password
 		DB:           0,  // default DB
-		DialTimeout:  5 * time.Second,
-		ReadTimeout:  3 * time.Second,
-		WriteTimeout: 3 * time.Second,
-		PoolSize:     10,
+		DialTimeout:  opts.DialTimeout,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		PoolSize:     opts.PoolSize,
+		MaxRetries:   maxRetries,
+		// Honor context deadlines on the connection so a hung Redis can't block past OpTimeout
+		ContextTimeoutEnabled: true,
 	})
+	if opts.OpTimeout > 0 {
+		client.AddHook(opTimeoutHook{timeout: opts.OpTimeout})
+	}
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	return &RedisCache{
 		client: client,
 		ttl:    ttl,
+		logger: logger,
 	}, nil
 }
 
 // GetUser gets user from cache
 func (c *RedisCache) GetUser(ctx context.Context, id int64) (*domain.User, error) {
-	key := fmt.Sprintf("user:%d", id)
+	key := userKey(id)
 
 	val, err := c.client.Get(ctx, key).Result()
 	if err == redis.Nil {
@@ -55,15 +98,72 @@ func (c *RedisCache) GetUser(ctx context.Context, id int64) (*domain.User, error
 
 	var user domain.User
 	if err := json.Unmarshal([]byte(val), &user); err != nil {
-		return nil, err
+		// Stale or corrupt entry (e.g. written by an older version); evict it and
+		// report a miss so the caller repopulates it from the database
+		c.evictCorrupt(ctx, key, err)
+		return nil, nil
 	}
 
 	return &user, nil
 }
 
+// GetUsers gets several users from cache with a single MGET; misses are absent from the map
+func (c *RedisCache) GetUsers(ctx context.Context, ids []int64) (map[int64]*domain.User, error) {
+	if len(ids) == 0 {
+		return map[int64]*domain.User{}, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = userKey(id)
+	}
+
+	vals, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[int64]*domain.User, len(ids))
+	for i, val := range vals {
+		s, ok := val.(string)
+		if !ok {
+			continue // Cache miss
+		}
+
+		var user domain.User
+		if err := json.Unmarshal([]byte(s), &user); err != nil {
+			c.evictCorrupt(ctx, keys[i], err)
+			continue
+		}
+		users[ids[i]] = &user
+	}
+
+	return users, nil
+}
+
+// evictCorrupt deletes a cache entry that could not be decoded
+func (c *RedisCache) evictCorrupt(ctx context.Context, key string, decodeErr error) {
+	c.logger.Warn("evicting undecodable cache entry", "key", key, "error", decodeErr)
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		c.logger.Error("failed to evict cache entry", "key", key, "error", err)
+	}
+}
+
+// HasUser reports whether the user is present in cache
+func (c *RedisCache) HasUser(ctx context.Context, id int64) (bool, error) {
+	key := userKey(id)
+
+	n, err := c.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
 // SetUser sets user in cache
 func (c *RedisCache) SetUser(ctx context.Context, user *domain.User) error {
-	key := fmt.Sprintf("user:%d", user.ID)
+	key := userKey(user.ID)
 
 	data, err := json.Marshal(user)
 	if err != nil {
@@ -75,13 +175,91 @@ func (c *RedisCache) SetUser(ctx context.Context, user *domain.User) error {
 
 // DeleteUser deletes user from cache
 func (c *RedisCache) DeleteUser(ctx context.Context, id int64) error {
-	key := fmt.Sprintf("user:%d", id)
+	key := userKey(id)
 	return c.client.Del(ctx, key).Err()
 }
 
-// Clear clears all cache
-func (c *RedisCache) Clear(ctx context.Context) error {
-	return c.client.FlushDB(ctx).Err()
+// SetPasswordResetToken stores a password reset token for the given user
+func (c *RedisCache) SetPasswordResetToken(ctx context.Context, token string, userID int64, ttl time.Duration) error {
+	return c.client.Set(ctx, passwordResetKey(token), userID, ttl).Err()
+}
+
+// GetPasswordResetToken returns the user id bound to a password reset token (0 if not found)
+func (c *RedisCache) GetPasswordResetToken(ctx context.Context, token string) (int64, error) {
+	userID, err := c.client.Get(ctx, passwordResetKey(token)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ConsumePasswordResetToken atomically deletes a password reset token and returns the user
+// id it was bound to (0 if not found), so only one caller can ever redeem a token
+func (c *RedisCache) ConsumePasswordResetToken(ctx context.Context, token string) (int64, error) {
+	userID, err := c.client.GetDel(ctx, passwordResetKey(token)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// passwordResetKey hashes the token so raw tokens are never stored in Redis
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "password_reset:" + hex.EncodeToString(sum[:])
+}
+
+// cachedDataPatterns match every key holding cached data, as opposed to state such as
+// reset tokens, idempotency records or the maintenance flag that only lives in Redis
+var cachedDataPatterns = []string{"user:v*", "users:list:v*", "recent:v*", "stats:v*"}
+
+// clearScanCount is the SCAN batch size used by Clear
+const clearScanCount = 500
+
+// Clear deletes every cached user, list page, recent users list and stats entry, leaving
+// other keys alone, and returns how many keys it deleted
+func (c *RedisCache) Clear(ctx context.Context) (int64, error) {
+	var deleted int64
+	for _, pattern := range cachedDataPatterns {
+		iter := c.client.Scan(ctx, 0, pattern, clearScanCount).Iterator()
+		batch := make([]string, 0, clearScanCount)
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == clearScanCount {
+				n, err := c.client.Unlink(ctx, batch...).Result()
+				if err != nil {
+					return deleted, err
+				}
+				deleted += n
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return deleted, err
+		}
+		if len(batch) > 0 {
+			n, err := c.client.Unlink(ctx, batch...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+	}
+
+	// Pages being written while the scan ran land under the old generation and are never read
+	if _, err := c.BumpListGeneration(ctx); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
 }
 
 // Close closes redis connection
@@ -92,4 +270,4 @@ func (c *RedisCache) Close() error {
 // Ping checks redis connection
 func (c *RedisCache) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
-}
\ No newline at end of file
+}
@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+func userKey(id int64) string {
+	return fmt.Sprintf("user:v%d:%d", cache.UserCacheVersion, id)
+}
+
+func TestGetUserEvictsUndecodableEntry(t *testing.T) {
+	ctx := context.Background()
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+
+	for _, corrupt := range []string{"not json", `{"id":"one"}`, `{"id":1`} {
+		server.Set(userKey(1), corrupt)
+
+		user, err := redisCache.GetUser(ctx, 1)
+		if user != nil || err != nil {
+			t.Errorf("GetUser with %q cached = %v, %v; want a miss", corrupt, user, err)
+		}
+		if _, ok := server.Get(userKey(1)); ok {
+			t.Errorf("undecodable entry %q was not evicted", corrupt)
+		}
+	}
+}
+
+func TestGetUsersEvictsOnlyUndecodableEntries(t *testing.T) {
+	ctx := context.Background()
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	if err := redisCache.SetUser(ctx, &domain.User{ID: 1, Name: "Alice"}); err != nil {
+		t.Fatal(err)
+	}
+	server.Set(userKey(2), "not json")
+
+	users, err := redisCache.GetUsers(ctx, []int64{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 1 || users[1] == nil || users[1].Name != "Alice" {
+		t.Errorf("GetUsers = %v, want only the decodable user 1", users)
+	}
+	if _, ok := server.Get(userKey(2)); ok {
+		t.Error("undecodable entry was not evicted")
+	}
+	if _, ok := server.Get(userKey(1)); !ok {
+		t.Error("valid entry was evicted")
+	}
+}
+
+func TestSetUserRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	want := &domain.User{ID: 7, Name: "Alice", Email: "alice@example.com", Age: 30}
+
+	if err := redisCache.SetUser(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := redisCache.GetUser(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got == nil || got.Name != want.Name || got.Email != want.Email || got.Age != want.Age {
+		t.Errorf("GetUser = %+v, want %+v", got, want)
+	}
+	if ttl := server.TTL(userKey(7)); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL = %v, want the cache TTL", ttl)
+	}
+}
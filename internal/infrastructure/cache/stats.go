@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"user-crud/internal/domain"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// statsKey returns the cache key for user stats over a lookback window
+func statsKey(days int) string {
+	return fmt.Sprintf("stats:v%d:%d", UserCacheVersion, days)
+}
+
+// GetUserStats gets cached user stats for the window (nil on a miss)
+func (c *RedisCache) GetUserStats(ctx context.Context, days int) (*domain.UserStats, error) {
+	key := statsKey(days)
+
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return nil, nil // Cache miss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stats domain.UserStats
+	if err := json.Unmarshal([]byte(val), &stats); err != nil {
+		c.evictCorrupt(ctx, key, err)
+		return nil, nil
+	}
+
+	return &stats, nil
+}
+
+// SetUserStats caches user stats for their window
+func (c *RedisCache) SetUserStats(ctx context.Context, stats *domain.UserStats, ttl time.Duration) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+
+	return c.client.Set(ctx, statsKey(stats.Days), data, ttl).Err()
+}
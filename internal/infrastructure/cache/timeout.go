@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// opTimeoutHook bounds every Redis command and pipeline. Background writes run with
+// context.Background(), so without it a hung Redis would block their workers forever.
+type opTimeoutHook struct {
+	timeout time.Duration
+}
+
+func (h opTimeoutHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h opTimeoutHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, cancel := context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+		return next(ctx, cmd)
+	}
+}
+
+func (h opTimeoutHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, cancel := context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+		return next(ctx, cmds)
+	}
+}
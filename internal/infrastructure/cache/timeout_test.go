@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+// slowCache returns a cache with opTimeout over a Redis that answers after 500ms
+func slowCache(t *testing.T, opTimeout time.Duration) *cache.RedisCache {
+	t.Helper()
+
+	server := cachetest.NewServer(t)
+	host, port, _ := net.SplitHostPort(server.Addr())
+	c, err := cache.NewRedisCache(host, port, time.Minute, cache.RedisOptions{PoolSize: 4, OpTimeout: opTimeout})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	server.SetDelay(500 * time.Millisecond)
+	return c
+}
+
+func TestCacheOperationsAreAbandonedAfterOpTimeout(t *testing.T) {
+	c := slowCache(t, 50*time.Millisecond)
+
+	ops := map[string]func(context.Context) error{
+		"SetUser":    func(ctx context.Context) error { return c.SetUser(ctx, &domain.User{ID: 1}) },
+		"DeleteUser": func(ctx context.Context) error { return c.DeleteUser(ctx, 1) },
+		"GetUser": func(ctx context.Context) error {
+			_, err := c.GetUser(ctx, 1)
+			return err
+		},
+	}
+
+	for name, op := range ops {
+		start := time.Now()
+		err := op(context.Background())
+		if elapsed := time.Since(start); err == nil || elapsed > 300*time.Millisecond {
+			t.Errorf("%s against a hung Redis = %v after %v, want an error near the 50ms timeout", name, err, elapsed)
+		}
+	}
+}
+
+func TestBackgroundCacheWritesDoNotPileUp(t *testing.T) {
+	c := slowCache(t, 50*time.Millisecond)
+	async := cache.NewWorkerPool(1, 10, nil)
+
+	for id := range int64(5) {
+		async.Submit(func(ctx context.Context) { c.SetUser(ctx, &domain.User{ID: id}) })
+	}
+
+	// Each write gives up after 50ms, so the queue drains long before the delay would
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	async.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("five queued writes took %v to drain, want each abandoned after the timeout", elapsed)
+	}
+}
+
+func TestCallerDeadlineStillApplies(t *testing.T) {
+	c := slowCache(t, time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := c.SetUser(ctx, &domain.User{ID: 1}); err == nil || time.Since(start) > 300*time.Millisecond {
+		t.Errorf("SetUser past the caller's deadline = %v after %v, want an error near 50ms", err, time.Since(start))
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"user-crud/internal/domain"
+)
+
+// WarmUsers preloads users from the repository into the cache so the first reads after a
+// deploy don't all miss. When ids is non-empty those users are loaded, otherwise the limit
+// most recently active ones. Users that fail to cache are logged and skipped; it returns
+// how many were cached.
+func (c *RedisCache) WarmUsers(ctx context.Context, repo domain.UserRepository, ids []int64, limit int) (int, error) {
+	start := time.Now()
+
+	var users []*domain.User
+	if len(ids) > 0 {
+		found, err := repo.GetByIDs(ctx, ids)
+		if err != nil {
+			return 0, err
+		}
+		for _, id := range ids {
+			if user, ok := found[id]; ok {
+				users = append(users, user)
+			}
+		}
+	} else {
+		var err error
+		users, err = repo.GetRecentlyActive(ctx, limit)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	warmed := 0
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return warmed, err
+		}
+		if err := c.SetUser(ctx, user); err != nil {
+			c.logger.Warn("cache warm set failed", "user_id", user.ID, "error", err)
+			continue
+		}
+		warmed++
+	}
+
+	c.logger.Info("cache warmed", "users", warmed, "loaded", len(users), "duration", time.Since(start))
+	return warmed, nil
+}
@@ -0,0 +1,91 @@
+package cache_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache/cachetest"
+)
+
+func warmFixture() *domaintest.UserRepository {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	return domaintest.NewUserRepository(
+		&domain.User{ID: 1, Name: "One", UpdatedAt: day(1)},
+		&domain.User{ID: 2, Name: "Two", UpdatedAt: day(4)},
+		&domain.User{ID: 3, Name: "Three", UpdatedAt: day(2)},
+		&domain.User{ID: 4, Name: "Four", UpdatedAt: day(3)},
+	)
+}
+
+// cachedKeys returns the keys of the cached users, sorted
+func cachedKeys(server *cachetest.Server) []string {
+	keys := server.Keys("user:*")
+	slices.Sort(keys)
+	return keys
+}
+
+func TestWarmUsersLoadsMostRecentlyActive(t *testing.T) {
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+
+	warmed, err := redisCache.WarmUsers(context.Background(), warmFixture(), nil, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{userKey(2), userKey(4)}; warmed != 2 || !slices.Equal(cachedKeys(server), want) {
+		t.Errorf("warmed %d users as %v, want %v", warmed, cachedKeys(server), want)
+	}
+	if ttl := server.TTL(userKey(2)); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("warmed entry TTL = %v, want the cache TTL", ttl)
+	}
+}
+
+func TestWarmUsersLoadsConfiguredIDs(t *testing.T) {
+	ctx := context.Background()
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	repo := warmFixture()
+
+	warmed, err := redisCache.WarmUsers(ctx, repo, []int64{3, 1, 99}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{userKey(1), userKey(3)}; warmed != 2 || !slices.Equal(cachedKeys(server), want) {
+		t.Errorf("warmed %d users as %v, want the listed ids that exist: %v", warmed, cachedKeys(server), want)
+	}
+	if user, err := redisCache.GetUser(ctx, 3); err != nil || user == nil || user.Name != "Three" {
+		t.Errorf("GetUser(3) after warming = %v, %v; want a hit", user, err)
+	}
+	if n := repo.Calls("GetRecentlyActive"); n != 0 {
+		t.Errorf("configured ids still queried recent users %d times", n)
+	}
+}
+
+func TestWarmUsersSkipsFailedWrites(t *testing.T) {
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	server.Close()
+
+	warmed, err := redisCache.WarmUsers(context.Background(), warmFixture(), nil, 10)
+	if err != nil || warmed != 0 {
+		t.Errorf("WarmUsers with Redis down = %d, %v; want 0 warmed and no error", warmed, err)
+	}
+}
+
+func TestWarmUsersStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	redisCache, server := cachetest.NewRedisCache(t, time.Minute)
+	repo := warmFixture()
+	repo.Before = func(context.Context, string) error {
+		cancel()
+		return nil
+	}
+
+	warmed, err := redisCache.WarmUsers(ctx, repo, nil, 10)
+	if err == nil || warmed != 0 || len(server.Keys("user:*")) != 0 {
+		t.Errorf("canceled warm cached %d users, err %v; want none and the context error", warmed, err)
+	}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Task is a background cache operation
+type Task func(ctx context.Context)
+
+// WorkerPool runs background cache operations on a fixed number of workers
+// so writes are bounded and can be drained on shutdown
+type WorkerPool struct {
+	tasks  chan Task
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+	logger *slog.Logger
+}
+
+// NewWorkerPool starts a pool with the given number of workers and queue size
+func NewWorkerPool(workers, queueSize int, logger *slog.Logger) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &WorkerPool{
+		tasks:  make(chan Task, queueSize),
+		logger: logger,
+	}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.worker()
+	}
+
+	return p
+}
+
+func (p *WorkerPool) worker() {
+	defer p.wg.Done()
+	for task := range p.tasks {
+		task(context.Background())
+	}
+}
+
+// Submit queues a task. If the queue is full or the pool is shutting down,
+// the task runs synchronously so cache invalidations are never dropped.
+func (p *WorkerPool) Submit(task Task) {
+	p.mu.RLock()
+	if !p.closed {
+		select {
+		case p.tasks <- task:
+			p.mu.RUnlock()
+			return
+		default:
+		}
+	}
+	p.mu.RUnlock()
+
+	task(context.Background())
+}
+
+// Shutdown stops accepting tasks and waits for queued tasks to finish or ctx to expire
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.logger.Warn("cache worker pool shutdown interrupted", "pending_tasks", len(p.tasks))
+		return ctx.Err()
+	}
+}
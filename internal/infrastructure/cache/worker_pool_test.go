@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolShutdownDrainsQueuedTasks(t *testing.T) {
+	pool := NewWorkerPool(2, 16, slog.New(slog.DiscardHandler))
+
+	var done atomic.Int32
+	for i := 0; i < 10; i++ {
+		pool.Submit(func(ctx context.Context) {
+			time.Sleep(5 * time.Millisecond)
+			done.Add(1)
+		})
+	}
+
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := done.Load(); n != 10 {
+		t.Errorf("%d of 10 tasks finished before Shutdown returned", n)
+	}
+}
+
+func TestWorkerPoolRunsTasksSynchronouslyAfterShutdown(t *testing.T) {
+	pool := NewWorkerPool(1, 1, slog.New(slog.DiscardHandler))
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ran := false
+	pool.Submit(func(ctx context.Context) { ran = true })
+
+	if !ran {
+		t.Error("task submitted after shutdown was dropped")
+	}
+	if err := pool.Shutdown(context.Background()); err != nil {
+		t.Errorf("second Shutdown = %v, want nil", err)
+	}
+}
+
+func TestWorkerPoolRunsTaskInlineWhenQueueIsFull(t *testing.T) {
+	pool := NewWorkerPool(1, 1, slog.New(slog.DiscardHandler))
+	started, release := make(chan struct{}), make(chan struct{})
+	pool.Submit(func(ctx context.Context) {
+		close(started)
+		<-release
+	})
+	defer pool.Shutdown(context.Background())
+	defer close(release)
+	<-started
+	pool.Submit(func(ctx context.Context) {}) // fills the queue
+
+	ran := false
+	pool.Submit(func(ctx context.Context) { ran = true })
+
+	if !ran {
+		t.Error("task was dropped while the only worker was busy")
+	}
+}
+
+func TestWorkerPoolShutdownStopsWaitingWhenContextExpires(t *testing.T) {
+	pool := NewWorkerPool(1, 1, slog.New(slog.DiscardHandler))
+	release := make(chan struct{})
+	pool.Submit(func(ctx context.Context) { <-release })
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := pool.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"user-crud/internal/application/command"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Login godoc
+// @Summary Log in
+// @Description Verify a user's email and password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body command.LoginCommand true "Login credentials"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} response.ErrorResponse "Invalid input"
+// @Failure 401 {object} response.ErrorResponse "Invalid credentials"
+// @Failure 404 {object} response.ErrorResponse "User not found (only when HIDE_USER_ENUMERATION is disabled)"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/login [post]
+func (h *Handler) Login(c *gin.Context) {
+	var cmd command.LoginCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	user, err := h.loginHandler.Handle(c.Request.Context(), cmd)
+	if err != nil {
+		if err == domain.ErrInvalidCredentials {
+			respondError(c, http.StatusUnauthorized, response.CodeInvalidCredentials, "invalid credentials")
+			return
+		}
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		if err == domain.ErrAccountSuspended {
+			respondError(c, http.StatusForbidden, response.CodeAccountSuspended, "account is suspended")
+			return
+		}
+		if err == domain.ErrInvalidPassword {
+			respondError(c, http.StatusUnauthorized, response.CodeIncorrectPassword, "incorrect password")
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, user.ToPublicUser())
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Issue a password reset token for the given email. Always returns 200 to avoid user enumeration.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body command.ForgotPasswordCommand true "Account email"
+// @Success 200 {object} map[string]interface{} "Reset requested"
+// @Failure 400 {object} response.ErrorResponse "Invalid input"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/forgot-password [post]
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var cmd command.ForgotPasswordCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	if err := h.forgotPasswordHandler.Handle(c.Request.Context(), cmd); err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondMessage(c, http.StatusOK, "if the email is registered, a password reset link has been sent")
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a password reset token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body command.ResetPasswordCommand true "Reset token and new password"
+// @Success 200 {object} map[string]interface{} "Password reset"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or token, or recently used password"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /auth/reset-password [post]
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var cmd command.ResetPasswordCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	cmd.SourceIP = c.ClientIP()
+	if err := h.resetPasswordHandler.Handle(c.Request.Context(), cmd); err != nil {
+		if err == domain.ErrInvalidResetToken {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidResetToken, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrWeakPassword) || err.Error() == "password cannot be empty" {
+			respondError(c, http.StatusBadRequest, validationCode(err), err.Error())
+			return
+		}
+		if err == domain.ErrPasswordReused {
+			respondError(c, http.StatusBadRequest, response.CodePasswordReused, err.Error())
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondMessage(c, http.StatusOK, "password reset successfully")
+}
+
+// GetPasswordPolicy godoc
+// @Summary Get the password policy
+// @Description Password rules enforced on registration, password changes and resets, so clients can show them up front
+// @Tags auth
+// @Produce json
+// @Success 200 {object} domain.PasswordRequirements "Active password policy"
+// @Router /auth/password-policy [get]
+func (h *Handler) GetPasswordPolicy(c *gin.Context) {
+	respondSuccess(c, http.StatusOK, domain.CurrentPasswordPolicy().Requirements())
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachedUserResponse describes a user's cache entry
+type cachedUserResponse struct {
+	UserID     int64 `json:"user_id"`
+	Cached     bool  `json:"cached"`
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"` // -1 when the entry never expires
+}
+
+// GetCachedUser godoc
+// @Summary Inspect a user's cache entry
+// @Description Report whether a user is cached in Redis and how many seconds the entry has left
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Cache entry"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/cache/users/{id} [get]
+func (h *Handler) GetCachedUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	entry, err := h.cache.InspectUser(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	body := cachedUserResponse{UserID: id, Cached: entry.Cached}
+	if entry.Cached {
+		body.TTLSeconds = int64(entry.TTL.Seconds())
+		if entry.TTL < 0 {
+			body.TTLSeconds = -1
+		}
+	}
+
+	respondSuccess(c, http.StatusOK, body)
+}
+
+// EvictCachedUser godoc
+// @Summary Evict a user from the cache
+// @Description Delete a user's Redis cache entry so the next read loads it from the database
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Number of keys deleted (0 if the user was not cached)"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/cache/users/{id} [delete]
+func (h *Handler) EvictCachedUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	deleted, err := h.cache.EvictUser(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// FlushCache godoc
+// @Summary Flush the cache
+// @Description Delete every cached user, list page, recent users list and stats entry. Reset and email change tokens, idempotency records and the maintenance flag are kept.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Success 200 {object} map[string]interface{} "Number of keys deleted"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/cache [delete]
+func (h *Handler) FlushCache(c *gin.Context) {
+	deleted, err := h.cache.Clear(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"user-crud/internal/application/command"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestEmailChange godoc
+// @Summary Request an email change
+// @Description Start changing a user's email. A confirmation token is sent to the new address; the current email stays active until the change is confirmed.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body command.RequestEmailChangeCommand true "New email"
+// @Success 202 {object} map[string]interface{} "Confirmation sent"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or blocked email domain"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "Email already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/{id}/email-change [post]
+func (h *Handler) RequestEmailChange(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	var cmd command.RequestEmailChangeCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	cmd.UserID = id
+	if err := h.requestEmailChangeHandler.Handle(c.Request.Context(), cmd); err != nil {
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		if err == domain.ErrUserAlreadyExists {
+			respondError(c, http.StatusConflict, response.CodeEmailTaken, "user with this email already exists")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidUserData) || errors.Is(err, domain.ErrInvalidEmail) || errors.Is(err, domain.ErrEmailDomainBlocked) {
+			respondError(c, http.StatusBadRequest, validationCode(err), err.Error())
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondMessage(c, http.StatusAccepted, "a confirmation token has been sent to the new email address")
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm an email change
+// @Description Commit a pending email change using the token sent to the new address
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body command.ConfirmEmailChangeCommand true "Confirmation token"
+// @Success 200 {object} map[string]interface{} "Email changed"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or token, or blocked email domain"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "Email already exists"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/{id}/email-change/confirm [post]
+func (h *Handler) ConfirmEmailChange(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	var cmd command.ConfirmEmailChangeCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	cmd.UserID = id
+	user, err := h.confirmEmailChangeHandler.Handle(c.Request.Context(), cmd)
+	if err != nil {
+		if err == domain.ErrInvalidEmailChangeToken {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidEmailChangeToken, err.Error())
+			return
+		}
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		if err == domain.ErrUserAlreadyExists {
+			respondError(c, http.StatusConflict, response.CodeEmailTaken, "user with this email already exists")
+			return
+		}
+		if errors.Is(err, domain.ErrEmailDomainBlocked) {
+			respondError(c, http.StatusBadRequest, response.CodeEmailDomainBlocked, err.Error())
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, user.ToPublicUser())
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"user-crud/internal/application/command"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// databaseRetryAfter is the Retry-After, in seconds, sent while the database is unreachable
+const databaseRetryAfter = "5"
+
+// StatusClientClosedRequest is the non-standard status (from nginx) recorded when the client went away
+const StatusClientClosedRequest = 499
+
+// respondInternalError writes a 500 for err, unless the request context was canceled or
+// timed out: a disconnected client gets 499 with no body, and a deadline gets 408. A command
+// that failed its own Validate is the client's fault and gets 400, and a lost database
+// connection gets 503 so clients back off while the pool reconnects.
+func respondInternalError(c *gin.Context, err error) {
+	var validationErr *command.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+	case errors.Is(err, domain.ErrDatabaseUnavailable):
+		c.Header("Retry-After", databaseRetryAfter)
+		respondError(c, http.StatusServiceUnavailable, response.CodeServiceUnavailable, "database temporarily unavailable, retry shortly")
+	case errors.Is(err, context.Canceled) && c.Request.Context().Err() != nil:
+		// Nobody is listening; record the outcome without logging it as a server error
+		c.AbortWithStatus(StatusClientClosedRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		response.Abort(c, http.StatusRequestTimeout, response.CodeRequestTimeout, "request timed out")
+	default:
+		respondError(c, http.StatusInternalServerError, response.CodeInternalError, err.Error())
+	}
+}
+
+// respondQueryError writes a 503 with Retry-After when a list or search query couldn't get a
+// concurrency slot, a 400 for a cursor issued for another sort, and otherwise falls back to
+// respondInternalError
+func respondQueryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTooManyQueries):
+		c.Header("Retry-After", "1")
+		respondError(c, http.StatusServiceUnavailable, response.CodeServiceUnavailable, "too many concurrent queries, retry shortly")
+	case errors.Is(err, domain.ErrInvalidCursor):
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "cursor is invalid or was issued for a different sort or order")
+	case errors.Is(err, domain.ErrSearchKeywordTooShort):
+		respondError(c, http.StatusBadRequest, response.CodeSearchKeywordTooShort, err.Error())
+	case errors.Is(err, domain.ErrSearchKeywordTooLong):
+		respondError(c, http.StatusBadRequest, response.CodeSearchKeywordTooLong, err.Error())
+	default:
+		respondInternalError(c, err)
+	}
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"user-crud/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userETag builds a weak ETag from the user's id and last update time
+func userETag(user *domain.User) string {
+	return fmt.Sprintf(`W/"%d-%d"`, user.ID, user.UpdatedAt.UnixNano())
+}
+
+// contentETag builds a weak ETag from a hash of the JSON encoding of v
+func contentETag(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// checkETag sets the ETag header and writes 304 Not Modified when the client's
+// If-None-Match matches. It returns true if the response has been written.
+func checkETag(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return true
+	}
+
+	return false
+}
+
+// etagMatches reports whether an If-None-Match header matches etag using weak comparison
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifUnmodifiedSince parses the If-Unmodified-Since header. A missing or malformed
+// header yields nil, which disables the precondition as RFC 9110 requires.
+func ifUnmodifiedSince(c *gin.Context) *time.Time {
+	header := c.GetHeader("If-Unmodified-Since")
+	if header == "" {
+		return nil
+	}
+
+	t, err := http.ParseTime(header)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
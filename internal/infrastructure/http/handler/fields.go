@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"user-crud/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicUserFields lists the JSON field names of domain.PublicUser that may be selected.
+// Only PublicUser fields are selectable, so sensitive columns like password_hash never are.
+var publicUserFields = jsonFieldNames(reflect.TypeOf(domain.PublicUser{}))
+
+// jsonFieldNames returns the JSON names of the exported fields of a struct type
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseFields reads the comma-separated fields query parameter.
+// It returns nil when no projection was requested.
+func parseFields(c *gin.Context) ([]string, error) {
+	raw := strings.TrimSpace(c.Query("fields"))
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+		if !publicUserFields[field] {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// projectUser returns only the requested fields of the public user
+func projectUser(user *domain.PublicUser, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+
+	return projected, nil
+}
@@ -2,52 +2,115 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"user-crud/internal/application/command"
 	"user-crud/internal/application/query"
 	"user-crud/internal/domain"
 	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/http/response"
+	"user-crud/internal/infrastructure/persistence"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Handler struct {
-	createUserHandler     *command.CreateUserHandler
-	updateUserHandler     *command.UpdateUserHandler
-	deleteUserHandler     *command.DeleteUserHandler
-	changePasswordHandler *command.ChangePasswordHandler
-	getUserHandler        *query.GetUserHandler
-	listUsersHandler      *query.ListUsersHandler
-	searchUsersHandler    *query.SearchUsersHandler
-	db                    *pgxpool.Pool
-	cache                 *cache.RedisCache
+	createUserHandler         *command.CreateUserHandler
+	validateUserHandler       *command.ValidateUserHandler
+	updateUserHandler         *command.UpdateUserHandler
+	deleteUserHandler         *command.DeleteUserHandler
+	batchDeleteHandler        *command.BatchDeleteUsersHandler
+	batchUpdateHandler        *command.BatchUpdateUsersHandler
+	mergeUsersHandler         *command.MergeUsersHandler
+	changePasswordHandler     *command.ChangePasswordHandler
+	suspendUserHandler        *command.SuspendUserHandler
+	activateUserHandler       *command.ActivateUserHandler
+	forgotPasswordHandler     *command.ForgotPasswordHandler
+	resetPasswordHandler      *command.ResetPasswordHandler
+	loginHandler              *command.LoginHandler
+	getUserHandler            *query.GetUserHandler
+	userExistsHandler         *query.UserExistsHandler
+	getByUsernameHandler      *query.GetUserByUsernameHandler
+	getUsersByIDsHandler      *query.GetUsersByIDsHandler
+	getActivityHandler        *query.GetUserActivityHandler
+	userStatsHandler          *query.UserStatsHandler
+	recentUsersHandler        *query.RecentUsersHandler
+	passwordHistoryHandler    *query.GetPasswordHistoryHandler
+	requestEmailChangeHandler *command.RequestEmailChangeHandler
+	confirmEmailChangeHandler *command.ConfirmEmailChangeHandler
+	listUsersHandler          *query.ListUsersHandler
+	searchUsersHandler        *query.SearchUsersHandler
+	db                        *pgxpool.Pool
+	cache                     *cache.RedisCache
+	hideUserEnumeration       bool
 }
 
 func NewHandler(
 	createUserHandler *command.CreateUserHandler,
+	validateUserHandler *command.ValidateUserHandler,
 	updateUserHandler *command.UpdateUserHandler,
 	deleteUserHandler *command.DeleteUserHandler,
+	batchDeleteHandler *command.BatchDeleteUsersHandler,
+	batchUpdateHandler *command.BatchUpdateUsersHandler,
+	mergeUsersHandler *command.MergeUsersHandler,
 	changePasswordHandler *command.ChangePasswordHandler,
+	suspendUserHandler *command.SuspendUserHandler,
+	activateUserHandler *command.ActivateUserHandler,
+	forgotPasswordHandler *command.ForgotPasswordHandler,
+	resetPasswordHandler *command.ResetPasswordHandler,
+	loginHandler *command.LoginHandler,
 	getUserHandler *query.GetUserHandler,
+	userExistsHandler *query.UserExistsHandler,
+	getByUsernameHandler *query.GetUserByUsernameHandler,
+	getUsersByIDsHandler *query.GetUsersByIDsHandler,
+	getActivityHandler *query.GetUserActivityHandler,
+	userStatsHandler *query.UserStatsHandler,
+	recentUsersHandler *query.RecentUsersHandler,
+	passwordHistoryHandler *query.GetPasswordHistoryHandler,
+	requestEmailChangeHandler *command.RequestEmailChangeHandler,
+	confirmEmailChangeHandler *command.ConfirmEmailChangeHandler,
 	listUsersHandler *query.ListUsersHandler,
 	searchUsersHandler *query.SearchUsersHandler,
 	db *pgxpool.Pool,
 	cache *cache.RedisCache,
+	hideUserEnumeration bool,
 ) *Handler {
 	return &Handler{
-		createUserHandler:     createUserHandler,
-		updateUserHandler:     updateUserHandler,
-		deleteUserHandler:     deleteUserHandler,
-		changePasswordHandler: changePasswordHandler,
-		getUserHandler:        getUserHandler,
-		listUsersHandler:      listUsersHandler,
-		searchUsersHandler:    searchUsersHandler,
-		db:                    db,
-		cache:                 cache,
+		createUserHandler:         createUserHandler,
+		validateUserHandler:       validateUserHandler,
+		updateUserHandler:         updateUserHandler,
+		deleteUserHandler:         deleteUserHandler,
+		batchDeleteHandler:        batchDeleteHandler,
+		batchUpdateHandler:        batchUpdateHandler,
+		mergeUsersHandler:         mergeUsersHandler,
+		changePasswordHandler:     changePasswordHandler,
+		suspendUserHandler:        suspendUserHandler,
+		activateUserHandler:       activateUserHandler,
+		forgotPasswordHandler:     forgotPasswordHandler,
+		resetPasswordHandler:      resetPasswordHandler,
+		loginHandler:              loginHandler,
+		getUserHandler:            getUserHandler,
+		userExistsHandler:         userExistsHandler,
+		getByUsernameHandler:      getByUsernameHandler,
+		getUsersByIDsHandler:      getUsersByIDsHandler,
+		getActivityHandler:        getActivityHandler,
+		userStatsHandler:          userStatsHandler,
+		recentUsersHandler:        recentUsersHandler,
+		passwordHistoryHandler:    passwordHistoryHandler,
+		requestEmailChangeHandler: requestEmailChangeHandler,
+		confirmEmailChangeHandler: confirmEmailChangeHandler,
+		listUsersHandler:          listUsersHandler,
+		searchUsersHandler:        searchUsersHandler,
+		db:                        db,
+		cache:                     cache,
+		hideUserEnumeration:       hideUserEnumeration,
 	}
 }
 
@@ -82,10 +145,43 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 		statusCode = http.StatusServiceUnavailable
 	}
 
-	c.JSON(statusCode, gin.H{
+	response.JSON(c, statusCode, gin.H{
+		"status":            status,
+		"database":          dbStatus,
+		"cache":             redisStatus,
+		"database_pool":     persistence.PoolStats(h.db),
+		"cache_key_version": cache.UserCacheVersion,
+		"timestamp":         time.Now(),
+	})
+}
+
+// ReadinessCheck godoc
+// @Summary Readiness check
+// @Description Check that the database is reachable and migrations have created the expected schema
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Ready to serve traffic"
+// @Failure 503 {object} map[string]interface{} "Database unreachable or migrations still running"
+// @Router /ready [get]
+func (h *Handler) ReadinessCheck(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	status := "ready"
+	statusCode := http.StatusOK
+
+	ready, err := persistence.SchemaReady(ctx, h.db)
+	switch {
+	case err != nil:
+		status = "unhealthy"
+		statusCode = http.StatusServiceUnavailable
+	case !ready:
+		status = "migrating"
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	response.JSON(c, statusCode, gin.H{
 		"status":    status,
-		"database":  dbStatus,
-		"cache":     redisStatus,
 		"timestamp": time.Now(),
 	})
 }
@@ -98,7 +194,7 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 // @Success 200 {object} map[string]interface{}
 // @Router /metrics [get]
 func (h *Handler) Metrics(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	response.JSON(c, http.StatusOK, gin.H{
 		"message": "Metrics endpoint - integrate with Prometheus here",
 	})
 }
@@ -110,137 +206,482 @@ func (h *Handler) Metrics(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param user body command.CreateUserCommand true "User data"
+// @Param Idempotency-Key header string false "Client-generated key; retries with the same key replay the original response"
 // @Success 201 {object} map[string]interface{} "User created successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid input"
-// @Failure 409 {object} map[string]interface{} "User already exists"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Success 202 {object} map[string]interface{} "Registration accepted (when HIDE_USER_ENUMERATION is enabled)"
+// @Failure 400 {object} response.ErrorResponse "Invalid input"
+// @Failure 409 {object} response.ErrorResponse "User or username already exists, or a request with the same Idempotency-Key is in progress"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /users [post]
 func (h *Handler) CreateUser(c *gin.Context) {
 	var cmd command.CreateUserCommand
 	if err := c.ShouldBindJSON(&cmd); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
 		return
 	}
 
 	user, err := h.createUserHandler.Handle(c.Request.Context(), cmd)
+	if h.hideUserEnumeration && (err == nil || err == domain.ErrUserAlreadyExists) {
+		// Same response whether or not the email was already registered
+		respondMessage(c, http.StatusAccepted, "registration request accepted")
+		return
+	}
 	if err != nil {
+		if err == domain.ErrUsernameTaken {
+			respondError(c, http.StatusConflict, response.CodeUsernameTaken, err.Error())
+			return
+		}
 		if err == domain.ErrUserAlreadyExists {
-			c.JSON(http.StatusConflict, gin.H{
-				"status":  "error",
-				"message": "user with this email already exists",
-			})
+			respondError(c, http.StatusConflict, response.CodeEmailTaken, "user with this email already exists")
 			return
 		}
-		if err.Error() == "password cannot be empty" ||
-			err.Error() == "password must be at least 8 characters" ||
-			err.Error() == "name cannot be empty" ||
+		if errors.Is(err, domain.ErrWeakPassword) ||
+			errors.Is(err, domain.ErrInvalidAge) ||
+			errors.Is(err, domain.ErrInvalidAvatarURL) ||
+			errors.Is(err, domain.ErrInvalidUsername) ||
+			errors.Is(err, domain.ErrInvalidName) ||
+			errors.Is(err, domain.ErrInvalidEmail) ||
+			errors.Is(err, domain.ErrEmailDomainBlocked) ||
+			err.Error() == "password cannot be empty" ||
 			err.Error() == "email cannot be empty" {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"status":  "error",
-				"message": err.Error(),
-			})
+			respondError(c, http.StatusBadRequest, validationCode(err), err.Error())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusCreated, user.ToPublicUser())
+}
+
+// validationFailedResponse is the error envelope for a dry run that found invalid fields
+type validationFailedResponse struct {
+	response.ErrorResponse
+	Valid  bool              `json:"valid"`
+	Errors map[string]string `json:"errors"`
+}
+
+// ValidateUser godoc
+// @Summary Validate a new user without creating it
+// @Description Run every check of user creation, including that the email and username are not taken, without saving anything. With HIDE_USER_ENUMERATION the email is not checked for uniqueness.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body command.CreateUserCommand true "User data"
+// @Success 200 {object} map[string]interface{} "Valid"
+// @Failure 400 {object} response.ErrorResponse "Malformed JSON"
+// @Failure 422 {object} validationFailedResponse "Invalid fields, with a message per field"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/validate [post]
+func (h *Handler) ValidateUser(c *gin.Context) {
+	// Decode without binding validation so every invalid field is reported, not just the first
+	var cmd command.CreateUserCommand
+	if err := json.NewDecoder(c.Request.Body).Decode(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, "request body must be a JSON object")
+		return
+	}
+
+	result, err := h.validateUserHandler.Handle(c.Request.Context(), cmd)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	if !result.Valid {
+		response.JSON(c, http.StatusUnprocessableEntity, validationFailedResponse{
+			ErrorResponse: response.NewError(response.CodeValidationError, "user data is invalid"),
+			Errors:        result.Errors,
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"status": "success",
-		"data":   user.ToPublicUser(),
-	})
+	respondSuccess(c, http.StatusOK, result)
 }
 
 // GetUser godoc
 // @Summary Get user by ID
 // @Description Get a single user by their ID (with Redis caching)
 // @Tags users
-// @Produce json
+// @Produce json,application/vnd.api+json
 // @Param id path int true "User ID"
+// @Param fields query string false "Comma-separated fields to return (id, name, username, email, age, avatar_url, status, last_login_at, created_at, updated_at)"
+// @Param Accept header string false "application/vnd.api+json for a JSON:API document instead of the default envelope"
+// @Param If-None-Match header string false "ETag from a previous response"
 // @Success 200 {object} map[string]interface{} "User found"
-// @Failure 400 {object} map[string]interface{} "Invalid user ID"
-// @Failure 404 {object} map[string]interface{} "User not found"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID or unknown field"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /users/{id} [get]
 func (h *Handler) GetUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid user id",
-		})
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	fields, err := parseFields(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
 		return
 	}
 
 	user, err := h.getUserHandler.Handle(c.Request.Context(), query.GetUserQuery{ID: id})
 	if err != nil {
 		if err == domain.ErrUserNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "user not found",
-			})
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		respondInternalError(c, err)
+		return
+	}
+
+	jsonAPI := wantsJSONAPI(c)
+
+	etag := userETag(user)
+	if fields != nil {
+		// Different projections of the same user are different representations
+		etag = strings.TrimSuffix(etag, `"`) + ";" + strings.Join(fields, ",") + `"`
+	}
+	if jsonAPI {
+		etag = strings.TrimSuffix(etag, `"`) + ";jsonapi" + `"`
+	}
+	if checkETag(c, etag) {
+		return
+	}
+
+	if jsonAPI {
+		resource, err := newUserResource(user.ToPublicUser(), fields)
+		if err != nil {
+			respondInternalError(c, err)
+			return
+		}
+		respondJSONAPI(c, http.StatusOK, jsonAPIDocument{Data: resource})
+		return
+	}
+
+	var data interface{} = user.ToPublicUser()
+	if fields != nil {
+		data, err = projectUser(user.ToPublicUser(), fields)
+		if err != nil {
+			respondInternalError(c, err)
+			return
+		}
+	}
+
+	respondSuccess(c, http.StatusOK, data)
+}
+
+// GetUserActivity godoc
+// @Summary Get user activity summary
+// @Description Get a user's status, creation, update, last login and password change times in one call
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "Activity summary"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/{id}/activity [get]
+func (h *Handler) GetUserActivity(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	activity, err := h.getActivityHandler.Handle(c.Request.Context(), query.GetUserActivityQuery{ID: id})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, activity)
+}
+
+// GetPasswordHistory godoc
+// @Summary Get password change history
+// @Description List when a user's password was changed or reset, newest first. Password hashes are never returned.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Items per page (default and max configurable)"
+// @Success 200 {object} map[string]interface{} "Paginated password changes"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID or page"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/{id}/password-history [get]
+func (h *Handler) GetPasswordHistory(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   user.ToPublicUser(),
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.passwordHistoryHandler.Handle(c.Request.Context(), query.GetPasswordHistoryQuery{
+		UserID: id,
+		Page:   page,
+		Limit:  limit,
 	})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	setPaginationHeaders(c, result.PageInfo)
+	response.JSON(c, http.StatusOK, newPaginatedResponse(result.Changes, result.PageInfo))
+}
+
+// Lookback window for GET /users/stats
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// GetUserStats godoc
+// @Summary Get user statistics
+// @Description Total users, average age, age distribution and signups per day over the last N days (cached briefly)
+// @Tags users
+// @Produce json
+// @Param days query int false "Lookback window in days for signups per day (1-365, default 30)"
+// @Success 200 {object} map[string]interface{} "User statistics"
+// @Failure 400 {object} response.ErrorResponse "Invalid days"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/stats [get]
+func (h *Handler) GetUserStats(c *gin.Context) {
+	days := defaultStatsDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxStatsDays {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, fmt.Sprintf("days must be between 1 and %d", maxStatsDays))
+			return
+		}
+		days = parsed
+	}
+
+	stats, err := h.userStatsHandler.Handle(c.Request.Context(), query.UserStatsQuery{Days: days})
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, stats)
+}
+
+// GetRecentUsers godoc
+// @Summary Get recently registered users
+// @Description The newest users by registration time, including suspended ones (cached briefly)
+// @Tags users
+// @Produce json
+// @Param limit query int false "Number of users (default 10, capped at 100)"
+// @Success 200 {object} map[string]interface{} "Users, newest first"
+// @Failure 400 {object} response.ErrorResponse "Invalid limit"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/recent [get]
+func (h *Handler) GetRecentUsers(c *gin.Context) {
+	var limit int
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	users, err := h.recentUsersHandler.Handle(c.Request.Context(), query.RecentUsersQuery{Limit: limit})
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	publicUsers := make([]*domain.PublicUser, len(users))
+	for i, user := range users {
+		publicUsers[i] = user.ToPublicUser()
+	}
+
+	respondSuccess(c, http.StatusOK, publicUsers)
+}
+
+// GetUserByUsername godoc
+// @Summary Get user by username
+// @Description Get a single user by their username (case-insensitive)
+// @Tags users
+// @Produce json
+// @Param username query string true "Username"
+// @Success 200 {object} map[string]interface{} "User found"
+// @Failure 400 {object} response.ErrorResponse "Missing username"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/by-username [get]
+func (h *Handler) GetUserByUsername(c *gin.Context) {
+	username := c.Query("username")
+	if username == "" {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "username is required")
+		return
+	}
+
+	user, err := h.getByUsernameHandler.Handle(c.Request.Context(), query.GetUserByUsernameQuery{Username: username})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, user.ToPublicUser())
+}
+
+// UserExists godoc
+// @Summary Check if a user exists
+// @Description Cheap existence check that does not return the user (consults Redis cache first)
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User exists"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/{id}/exists [get]
+func (h *Handler) UserExists(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	exists, err := h.userExistsHandler.Handle(c.Request.Context(), query.UserExistsQuery{ID: id})
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	if !exists {
+		respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{"id": id, "exists": true})
+}
+
+// HeadUser godoc
+// @Summary Check if a user exists (HEAD)
+// @Description Returns 200 if the user exists and 404 otherwise, with no body
+// @Tags users
+// @Param id path int true "User ID"
+// @Success 200 "User exists"
+// @Failure 400 "Invalid user ID"
+// @Failure 404 "User not found"
+// @Failure 500 "Internal server error"
+// @Router /users/{id} [head]
+func (h *Handler) HeadUser(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	exists, err := h.userExistsHandler.Handle(c.Request.Context(), query.UserExistsQuery{ID: id})
+	switch {
+	case err != nil:
+		c.Status(http.StatusInternalServerError)
+	case !exists:
+		c.Status(http.StatusNotFound)
+	default:
+		c.Status(http.StatusOK)
+	}
 }
 
 // ListUsers godoc
 // @Summary List users with filters
 // @Description Get paginated list of users with optional filters
 // @Tags users
-// @Produce json
+// @Produce json,application/vnd.api+json
 // @Param search query string false "Search by name or email"
 // @Param age_min query int false "Minimum age"
 // @Param age_max query int false "Maximum age"
-// @Param sort query string false "Sort field (id, name, email, age, created_at)"
-// @Param order query string false "Sort order (asc, desc)"
-// @Param page query int false "Page number"
-// @Param limit query int false "Items per page"
-// @Success 200 {object} map[string]interface{} "Users list"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Param ages query string false "Comma-separated exact ages (at most 50), ANDed with age_min and age_max"
+// @Param sort query string false "Sort field (id, name, email, age, created_at, updated_at)"
+// @Param order query string false "Sort order (asc, desc); defaults per field from SORT_DEFAULT_ORDERS (created_at and updated_at: desc, others: asc)"
+// @Param page query int false "Page number (must not be negative)"
+// @Param limit query int false "Items per page (defaults to PAGINATION_DEFAULT_LIMIT, clamped to PAGINATION_MAX_LIMIT)"
+// @Param ids query string false "Comma-separated user ids. On its own (max 100) returns those users in order with a not_found list; with search, age or inactive_since filters (max 1000) it is ANDed with them and paginated"
+// @Param inactive_since query string false "Only users who have not logged in since this date (2006-01-02) or RFC 3339 time"
+// @Param fields query string false "Comma-separated fields to return (id, name, username, email, age, avatar_url, status, last_login_at, created_at, updated_at)"
+// @Param with_total query bool false "Set to false to skip counting matches: total and total_pages are -1 and has_more tells whether a next page exists (default true)"
+// @Param include_suspended query bool false "Also list suspended users, which are excluded by default (default false)"
+// @Param paginate query string false "page (default) for page numbers and totals, or cursor for {data, next_cursor} without a count query"
+// @Param cursor query string false "next_cursor from the previous response, with paginate=cursor and the same sort and order"
+// @Param Accept header string false "application/vnd.api+json for a JSON:API document instead of the default envelope"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} map[string]interface{} "Users list (pagination also in X-Total-Count, X-Page, X-Limit, X-Total-Pages and Link headers)"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorResponse "Unknown field, invalid ages, inactive_since, with_total, include_suspended, paginate or cursor, or negative page"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Failure 503 {object} response.ErrorResponse "Too many concurrent list and search queries (see Retry-After)"
 // @Router /users [get]
 func (h *Handler) ListUsers(c *gin.Context) {
-	search := c.Query("search")
-	ageMin, _ := strconv.Atoi(c.Query("age_min"))
-	ageMax, _ := strconv.Atoi(c.Query("age_max"))
-	sortBy := c.DefaultQuery("sort", "id")
-	order := c.DefaultQuery("order", "asc")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
-
-	q := query.ListUsersQuery{
-		Search: search,
-		AgeMin: ageMin,
-		AgeMax: ageMax,
-		SortBy: sortBy,
-		Order:  order,
-		Page:   page,
-		Limit:  limit,
+	fields, err := parseFields(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+		return
 	}
 
+	ids, ok := parseIDFilter(c)
+	if !ok {
+		return
+	}
+
+	// Without other filters a short id list is a batch lookup served from the cache
+	if len(ids) > 0 && len(ids) <= maxBatchIDs && !hasListFilters(c) {
+		h.listUsersByIDs(c, ids, fields)
+		return
+	}
+
+	q, ok := parseListQuery(c, ids)
+	if !ok {
+		return
+	}
+
+	cursorMode, after, ok := parseCursorPagination(c)
+	if !ok {
+		return
+	}
+	q.CursorMode = cursorMode
+	q.After = after
+
 	result, err := h.listUsersHandler.Handle(c.Request.Context(), q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		respondQueryError(c, err)
+		return
+	}
+
+	if wantsJSONAPI(c) {
+		if cursorMode {
+			respondJSONAPICursorPage(c, result, fields)
+			return
+		}
+		respondJSONAPIPage(c, result, fields)
 		return
 	}
 
@@ -249,133 +690,407 @@ func (h *Handler) ListUsers(c *gin.Context) {
 		publicUsers[i] = user.ToPublicUser()
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":      "success",
-		"data":        publicUsers,
-		"total":       result.Total,
-		"page":        result.Page,
-		"limit":       result.Limit,
-		"total_pages": result.TotalPages,
+	var data interface{} = publicUsers
+	if fields != nil {
+		projected := make([]map[string]interface{}, len(publicUsers))
+		for i, user := range publicUsers {
+			projected[i], err = projectUser(user, fields)
+			if err != nil {
+				respondInternalError(c, err)
+				return
+			}
+		}
+		data = projected
+	}
+
+	if cursorMode {
+		respondCursorPage(c, data, result.NextCursor)
+		return
+	}
+
+	body := newPaginatedResponse(data, result.PageInfo)
+
+	setPaginationHeaders(c, result.PageInfo)
+
+	if etag, err := contentETag(body); err == nil && checkETag(c, etag) {
+		return
+	}
+
+	response.JSON(c, http.StatusOK, body)
+}
+
+// CountUsers godoc
+// @Summary Count users
+// @Description Pagination headers of the matching GET /users request without the body; only the count query runs
+// @Tags users
+// @Param search query string false "Search by name or email"
+// @Param age_min query int false "Minimum age"
+// @Param age_max query int false "Maximum age"
+// @Param ages query string false "Comma-separated exact ages (at most 50), ANDed with age_min and age_max"
+// @Param ids query string false "Comma-separated user ids (at most 1000)"
+// @Param inactive_since query string false "Only users who have not logged in since this date (2006-01-02) or RFC 3339 time"
+// @Param include_suspended query bool false "Also count suspended users (default false)"
+// @Param page query int false "Page number used for the Link header"
+// @Param limit query int false "Items per page used for X-Total-Pages and the Link header"
+// @Success 200 "Counts in X-Total-Count, X-Total-Pages, X-Page, X-Limit and Link headers"
+// @Failure 400 "Invalid filter"
+// @Failure 503 "Too many concurrent list and search queries (see Retry-After)"
+// @Router /users [head]
+func (h *Handler) CountUsers(c *gin.Context) {
+	ids, ok := parseIDFilter(c)
+	if !ok {
+		return
+	}
+	q, ok := parseListQuery(c, ids)
+	if !ok {
+		return
+	}
+
+	pageInfo, err := h.listUsersHandler.Count(c.Request.Context(), q)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	setPaginationHeaders(c, pageInfo)
+	c.Status(http.StatusOK)
+}
+
+// parseIDFilter parses the ids parameter, responding with 400 when it is invalid
+func parseIDFilter(c *gin.Context) ([]int64, bool) {
+	rawIDs := c.Query("ids")
+	if rawIDs == "" {
+		return nil, true
+	}
+
+	ids, err := parseIDs(rawIDs)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+		return nil, false
+	}
+	if len(ids) > maxFilterIDs {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, fmt.Sprintf("at most %d ids can be requested at once", maxFilterIDs))
+		return nil, false
+	}
+	return ids, true
+}
+
+// parseListQuery parses the list filters, sorting and page parameters shared by GET and
+// HEAD /users, responding with 400 when one is invalid
+func parseListQuery(c *gin.Context, ids []int64) (query.ListUsersQuery, bool) {
+	ageMin, _ := strconv.Atoi(c.Query("age_min"))
+	ageMax, _ := strconv.Atoi(c.Query("age_max"))
+	var ages []int
+	if rawAges := c.Query("ages"); rawAges != "" {
+		var err error
+		ages, err = parseAges(rawAges)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, err.Error())
+			return query.ListUsersQuery{}, false
+		}
+	}
+
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return query.ListUsersQuery{}, false
+	}
+
+	withTotal, err := strconv.ParseBool(c.DefaultQuery("with_total", "true"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "with_total must be true or false")
+		return query.ListUsersQuery{}, false
+	}
+
+	includeSuspended, err := strconv.ParseBool(c.DefaultQuery("include_suspended", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "include_suspended must be true or false")
+		return query.ListUsersQuery{}, false
+	}
+
+	var inactiveSince *time.Time
+	if raw := c.Query("inactive_since"); raw != "" {
+		t, err := parseDateOrTime(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "inactive_since must be a date (2006-01-02) or RFC 3339 time")
+			return query.ListUsersQuery{}, false
+		}
+		inactiveSince = &t
+	}
+
+	return query.ListUsersQuery{
+		IDs:              ids,
+		Search:           c.Query("search"),
+		AgeMin:           ageMin,
+		AgeMax:           ageMax,
+		Ages:             ages,
+		InactiveSince:    inactiveSince,
+		IncludeSuspended: includeSuspended,
+		SortBy:           c.DefaultQuery("sort", "id"),
+		Order:            c.Query("order"),
+		Page:             page,
+		Limit:            limit,
+		SkipTotal:        !withTotal,
+	}, true
+}
+
+// maxBatchIDs limits how many users can be fetched with ?ids= as a batch lookup
+const maxBatchIDs = 100
+
+// maxFilterIDs limits how many ids can be combined with the other list filters
+const maxFilterIDs = 1000
+
+// parseIDs parses a comma-separated list of positive user ids
+func parseIDs(raw string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(raw, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, errors.New("ids must be a comma-separated list of positive integers")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// maxFilterAges limits how many exact ages can be requested at once
+const maxFilterAges = 50
+
+// parseAges parses a comma-separated list of non-negative ages
+func parseAges(raw string) ([]int, error) {
+	var ages []int
+	for _, part := range strings.Split(raw, ",") {
+		age, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || age < 0 {
+			return nil, errors.New("ages must be a comma-separated list of non-negative integers")
+		}
+		ages = append(ages, age)
+	}
+	if len(ages) > maxFilterAges {
+		return nil, fmt.Errorf("at most %d ages can be requested at once", maxFilterAges)
+	}
+	return ages, nil
+}
+
+// hasListFilters reports whether the request filters users by anything other than ids
+func hasListFilters(c *gin.Context) bool {
+	for _, param := range []string{"search", "age_min", "age_max", "ages", "inactive_since"} {
+		if c.Query(param) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// listUsersByIDs serves GET /users?ids=1,2,3, returning users in the requested order
+func (h *Handler) listUsersByIDs(c *gin.Context, ids []int64, fields []string) {
+	includeSuspended, err := strconv.ParseBool(c.DefaultQuery("include_suspended", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "include_suspended must be true or false")
+		return
+	}
+
+	result, err := h.getUsersByIDsHandler.Handle(c.Request.Context(), query.GetUsersByIDsQuery{IDs: ids, IncludeSuspended: includeSuspended})
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	if wantsJSONAPI(c) {
+		resources, err := newUserResources(result.Users, nil, fields)
+		if err != nil {
+			respondInternalError(c, err)
+			return
+		}
+		respondJSONAPI(c, http.StatusOK, jsonAPIDocument{
+			Data: resources,
+			Meta: map[string]interface{}{"not_found": result.NotFound},
+		})
+		return
+	}
+
+	data := make([]interface{}, len(result.Users))
+	for i, user := range result.Users {
+		if fields == nil {
+			data[i] = user.ToPublicUser()
+			continue
+		}
+		data[i], err = projectUser(user.ToPublicUser(), fields)
+		if err != nil {
+			respondInternalError(c, err)
+			return
+		}
+	}
+
+	response.JSON(c, http.StatusOK, batchResponse{
+		SuccessResponse: response.NewSuccess(data),
+		NotFound:        result.NotFound,
 	})
 }
 
+// scoredPublicUser is a public user with its search relevance or fuzzy similarity
+type scoredPublicUser struct {
+	*domain.PublicUser
+	Score float64 `json:"score"`
+}
+
 // SearchUsers godoc
 // @Summary Search users
 // @Description Search users by keyword
 // @Tags users
-// @Produce json
-// @Param q query string true "Search keyword"
-// @Param page query int false "Page number"
-// @Param limit query int false "Items per page"
-// @Success 200 {object} map[string]interface{} "Search results"
-// @Failure 400 {object} map[string]interface{} "Invalid input"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Produce json,application/vnd.api+json
+// @Param q query string true "Search keyword (SEARCH_KEYWORD_MIN_LENGTH to SEARCH_KEYWORD_MAX_LENGTH characters)"
+// @Param mode query string false "Search mode: exact (substring, default) or fuzzy (typo tolerant, adds a score per user)"
+// @Param threshold query number false "Minimum similarity (0-1] for fuzzy mode"
+// @Param with_score query bool false "Add each user's relevance (0-1] in exact mode; fuzzy mode always includes it (default false)"
+// @Param page query int false "Page number (must not be negative)"
+// @Param limit query int false "Items per page (defaults to PAGINATION_DEFAULT_LIMIT, clamped to PAGINATION_MAX_LIMIT)"
+// @Param Accept header string false "application/vnd.api+json for a JSON:API document instead of the default envelope"
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} map[string]interface{} "Search results (pagination also in X-Total-Count, X-Page, X-Limit, X-Total-Pages and Link headers)"
+// @Success 304 "Not modified"
+// @Failure 400 {object} response.ErrorResponse "Invalid input"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Failure 503 {object} response.ErrorResponse "Too many concurrent list and search queries (see Retry-After)"
 // @Router /users/search [get]
 func (h *Handler) SearchUsers(c *gin.Context) {
 	keyword := c.Query("q")
 	if keyword == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "search keyword is required",
-		})
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "search keyword is required")
+		return
+	}
+
+	mode := c.DefaultQuery("mode", query.SearchModeExact)
+	if mode != query.SearchModeExact && mode != query.SearchModeFuzzy {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "mode must be exact or fuzzy")
+		return
+	}
+
+	var threshold float64
+	if raw := c.Query("threshold"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 || parsed > 1 {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "threshold must be a number greater than 0 and at most 1")
+			return
+		}
+		threshold = parsed
+	}
+
+	withScore, err := strconv.ParseBool(c.DefaultQuery("with_score", "false"))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "with_score must be true or false")
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	page, limit, ok := parsePagination(c)
+	if !ok {
+		return
+	}
 
 	q := query.SearchUsersQuery{
-		Keyword: keyword,
-		Page:    page,
-		Limit:   limit,
+		Keyword:    keyword,
+		Mode:       mode,
+		Threshold:  threshold,
+		WithScores: withScore,
+		Page:       page,
+		Limit:      limit,
 	}
 
 	result, err := h.searchUsersHandler.Handle(c.Request.Context(), q)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		respondQueryError(c, err)
 		return
 	}
 
-	publicUsers := make([]*domain.PublicUser, len(result.Users))
-	for i, user := range result.Users {
-		publicUsers[i] = user.ToPublicUser()
+	if wantsJSONAPI(c) {
+		respondJSONAPIPage(c, result, nil)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":      "success",
-		"data":        publicUsers,
-		"total":       result.Total,
-		"page":        result.Page,
-		"limit":       result.Limit,
-		"total_pages": result.TotalPages,
-	})
+	var data interface{}
+	if result.Scores != nil {
+		scoredUsers := make([]scoredPublicUser, len(result.Users))
+		for i, user := range result.Users {
+			scoredUsers[i] = scoredPublicUser{PublicUser: user.ToPublicUser(), Score: result.Scores[i]}
+		}
+		data = scoredUsers
+	} else {
+		publicUsers := make([]*domain.PublicUser, len(result.Users))
+		for i, user := range result.Users {
+			publicUsers[i] = user.ToPublicUser()
+		}
+		data = publicUsers
+	}
+
+	body := newPaginatedResponse(data, result.PageInfo)
+
+	setPaginationHeaders(c, result.PageInfo)
+
+	if etag, err := contentETag(body); err == nil && checkETag(c, etag) {
+		return
+	}
+
+	response.JSON(c, http.StatusOK, body)
 }
 
 // UpdateUser godoc
 // @Summary Update user
-// @Description Update user information
+// @Description Update user information. The email must match the current one; change it with POST /users/{id}/email-change.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
 // @Param user body command.UpdateUserCommand true "User data"
+// @Param If-Unmodified-Since header string false "Reject with 412 if the user was updated after this HTTP date"
 // @Success 200 {object} map[string]interface{} "User updated"
-// @Failure 400 {object} map[string]interface{} "Invalid input"
-// @Failure 404 {object} map[string]interface{} "User not found"
-// @Failure 409 {object} map[string]interface{} "Email already exists"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or email differs from the current one"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "Username already exists"
+// @Failure 412 {object} response.ErrorResponse "User modified since If-Unmodified-Since"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /users/{id} [put]
 func (h *Handler) UpdateUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid user id",
-		})
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
 		return
 	}
 
 	var cmd command.UpdateUserCommand
 	if err := c.ShouldBindJSON(&cmd); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
 		return
 	}
 
 	cmd.ID = id
+	cmd.UnmodifiedSince = ifUnmodifiedSince(c)
 	user, err := h.updateUserHandler.Handle(c.Request.Context(), cmd)
 	if err != nil {
 		if err == domain.ErrUserNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "user not found",
-			})
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
 			return
 		}
-		if err == domain.ErrUserAlreadyExists {
-			c.JSON(http.StatusConflict, gin.H{
-				"status":  "error",
-				"message": "user with this email already exists",
-			})
+		if err == domain.ErrPreconditionFailed {
+			respondError(c, http.StatusPreconditionFailed, response.CodePreconditionFailed, err.Error())
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		if err == domain.ErrEmailChangeRequiresVerification {
+			respondError(c, http.StatusBadRequest, response.CodeEmailChangeNotAllowed, err.Error())
+			return
+		}
+		if err == domain.ErrUsernameTaken {
+			respondError(c, http.StatusConflict, response.CodeUsernameTaken, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidAge) || errors.Is(err, domain.ErrInvalidAvatarURL) || errors.Is(err, domain.ErrInvalidUsername) || errors.Is(err, domain.ErrInvalidName) || errors.Is(err, domain.ErrInvalidEmail) {
+			respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+			return
+		}
+		respondInternalError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   user.ToPublicUser(),
-	})
+	respondSuccess(c, http.StatusOK, user.ToPublicUser())
 }
 
 // DeleteUser godoc
@@ -384,42 +1099,135 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 // @Tags users
 // @Produce json
 // @Param id path int true "User ID"
+// @Param If-Unmodified-Since header string false "Reject with 412 if the user was updated after this HTTP date"
 // @Success 200 {object} map[string]interface{} "User deleted"
-// @Failure 400 {object} map[string]interface{} "Invalid user ID"
-// @Failure 404 {object} map[string]interface{} "User not found"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 412 {object} response.ErrorResponse "User modified since If-Unmodified-Since"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /users/{id} [delete]
 func (h *Handler) DeleteUser(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid user id",
-		})
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
 		return
 	}
 
-	err = h.deleteUserHandler.Handle(c.Request.Context(), command.DeleteUserCommand{ID: id})
+	err = h.deleteUserHandler.Handle(c.Request.Context(), command.DeleteUserCommand{
+		ID:              id,
+		UnmodifiedSince: ifUnmodifiedSince(c),
+	})
 	if err != nil {
 		if err == domain.ErrUserNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "user not found",
-			})
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		if err == domain.ErrPreconditionFailed {
+			respondError(c, http.StatusPreconditionFailed, response.CodePreconditionFailed, err.Error())
+			return
+		}
+		respondInternalError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "user deleted successfully",
-	})
+	respondMessage(c, http.StatusOK, "user deleted successfully")
+}
+
+// BatchDeleteUsers godoc
+// @Summary Delete multiple users
+// @Description Delete several users by ID in a single transaction
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param request body command.BatchDeleteUsersCommand true "User IDs"
+// @Success 200 {object} map[string]interface{} "Deleted and not found IDs"
+// @Failure 400 {object} response.ErrorResponse "Invalid input"
+// @Failure 401 {object} response.ErrorResponse "Missing or invalid API key (only when API_KEYS is set)"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users [delete]
+func (h *Handler) BatchDeleteUsers(c *gin.Context) {
+	var cmd command.BatchDeleteUsersCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	result, err := h.batchDeleteHandler.Handle(c.Request.Context(), cmd)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, result)
+}
+
+// BatchUpdateUsers godoc
+// @Summary Update multiple users
+// @Description Apply the same partial patch (name, age, avatar_url, status) to several users in a single transaction. Each user is validated after patching. In all_or_nothing mode (default) any missing, invalid or failed user rolls the whole batch back; in best_effort mode each user is written in its own savepoint and the users that succeed are saved.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param request body command.BatchUpdateUsersCommand true "User IDs and patch"
+// @Success 200 {object} map[string]interface{} "Whether the batch was committed, and the outcome per id: updated, not_found, invalid, failed or rolled_back"
+// @Failure 400 {object} response.ErrorResponse "Invalid input, empty patch or unknown mode"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token or API key"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /users/batch [patch]
+func (h *Handler) BatchUpdateUsers(c *gin.Context) {
+	var cmd command.BatchUpdateUsersCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	result, err := h.batchUpdateHandler.Handle(c.Request.Context(), cmd)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, result)
+}
+
+// MergeUsers godoc
+// @Summary Merge a duplicate user into another
+// @Description Soft-delete the duplicate account and record that it was merged into the primary, in one transaction. Repeating a completed merge returns the primary unchanged.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param request body command.MergeUsersCommand true "Surviving and duplicate user IDs"
+// @Success 200 {object} map[string]interface{} "Surviving user"
+// @Failure 400 {object} response.ErrorResponse "Invalid ids or both ids are the same user"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 404 {object} response.ErrorResponse "Primary or duplicate user not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/users/merge [post]
+func (h *Handler) MergeUsers(c *gin.Context) {
+	var cmd command.MergeUsersCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	user, err := h.mergeUsersHandler.Handle(c.Request.Context(), cmd)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, err.Error())
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, user.ToPublicUser())
 }
 
 // ChangePassword godoc
@@ -431,57 +1239,122 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Param password body command.ChangePasswordCommand true "Password data"
 // @Success 200 {object} map[string]interface{} "Password changed"
-// @Failure 400 {object} map[string]interface{} "Invalid input"
-// @Failure 401 {object} map[string]interface{} "Incorrect old password"
-// @Failure 404 {object} map[string]interface{} "User not found"
-// @Failure 500 {object} map[string]interface{} "Internal server error"
+// @Failure 400 {object} response.ErrorResponse "Invalid input or recently used password"
+// @Failure 401 {object} response.ErrorResponse "Incorrect old password"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
 // @Router /users/{id}/change-password [put]
 func (h *Handler) ChangePassword(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": "invalid user id",
-		})
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
 		return
 	}
 
 	var cmd command.ChangePasswordCommand
 	if err := c.ShouldBindJSON(&cmd); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
 		return
 	}
 
 	cmd.UserID = id
+	cmd.SourceIP = c.ClientIP()
 	err = h.changePasswordHandler.Handle(c.Request.Context(), cmd)
 	if err != nil {
 		if err == domain.ErrUserNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"status":  "error",
-				"message": "user not found",
-			})
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
 			return
 		}
 		if err.Error() == "old password is incorrect" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"status":  "error",
-				"message": "old password is incorrect",
-			})
+			respondError(c, http.StatusUnauthorized, response.CodeIncorrectPassword, "old password is incorrect")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status":  "error",
-			"message": err.Error(),
-		})
+		if errors.Is(err, domain.ErrWeakPassword) {
+			respondError(c, http.StatusBadRequest, response.CodeWeakPassword, err.Error())
+			return
+		}
+		if err == domain.ErrPasswordReused {
+			respondError(c, http.StatusBadRequest, response.CodePasswordReused, err.Error())
+			return
+		}
+		respondInternalError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "success",
-		"message": "password changed successfully",
-	})
-}
\ No newline at end of file
+	respondMessage(c, http.StatusOK, "password changed successfully")
+}
+
+// SuspendUser godoc
+// @Summary Suspend user
+// @Description Disable an active account without deleting it; suspended users cannot log in
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User suspended"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "User is not active"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/suspend [post]
+func (h *Handler) SuspendUser(c *gin.Context) {
+	h.changeUserStatus(c, h.suspendUserHandler.Handle)
+}
+
+// ActivateUser godoc
+// @Summary Activate user
+// @Description Re-enable a suspended account
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User activated"
+// @Failure 400 {object} response.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 404 {object} response.ErrorResponse "User not found"
+// @Failure 409 {object} response.ErrorResponse "User is not suspended"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/users/{id}/activate [post]
+func (h *Handler) ActivateUser(c *gin.Context) {
+	h.changeUserStatus(c, h.activateUserHandler.Handle)
+}
+
+// changeUserStatus runs a suspend or activate command for the user in the path
+func (h *Handler) changeUserStatus(c *gin.Context, handle func(context.Context, command.ChangeUserStatusCommand) (*domain.User, error)) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidID, "invalid user id")
+		return
+	}
+
+	user, err := handle(c.Request.Context(), command.ChangeUserStatusCommand{ID: id})
+	if err != nil {
+		if err == domain.ErrUserNotFound {
+			respondError(c, http.StatusNotFound, response.CodeUserNotFound, "user not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidStatusTransition) {
+			respondError(c, http.StatusConflict, response.CodeInvalidStatusTransition, err.Error())
+			return
+		}
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, user.ToPublicUser())
+}
+
+// parseDateOrTime accepts either a plain date or an RFC 3339 timestamp
+func parseDateOrTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"user-crud/internal/application/query"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mediaTypeJSONAPI selects JSON:API (https://jsonapi.org) responses when present in Accept
+const mediaTypeJSONAPI = "application/vnd.api+json"
+
+// jsonAPIUserType is the JSON:API resource type of users
+const jsonAPIUserType = "users"
+
+// jsonAPIResource is a single resource object
+type jsonAPIResource struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+}
+
+// jsonAPIDocument is the top-level JSON:API document
+type jsonAPIDocument struct {
+	Data  interface{}            `json:"data"`
+	Links map[string]string      `json:"links,omitempty"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+// wantsJSONAPI reports whether the Accept header asks for JSON:API. Every user read
+// varies on Accept, so the Vary header is set here too.
+func wantsJSONAPI(c *gin.Context) bool {
+	c.Header("Vary", "Accept")
+	for _, accepted := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == mediaTypeJSONAPI {
+			return true
+		}
+	}
+	return false
+}
+
+// newUserResource builds a user resource; fields restricts the attributes like ?fields= does
+func newUserResource(user *domain.PublicUser, fields []string) (jsonAPIResource, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return jsonAPIResource{}, err
+	}
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return jsonAPIResource{}, err
+	}
+
+	if fields != nil {
+		keep := make(map[string]bool, len(fields))
+		for _, field := range fields {
+			keep[field] = true
+		}
+		for name := range attributes {
+			if !keep[name] {
+				delete(attributes, name)
+			}
+		}
+	}
+	// The id is a member of the resource object, not an attribute
+	delete(attributes, "id")
+
+	return jsonAPIResource{
+		Type:       jsonAPIUserType,
+		ID:         strconv.FormatInt(user.ID, 10),
+		Attributes: attributes,
+	}, nil
+}
+
+// newUserResources builds a resource per user; scores, when set, go in each resource's meta
+func newUserResources(users []*domain.User, scores []float64, fields []string) ([]jsonAPIResource, error) {
+	resources := make([]jsonAPIResource, len(users))
+	for i, user := range users {
+		resource, err := newUserResource(user.ToPublicUser(), fields)
+		if err != nil {
+			return nil, err
+		}
+		if scores != nil {
+			resource.Meta = map[string]interface{}{"score": scores[i]}
+		}
+		resources[i] = resource
+	}
+	return resources, nil
+}
+
+// newJSONAPIPage builds a collection document with pagination links and meta. Without a
+// total count the meta has no totals and there is no last link.
+func newJSONAPIPage(c *gin.Context, resources []jsonAPIResource, page query.PageInfo) jsonAPIDocument {
+	links := map[string]string{
+		"self":  pageURL(c, page.Page, page.Limit),
+		"first": pageURL(c, 1, page.Limit),
+	}
+	meta := map[string]interface{}{
+		"page":         page.Page,
+		"limit":        page.Limit,
+		"has_more":     page.HasMore,
+		"out_of_range": page.OutOfRange,
+	}
+
+	if page.Page > 1 {
+		prev := page.Page - 1
+		if page.Total >= 0 {
+			prev = min(prev, page.TotalPages)
+		}
+		links["prev"] = pageURL(c, prev, page.Limit)
+	}
+	if page.HasMore {
+		links["next"] = pageURL(c, page.Page+1, page.Limit)
+	}
+	if page.Total >= 0 {
+		links["last"] = pageURL(c, page.TotalPages, page.Limit)
+		meta["total"] = page.Total
+		meta["total_pages"] = page.TotalPages
+	}
+
+	return jsonAPIDocument{Data: resources, Links: links, Meta: meta}
+}
+
+// respondJSONAPI writes a JSON:API document with its media type
+func respondJSONAPI(c *gin.Context, status int, doc jsonAPIDocument) {
+	c.Header("Content-Type", mediaTypeJSONAPI)
+	response.JSON(c, status, doc)
+}
+
+// respondJSONAPIPage writes a page of users as a JSON:API collection, honoring If-None-Match
+func respondJSONAPIPage(c *gin.Context, result *query.ListUsersResult, fields []string) {
+	resources, err := newUserResources(result.Users, result.Scores, fields)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	doc := newJSONAPIPage(c, resources, result.PageInfo)
+	setPaginationHeaders(c, result.PageInfo)
+
+	if etag, err := contentETag(doc); err == nil && checkETag(c, etag) {
+		return
+	}
+
+	respondJSONAPI(c, http.StatusOK, doc)
+}
+
+// respondJSONAPICursorPage writes a page of cursor pagination as a JSON:API collection with
+// a next link and meta.next_cursor (null on the last page), honoring If-None-Match
+func respondJSONAPICursorPage(c *gin.Context, result *query.ListUsersResult, fields []string) {
+	resources, err := newUserResources(result.Users, nil, fields)
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	doc := jsonAPIDocument{
+		Data:  resources,
+		Links: map[string]string{"self": requestURL(c).String()},
+		Meta:  map[string]interface{}{"next_cursor": nil},
+	}
+	if result.NextCursor != "" {
+		doc.Links["next"] = cursorURL(c, result.NextCursor)
+		doc.Meta["next_cursor"] = result.NextCursor
+	}
+
+	if etag, err := contentETag(doc); err == nil && checkETag(c, etag) {
+		return
+	}
+
+	respondJSONAPI(c, http.StatusOK, doc)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetMaintenanceRequest toggles the runtime maintenance flag
+type SetMaintenanceRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// GetMaintenance godoc
+// @Summary Get maintenance mode
+// @Description Report whether maintenance mode is toggled on at runtime (MAINTENANCE_MODE forces it on regardless)
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Success 200 {object} map[string]interface{} "Maintenance flag"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/maintenance [get]
+func (h *Handler) GetMaintenance(c *gin.Context) {
+	enabled, err := h.cache.MaintenanceEnabled(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{"enabled": enabled})
+}
+
+// SetMaintenance godoc
+// @Summary Toggle maintenance mode
+// @Description Turn maintenance mode on or off at runtime; while on, writes return 503 and reads still work
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security APIKey
+// @Param request body SetMaintenanceRequest true "Maintenance flag"
+// @Success 200 {object} map[string]interface{} "Maintenance flag updated"
+// @Failure 400 {object} response.ErrorResponse "Invalid input"
+// @Failure 401 {object} response.ErrorResponse "Invalid admin token"
+// @Failure 403 {object} response.ErrorResponse "Admin endpoints disabled"
+// @Failure 500 {object} response.ErrorResponse "Internal server error"
+// @Router /admin/maintenance [put]
+func (h *Handler) SetMaintenance(c *gin.Context) {
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, response.CodeValidationError, err.Error())
+		return
+	}
+
+	if err := h.cache.SetMaintenance(c.Request.Context(), *req.Enabled); err != nil {
+		respondInternalError(c, err)
+		return
+	}
+
+	respondSuccess(c, http.StatusOK, gin.H{"enabled": *req.Enabled})
+}
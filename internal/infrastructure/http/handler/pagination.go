@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"user-crud/internal/application/query"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination reads the page and limit query parameters, leaving them zero when
+// absent so the query handlers apply the configured defaults. A negative page is
+// rejected with 400 and ok is false.
+func parsePagination(c *gin.Context) (page, limit int, ok bool) {
+	page, _ = strconv.Atoi(c.Query("page"))
+	limit, _ = strconv.Atoi(c.Query("limit"))
+
+	if page < 0 {
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "page must not be negative")
+		return 0, 0, false
+	}
+
+	return page, limit, true
+}
+
+// setPaginationHeaders mirrors the pagination body fields as X-* headers and adds an RFC 5988 Link header.
+// Without a total count there are no X-Total-* headers or last link.
+func setPaginationHeaders(c *gin.Context, result query.PageInfo) {
+	c.Header("X-Page", strconv.Itoa(result.Page))
+	c.Header("X-Limit", strconv.Itoa(result.Limit))
+
+	if result.Total < 0 {
+		links := []string{
+			pageLink(c, 1, result.Limit, "first"),
+		}
+		if result.Page > 1 {
+			links = append(links, pageLink(c, result.Page-1, result.Limit, "prev"))
+		}
+		if result.HasMore {
+			links = append(links, pageLink(c, result.Page+1, result.Limit, "next"))
+		}
+		c.Header("Link", strings.Join(links, ", "))
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(result.Total, 10))
+	c.Header("X-Total-Pages", strconv.Itoa(result.TotalPages))
+
+	lastPage := result.TotalPages
+
+	links := []string{
+		pageLink(c, 1, result.Limit, "first"),
+	}
+	if result.Page > 1 {
+		links = append(links, pageLink(c, min(result.Page-1, lastPage), result.Limit, "prev"))
+	}
+	if result.Page < lastPage {
+		links = append(links, pageLink(c, result.Page+1, result.Limit, "next"))
+	}
+	links = append(links, pageLink(c, lastPage, result.Limit, "last"))
+
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// pageLink builds a single Link entry pointing at the current request with page and limit replaced
+func pageLink(c *gin.Context, page, limit int, rel string) string {
+	return fmt.Sprintf(`<%s>; rel="%s"`, pageURL(c, page, limit), rel)
+}
+
+// pageURL returns the absolute URL of the current request with page and limit replaced
+func pageURL(c *gin.Context, page, limit int) string {
+	u := requestURL(c)
+	params := u.Query()
+	params.Set("page", strconv.Itoa(page))
+	params.Set("limit", strconv.Itoa(limit))
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+// requestURL returns the absolute URL of the current request
+func requestURL(c *gin.Context) *url.URL {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return &url.URL{
+		Scheme:   scheme,
+		Host:     c.Request.Host,
+		Path:     c.Request.URL.Path,
+		RawQuery: c.Request.URL.RawQuery,
+	}
+}
+
+// parseCursorPagination reads paginate and cursor. paginate=cursor selects cursor mode and
+// cursor, when given, is where it continues. An unknown mode or malformed cursor is
+// rejected with 400 and ok is false.
+func parseCursorPagination(c *gin.Context) (cursorMode bool, after *query.Cursor, ok bool) {
+	switch c.DefaultQuery("paginate", "page") {
+	case "page":
+		return false, nil, true
+	case "cursor":
+	default:
+		respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "paginate must be page or cursor")
+		return false, nil, false
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := query.DecodeCursor(raw)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, response.CodeInvalidParameter, "cursor is invalid")
+			return false, nil, false
+		}
+		after = cursor
+	}
+
+	return true, after, true
+}
+
+// cursorURL returns the absolute URL of the current request with cursor replaced
+func cursorURL(c *gin.Context, cursor string) string {
+	u := requestURL(c)
+	params := u.Query()
+	params.Set("cursor", cursor)
+	params.Del("page")
+	u.RawQuery = params.Encode()
+	return u.String()
+}
+
+// respondCursorPage writes a page of cursor pagination with a Link header to the next
+// page, honoring If-None-Match
+func respondCursorPage(c *gin.Context, data interface{}, nextCursor string) {
+	body := cursorResponse{SuccessResponse: response.NewSuccess(data)}
+	if nextCursor != "" {
+		body.NextCursor = &nextCursor
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, cursorURL(c, nextCursor)))
+	}
+
+	if etag, err := contentETag(body); err == nil && checkETag(c, etag) {
+		return
+	}
+
+	response.JSON(c, http.StatusOK, body)
+}
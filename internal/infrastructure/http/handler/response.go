@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+
+	"user-crud/internal/application/query"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paginatedResponse is the success envelope for a page of list or search results
+type paginatedResponse struct {
+	response.SuccessResponse
+	Total      int64 `json:"total"`
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	TotalPages int   `json:"total_pages"`
+	OutOfRange bool  `json:"out_of_range"`
+	HasMore    bool  `json:"has_more"`
+}
+
+// newPaginatedResponse wraps data with the pagination fields of result
+func newPaginatedResponse(data interface{}, result query.PageInfo) paginatedResponse {
+	return paginatedResponse{
+		SuccessResponse: response.NewSuccess(data),
+		Total:           result.Total,
+		Page:            result.Page,
+		Limit:           result.Limit,
+		TotalPages:      result.TotalPages,
+		OutOfRange:      result.OutOfRange,
+		HasMore:         result.HasMore,
+	}
+}
+
+// cursorResponse is the success envelope for a page of cursor pagination; NextCursor is
+// null on the last page
+type cursorResponse struct {
+	response.SuccessResponse
+	NextCursor *string `json:"next_cursor"`
+}
+
+// batchResponse is the success envelope for users fetched by id, listing ids that don't exist
+type batchResponse struct {
+	response.SuccessResponse
+	NotFound []int64 `json:"not_found"`
+}
+
+// respondSuccess writes data in the success envelope
+func respondSuccess(c *gin.Context, status int, data interface{}) {
+	response.Success(c, status, data)
+}
+
+// respondMessage writes a success envelope carrying only a message
+func respondMessage(c *gin.Context, status int, message string) {
+	response.Message(c, status, message)
+}
+
+// respondError writes the error envelope with a machine-readable code
+func respondError(c *gin.Context, status int, code response.Code, message string) {
+	response.Error(c, status, code, message)
+}
+
+// validationCode distinguishes password policy and email domain failures from other invalid input
+func validationCode(err error) response.Code {
+	if errors.Is(err, domain.ErrWeakPassword) {
+		return response.CodeWeakPassword
+	}
+	if errors.Is(err, domain.ErrEmailDomainBlocked) {
+		return response.CodeEmailDomainBlocked
+	}
+	return response.CodeValidationError
+}
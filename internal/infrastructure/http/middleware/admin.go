@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth requires an "Authorization: Bearer <token>" header matching the admin token.
+// When no token is configured admin endpoints are disabled and return 403. Internal
+// services may send an X-API-Key header matching one of apiKeys instead.
+func AdminAuth(token string, apiKeys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if provided := c.GetHeader(APIKeyHeader); provided != "" && len(apiKeys) > 0 {
+			if !validAPIKey(provided, apiKeys) {
+				response.Abort(c, http.StatusUnauthorized, response.CodeUnauthorized, "invalid API key")
+				return
+			}
+			c.Set(trustedServiceKey, true)
+			c.Next()
+			return
+		}
+
+		if token == "" {
+			response.Abort(c, http.StatusForbidden, response.CodeForbidden, "admin endpoints are disabled")
+			return
+		}
+
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", "Bearer")
+			response.Abort(c, http.StatusUnauthorized, response.CodeUnauthorized, "invalid admin token")
+			return
+		}
+
+		c.Next()
+	}
+}
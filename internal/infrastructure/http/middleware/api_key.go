@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the API key of an internal service
+const APIKeyHeader = "X-API-Key"
+
+// trustedServiceKey marks requests authenticated with an API key in the gin context
+const trustedServiceKey = "trusted_service"
+
+// APIKeyAuth requires an X-API-Key header matching one of keys and marks the request as
+// coming from a trusted service. With no keys configured every request is rejected.
+func APIKeyAuth(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !validAPIKey(c.GetHeader(APIKeyHeader), keys) {
+			response.Abort(c, http.StatusUnauthorized, response.CodeUnauthorized, "invalid API key")
+			return
+		}
+
+		c.Set(trustedServiceKey, true)
+		c.Next()
+	}
+}
+
+// IsTrustedService reports whether the request was authenticated with an API key
+func IsTrustedService(c *gin.Context) bool {
+	return c.GetBool(trustedServiceKey)
+}
+
+// validAPIKey reports whether provided matches one of keys. Both sides are hashed so the
+// comparison doesn't leak key lengths, and every key is compared so the time taken doesn't
+// reveal which one matched.
+func validAPIKey(provided string, keys []string) bool {
+	if provided == "" {
+		return false
+	}
+
+	providedSum := sha256.Sum256([]byte(provided))
+	match := 0
+	for _, key := range keys {
+		keySum := sha256.Sum256([]byte(key))
+		match |= subtle.ConstantTimeCompare(providedSum[:], keySum[:])
+	}
+	return match == 1
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func apiKeyRouter(keys ...string) *gin.Engine {
+	r := gin.New()
+	r.Use(APIKeyAuth(keys))
+	r.GET("/internal", func(c *gin.Context) {
+		if !IsTrustedService(c) {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	r := apiKeyRouter("service-a-key", "service-b-key")
+
+	tests := []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"first key", "service-a-key", http.StatusOK},
+		{"second key", "service-b-key", http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "service-c-key", http.StatusUnauthorized},
+		{"prefix of a key", "service-a", http.StatusUnauthorized},
+		{"key with a suffix", "service-a-key2", http.StatusUnauthorized},
+		{"different case", "SERVICE-A-KEY", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/internal", nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+
+			w := serve(r, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized {
+				if body := decodeError(t, w); body.Code != response.CodeUnauthorized {
+					t.Errorf("code = %s, want UNAUTHORIZED", body.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthWithoutKeysRejectsEverything(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/internal", nil)
+	req.Header.Set(APIKeyHeader, "anything")
+
+	if w := serve(apiKeyRouter(), req); w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d with no keys configured, want 401", w.Code)
+	}
+	if validAPIKey("", []string{""}) {
+		t.Error("an empty key matched an empty configured key")
+	}
+}
+
+func TestValidAPIKeyTimingDoesNotDependOnMatchingPrefix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing comparison")
+	}
+
+	key := strings.Repeat("k", 64)
+	keys := []string{key, strings.Repeat("x", 64)}
+	nearMiss := key[:63] + "j"
+	farMiss := strings.Repeat("a", 64)
+
+	// Takes the fastest of several rounds so scheduler noise doesn't count
+	measure := func(provided string) time.Duration {
+		best := time.Duration(1<<63 - 1)
+		for range 5 {
+			start := time.Now()
+			for range 2000 {
+				validAPIKey(provided, keys)
+			}
+			best = min(best, time.Since(start))
+		}
+		return best
+	}
+
+	near, far := measure(nearMiss), measure(farMiss)
+	if ratio := float64(near) / float64(far); ratio > 1.5 || ratio < 1/1.5 {
+		t.Errorf("a key differing in its last byte took %v, one differing in its first %v; want the same", near, far)
+	}
+}
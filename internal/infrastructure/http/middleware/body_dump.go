@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sensitiveFields matches the JSON values of password fields and password reset tokens,
+// including values cut off by the size cap. Keys are matched case-insensitively because
+// JSON binding ignores case.
+var sensitiveFields = regexp.MustCompile(`(?i)("(?:password|old_password|new_password|token)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+
+// redactBody replaces the values of sensitive fields with a placeholder
+func redactBody(body []byte) string {
+	return sensitiveFields.ReplaceAllString(string(body), `$1"[REDACTED]"`)
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	max   int
+	total int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.total += len(p)
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// truncated reports whether bytes were dropped because of the cap
+func (b *cappedBuffer) truncated() bool {
+	return b.total > b.buf.Len()
+}
+
+// bodyDumpWriter copies the response body into a capped buffer as it is written
+type bodyDumpWriter struct {
+	gin.ResponseWriter
+	body *cappedBuffer
+}
+
+func (w *bodyDumpWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyDumpWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// BodyDump logs request and response bodies at debug level for diagnosing client
+// integrations. Password and reset token fields are redacted and each body is capped at maxBytes. The
+// request body is teed as the handler reads it, so the handler still sees all of it.
+func BodyDump(logger *slog.Logger, maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqBody := &cappedBuffer{max: maxBytes}
+		if c.Request.Body != nil {
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.TeeReader(c.Request.Body, reqBody), c.Request.Body}
+		}
+
+		respBody := &cappedBuffer{max: maxBytes}
+		c.Writer = &bodyDumpWriter{ResponseWriter: c.Writer, body: respBody}
+
+		c.Next()
+
+		logger.Debug("http body dump",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"request_body", redactBody(reqBody.buf.Bytes()),
+			"request_truncated", reqBody.truncated(),
+			"response_body", redactBody(respBody.buf.Bytes()),
+			"response_truncated", respBody.truncated(),
+		)
+	}
+}
@@ -1,33 +1,158 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"user-crud/internal/infrastructure/http/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sony/gobreaker"
 )
 
-// CircuitBreakerMiddleware creates a circuit breaker middleware
-func CircuitBreakerMiddleware() gin.HandlerFunc {
-	// Configure circuit breaker
-	settings := gobreaker.Settings{
-		Name:        "HTTP Circuit Breaker",
-		MaxRequests: 3,                // Max requests allowed in half-open state
-		Interval:    0,                // 0 means counter will never be cleared
-		Timeout:     60,               // Timeout in seconds to switch from open to half-open
+// CircuitBreakerConfig holds the thresholds applied to every route breaker
+type CircuitBreakerConfig struct {
+	MaxRequests  uint32        // Max requests allowed in half-open state
+	MinRequests  uint32        // Min requests before the failure ratio is evaluated
+	FailureRatio float64       // Failure ratio that trips the breaker
+	Timeout      time.Duration // Time spent open before switching to half-open
+}
+
+// DefaultCircuitBreakerConfig returns the default breaker thresholds
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		MaxRequests:  3,
+		MinRequests:  3,
+		FailureRatio: 0.6,
+		Timeout:      60 * time.Second,
+	}
+}
+
+// CircuitBreakers maintains one circuit breaker per route so failures are isolated
+type CircuitBreakers struct {
+	cfg      CircuitBreakerConfig
+	breakers map[string]*routeBreaker
+	mu       sync.Mutex
+}
+
+// routeBreaker is a route's breaker and how many times it has tripped open
+type routeBreaker struct {
+	cb    *gobreaker.CircuitBreaker
+	trips atomic.Uint64
+}
+
+// CircuitBreakerStats is a snapshot of one route's breaker
+type CircuitBreakerStats struct {
+	Route                string `json:"route"`
+	State                string `json:"state"`
+	Trips                uint64 `json:"trips"` // Times the breaker has opened since startup
+	Requests             uint32 `json:"requests"`
+	TotalSuccesses       uint32 `json:"total_successes"`
+	TotalFailures        uint32 `json:"total_failures"`
+	ConsecutiveSuccesses uint32 `json:"consecutive_successes"`
+	ConsecutiveFailures  uint32 `json:"consecutive_failures"`
+}
+
+// NewCircuitBreakers creates a per-route circuit breaker registry
+func NewCircuitBreakers(cfg CircuitBreakerConfig) *CircuitBreakers {
+	// Timeout is a time.Duration: a bare number would be nanoseconds, not seconds
+	if cfg.Timeout < time.Second {
+		defaultTimeout := DefaultCircuitBreakerConfig().Timeout
+		log.Printf("Circuit breaker timeout %v is too short, using default: %v", cfg.Timeout, defaultTimeout)
+		cfg.Timeout = defaultTimeout
+	}
+
+	return &CircuitBreakers{
+		cfg:      cfg,
+		breakers: make(map[string]*routeBreaker),
+	}
+}
+
+// getBreaker returns the breaker for the given route, creating it on first use
+func (cbs *CircuitBreakers) getBreaker(route string) *gobreaker.CircuitBreaker {
+	cbs.mu.Lock()
+	defer cbs.mu.Unlock()
+
+	rb, exists := cbs.breakers[route]
+	if !exists {
+		rb = &routeBreaker{}
+		rb.cb = gobreaker.NewCircuitBreaker(cbs.settings(route, &rb.trips))
+		cbs.breakers[route] = rb
+	}
+
+	return rb.cb
+}
+
+// Stats returns a snapshot of every route's breaker, sorted by route
+func (cbs *CircuitBreakers) Stats() []CircuitBreakerStats {
+	// Copy the map first: reading a breaker's state can trigger a state change
+	// callback, which must not run while the registry lock is held
+	cbs.mu.Lock()
+	breakers := make(map[string]*routeBreaker, len(cbs.breakers))
+	for route, rb := range cbs.breakers {
+		breakers[route] = rb
+	}
+	cbs.mu.Unlock()
+
+	stats := make([]CircuitBreakerStats, 0, len(breakers))
+	for route, rb := range breakers {
+		state := rb.cb.State()
+		counts := rb.cb.Counts()
+		stats = append(stats, CircuitBreakerStats{
+			Route:                route,
+			State:                state.String(),
+			Trips:                rb.trips.Load(),
+			Requests:             counts.Requests,
+			TotalSuccesses:       counts.TotalSuccesses,
+			TotalFailures:        counts.TotalFailures,
+			ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+			ConsecutiveFailures:  counts.ConsecutiveFailures,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+
+	return stats
+}
+
+// StatsHandler serves Stats as JSON so operators can tell breaker 503s from dependency failures
+func (cbs *CircuitBreakers) StatsHandler(c *gin.Context) {
+	response.Success(c, http.StatusOK, cbs.Stats())
+}
+
+// settings builds the gobreaker settings for a route, counting trips into trips
+func (cbs *CircuitBreakers) settings(route string, trips *atomic.Uint64) gobreaker.Settings {
+	cfg := cbs.cfg
+	return gobreaker.Settings{
+		Name:        route,
+		MaxRequests: cfg.MaxRequests,
+		Interval:    0, // 0 means counter will never be cleared
+		Timeout:     cfg.Timeout,
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
 			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
-			return counts.Requests >= 3 && failureRatio >= 0.6
+			return counts.Requests >= cfg.MinRequests && failureRatio >= cfg.FailureRatio
 		},
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
-			// Log state changes
-			// You can add logging here
+			if to == gobreaker.StateOpen {
+				trips.Add(1)
+			}
+			log.Printf("Circuit breaker %q changed state: %s -> %s", name, from, to)
 		},
 	}
+}
 
-	cb := gobreaker.NewCircuitBreaker(settings)
-
+// Middleware returns a gin middleware that guards each route with its own breaker
+func (cbs *CircuitBreakers) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		if c.FullPath() == "" {
+			route = "NO_ROUTE"
+		}
+		cb := cbs.getBreaker(route)
+
 		_, err := cb.Execute(func() (interface{}, error) {
 			c.Next()
 
@@ -42,29 +167,28 @@ func CircuitBreakerMiddleware() gin.HandlerFunc {
 		if err != nil {
 			// Circuit breaker is open
 			if err == gobreaker.ErrOpenState {
-				c.JSON(http.StatusServiceUnavailable, gin.H{
-					"status":  "error",
-					"message": "service temporarily unavailable",
-					"details": "circuit breaker is open, please try again later",
-				})
-				c.Abort()
+				body := response.NewError(response.CodeServiceUnavailable, "service temporarily unavailable")
+				body.Details = "circuit breaker is open, please try again later"
+				response.AbortJSON(c, http.StatusServiceUnavailable, body)
 				return
 			}
 
 			// Too many requests in half-open state
 			if err == gobreaker.ErrTooManyRequests {
-				c.JSON(http.StatusTooManyRequests, gin.H{
-					"status":  "error",
-					"message": "too many requests",
-					"details": "circuit breaker is in half-open state",
-				})
-				c.Abort()
+				body := response.NewError(response.CodeServiceUnavailable, "too many requests")
+				body.Details = "circuit breaker is in half-open state"
+				response.AbortJSON(c, http.StatusTooManyRequests, body)
 				return
 			}
 		}
 	}
 }
 
+// CircuitBreakerMiddleware creates a per-route circuit breaker middleware
+func CircuitBreakerMiddleware(cfg CircuitBreakerConfig) gin.HandlerFunc {
+	return NewCircuitBreakers(cfg).Middleware()
+}
+
 // CircuitBreakerError represents a circuit breaker error
 type CircuitBreakerError struct {
 	StatusCode int
@@ -72,4 +196,4 @@ type CircuitBreakerError struct {
 
 func (e *CircuitBreakerError) Error() string {
 	return "circuit breaker error"
-}
\ No newline at end of file
+}
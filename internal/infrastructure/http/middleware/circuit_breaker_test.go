@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// breakerRouter guards a failing DELETE and a healthy GET with breakers that trip after
+// three failed requests
+func breakerRouter(timeout time.Duration) (*gin.Engine, *CircuitBreakers) {
+	breakers := NewCircuitBreakers(CircuitBreakerConfig{MaxRequests: 1, MinRequests: 3, FailureRatio: 0.6, Timeout: timeout})
+
+	r := gin.New()
+	r.Use(breakers.Middleware())
+	r.DELETE("/users/:id", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+	r.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, breakers
+}
+
+// tripDelete fails the DELETE route until its breaker opens
+func tripDelete(t *testing.T, r *gin.Engine) {
+	t.Helper()
+
+	for i := 0; i < 3; i++ {
+		serve(r, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	}
+	w := serve(r, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("DELETE after repeated failures = %d, want 503 from the open breaker", w.Code)
+	}
+	if body := decodeError(t, w); body.Code != response.CodeServiceUnavailable {
+		t.Errorf("open breaker code = %s, want SERVICE_UNAVAILABLE", body.Code)
+	}
+}
+
+func TestCircuitBreakerIsolatesRoutes(t *testing.T) {
+	r, breakers := breakerRouter(time.Minute)
+	tripDelete(t, r)
+
+	for i := 0; i < 5; i++ {
+		if w := serve(r, httptest.NewRequest(http.MethodGet, "/users/2", nil)); w.Code != http.StatusOK {
+			t.Fatalf("GET while the DELETE breaker is open = %d, want 200", w.Code)
+		}
+	}
+
+	states := map[string]string{}
+	for _, s := range breakers.Stats() {
+		states[s.Route] = s.State
+	}
+	if states["DELETE /users/:id"] != "open" || states["GET /users/:id"] != "closed" {
+		t.Errorf("breaker states = %v, want only the DELETE route open", states)
+	}
+}
+
+func TestCircuitBreakerKeysRoutesByPattern(t *testing.T) {
+	r, breakers := breakerRouter(time.Minute)
+
+	for _, id := range []string{"1", "2", "3"} {
+		serve(r, httptest.NewRequest(http.MethodGet, "/users/"+id, nil))
+	}
+
+	stats := breakers.Stats()
+	if len(stats) != 1 || stats[0].Route != "GET /users/:id" || stats[0].Requests != 3 {
+		t.Errorf("stats = %+v, want one breaker for the route pattern", stats)
+	}
+}
+
+func TestCircuitBreakerStatsCountTrips(t *testing.T) {
+	r, breakers := breakerRouter(time.Minute)
+	tripDelete(t, r)
+
+	for _, s := range breakers.Stats() {
+		if s.Route == "DELETE /users/:id" && s.Trips != 1 {
+			t.Errorf("trips = %d, want 1", s.Trips)
+		}
+	}
+}
+
+func TestCircuitBreakerStaysOpenForTimeout(t *testing.T) {
+	timeout := time.Second
+	r, breakers := breakerRouter(timeout)
+	tripDelete(t, r)
+	opened := time.Now()
+
+	time.Sleep(timeout / 2)
+	if w := serve(r, httptest.NewRequest(http.MethodDelete, "/users/1", nil)); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("DELETE %v after opening = %d, want the breaker still open", time.Since(opened), w.Code)
+	}
+
+	time.Sleep(timeout - time.Since(opened) + 50*time.Millisecond)
+	if w := serve(r, httptest.NewRequest(http.MethodDelete, "/users/1", nil)); w.Code != http.StatusInternalServerError {
+		t.Fatalf("DELETE after the timeout = %d, want the half-open breaker to let it through", w.Code)
+	}
+	for _, s := range breakers.Stats() {
+		if s.Route == "DELETE /users/:id" && s.State != "open" {
+			t.Errorf("state after a failed half-open request = %s, want open again", s.State)
+		}
+	}
+}
+
+func TestNewCircuitBreakersRejectsSubSecondTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, 60, 500 * time.Millisecond} {
+		breakers := NewCircuitBreakers(CircuitBreakerConfig{Timeout: timeout})
+		if got, want := breakers.cfg.Timeout, DefaultCircuitBreakerConfig().Timeout; got != want {
+			t.Errorf("timeout %v became %v, want the default %v", timeout, got, want)
+		}
+	}
+}
+
+func TestCircuitBreakerLogsStateChanges(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	r, _ := breakerRouter(time.Minute)
+	tripDelete(t, r)
+
+	if want := `Circuit breaker "DELETE /users/:id" changed state: closed -> open`; !strings.Contains(logs.String(), want) {
+		t.Errorf("log %q does not contain %q", logs.String(), want)
+	}
+}
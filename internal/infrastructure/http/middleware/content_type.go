@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireJSON rejects write requests whose body isn't declared as application/json with
+// 415, so clients get a clear error instead of a binding failure. Parameters such as
+// charset are allowed. GET, HEAD, OPTIONS and DELETE requests and requests without a
+// body are let through.
+func RequireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodDelete:
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			response.Abort(c, http.StatusUnsupportedMediaType, response.CodeUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+
+		c.Next()
+	}
+}
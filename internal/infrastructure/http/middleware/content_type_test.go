@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func jsonRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(RequireJSON())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/users", ok)
+	r.PUT("/users/1", ok)
+	r.GET("/users/1", ok)
+	r.DELETE("/users/1", ok)
+	return r
+}
+
+func TestRequireJSON(t *testing.T) {
+	r := jsonRouter()
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		contentType string
+		want        int
+	}{
+		{"json", http.MethodPost, "{}", "application/json", http.StatusOK},
+		{"json with charset", http.MethodPut, "{}", "application/json; charset=utf-8", http.StatusOK},
+		{"json in another case", http.MethodPost, "{}", "Application/JSON", http.StatusOK},
+		{"form", http.MethodPost, "name=a", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"text", http.MethodPut, "{}", "text/plain", http.StatusUnsupportedMediaType},
+		{"json suffix type", http.MethodPost, "{}", "application/json-patch+json", http.StatusUnsupportedMediaType},
+		{"missing", http.MethodPost, "{}", "", http.StatusUnsupportedMediaType},
+		{"malformed", http.MethodPost, "{}", "application/json; charset", http.StatusUnsupportedMediaType},
+		{"write without a body", http.MethodPost, "", "", http.StatusOK},
+		{"get", http.MethodGet, "", "text/plain", http.StatusOK},
+		{"delete with a body", http.MethodDelete, "{}", "text/plain", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/users/1"
+			if tt.method == http.MethodPost {
+				path = "/users"
+			}
+			req := httptest.NewRequest(tt.method, path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			w := serve(r, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnsupportedMediaType {
+				if body := decodeError(t, w); body.Code != response.CodeUnsupportedMediaType {
+					t.Errorf("code = %s, want UNSUPPORTED_MEDIA_TYPE", body.Code)
+				}
+			}
+		})
+	}
+}
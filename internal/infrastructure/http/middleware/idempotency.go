@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client-chosen idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// inProgressTTL bounds how long a claimed key blocks retries if the request never completes
+const inProgressTTL = time.Minute
+
+// responseRecorder captures the response body while still writing it to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when a request is retried with the same
+// Idempotency-Key header. A key still being processed returns 409 Conflict, and a key
+// reused with a different body returns 422 instead of replaying the first response.
+// Requests without the header are passed through unchanged.
+func Idempotency(store *cache.RedisCache, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		// Scope keys to the route so the same key can't replay another endpoint's response
+		scopedKey := c.Request.Method + ":" + c.FullPath() + ":" + key
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			response.Abort(c, http.StatusBadRequest, response.CodeValidationError, "failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		bodyHash := hashBody(body)
+
+		acquired, record, err := store.StartIdempotentRequest(c.Request.Context(), scopedKey, bodyHash, inProgressTTL)
+		if err != nil {
+			log.Printf("Idempotency store error, processing request without idempotency: %v", err)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			if record != nil && record.BodyHash != "" && record.BodyHash != bodyHash {
+				response.Abort(c, http.StatusUnprocessableEntity, response.CodeIdempotencyKeyReused, "idempotency key was already used with a different request body")
+				return
+			}
+			if record != nil && record.Completed {
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.StatusCode, record.ContentType, record.Body)
+				c.Abort()
+				return
+			}
+
+			response.Abort(c, http.StatusConflict, response.CodeIdempotencyConflict, "a request with this idempotency key is already in progress")
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+
+		// A panicking handler never reaches the code below; free the key on the way out
+		// so retries aren't locked out until inProgressTTL expires
+		finished := false
+		defer func() {
+			if finished {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := store.ReleaseIdempotentRequest(ctx, scopedKey); err != nil {
+				log.Printf("Failed to release idempotency key after panic: %v", err)
+			}
+		}()
+
+		c.Next()
+		finished = true
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		// Server errors are not cached so the client can retry them
+		if recorder.Status() >= http.StatusInternalServerError {
+			if err := store.ReleaseIdempotentRequest(ctx, scopedKey); err != nil {
+				log.Printf("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+
+		err = store.CompleteIdempotentRequest(ctx, scopedKey, &cache.IdempotencyRecord{
+			BodyHash:    bodyHash,
+			StatusCode:  recorder.Status(),
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}, ttl)
+		if err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
+
+// hashBody fingerprints a request body so a reused key can be matched to its first request
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maintenance rejects writes with 503 and a Retry-After header while maintenance mode is on,
+// either because forced is set or the Redis flag was toggled at runtime. Reads (GET, HEAD,
+// OPTIONS) and requests to allowlisted paths always go through. If Redis is unreachable the
+// runtime flag is treated as off so an outage doesn't block all writes.
+func Maintenance(store *cache.RedisCache, forced bool, retryAfter time.Duration, allowlist []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, path := range allowlist {
+		allowed[path] = true
+	}
+	retryAfterSeconds := strconv.Itoa(int(retryAfter.Seconds()))
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if allowed[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		enabled := forced
+		if !enabled {
+			var err error
+			enabled, err = store.MaintenanceEnabled(c.Request.Context())
+			if err != nil {
+				log.Printf("Maintenance flag unavailable, allowing request: %v", err)
+			}
+		}
+
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", retryAfterSeconds)
+		response.Abort(c, http.StatusServiceUnavailable, response.CodeMaintenance, "service is in maintenance mode, writes are temporarily disabled")
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxInFlight rejects requests with 503 and Retry-After while n are already being served,
+// so overload sheds load instead of queueing without bound. Requests to the skipped
+// paths, such as health probes, are neither counted nor limited. n <= 0 disables the limit.
+func MaxInFlight(n int, skip ...string) gin.HandlerFunc {
+	if n <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	skipped := make(map[string]bool, len(skip))
+	for _, path := range skip {
+		skipped[path] = true
+	}
+	slots := make(chan struct{}, n)
+
+	return func(c *gin.Context) {
+		if skipped[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			c.Header("Retry-After", "1")
+			response.Abort(c, http.StatusServiceUnavailable, response.CodeServiceUnavailable, "server is at capacity, retry shortly")
+			return
+		}
+		defer func() { <-slots }()
+
+		c.Next()
+	}
+}
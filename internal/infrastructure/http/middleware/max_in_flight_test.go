@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// inFlightRouter limits /work to n concurrent requests; each /work request signals
+// entered and then blocks until release is closed
+func inFlightRouter(n int) (r *gin.Engine, entered chan struct{}, release chan struct{}) {
+	entered, release = make(chan struct{}, 16), make(chan struct{})
+	r = gin.New()
+	r.Use(MaxInFlight(n, "/health"))
+	r.GET("/work", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, entered, release
+}
+
+func get(r http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestMaxInFlight(t *testing.T) {
+	const n = 3
+	r, entered, release := inFlightRouter(n)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, n)
+	for range n {
+		wg.Go(func() { codes <- get(r, "/work").Code })
+	}
+	for range n {
+		<-entered
+	}
+
+	for range 2 {
+		w := get(r, "/work")
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("request over the limit = %d, want 503", w.Code)
+		}
+		if w.Header().Get("Retry-After") == "" {
+			t.Error("503 without Retry-After")
+		}
+	}
+	if w := get(r, "/health"); w.Code != http.StatusOK {
+		t.Errorf("health probe at capacity = %d, want 200", w.Code)
+	}
+
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("admitted request = %d, want 200", code)
+		}
+	}
+	if w := get(r, "/work"); w.Code != http.StatusOK {
+		t.Errorf("request after the others finished = %d, want 200", w.Code)
+	}
+}
+
+func TestMaxInFlightDisabled(t *testing.T) {
+	r, entered, release := inFlightRouter(0)
+	close(release)
+
+	for range 20 {
+		if w := get(r, "/work"); w.Code != http.StatusOK {
+			t.Fatalf("request without a limit = %d, want 200", w.Code)
+		}
+		<-entered
+	}
+}
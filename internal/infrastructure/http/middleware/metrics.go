@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"time"
+
+	"user-crud/internal/infrastructure/tracing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware records the duration of every request
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		tracing.RecordRequestDuration(
+			c.Request.Context(),
+			time.Since(start),
+			c.Request.Method,
+			c.FullPath(),
+			c.Writer.Status(),
+		)
+	}
+}
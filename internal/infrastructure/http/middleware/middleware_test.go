@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve sends req through r and returns the recorded response
+func serve(r http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// decodeError decodes an error envelope from w, failing the test if the body is not one
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) response.ErrorResponse {
+	t.Helper()
+
+	var body response.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response body %q is not JSON: %v", w.Body.String(), err)
+	}
+	if body.Status != "error" {
+		t.Fatalf("response body %q is not an error envelope", w.Body.String())
+	}
+	return body
+}
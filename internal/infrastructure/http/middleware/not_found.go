@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotFound answers requests for undefined paths in the standard JSON envelope instead of
+// gin's plain-text 404. Register it with Engine.NoRoute.
+func NotFound() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.Abort(c, http.StatusNotFound, response.CodeNotFound, "route not found")
+	}
+}
+
+// MethodNotAllowed answers requests whose path exists under another method. Register it
+// with Engine.NoMethod and set HandleMethodNotAllowed; gin fills in the Allow header.
+func MethodNotAllowed() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.Abort(c, http.StatusMethodNotAllowed, response.CodeMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaginationGuard rejects page and limit query parameters that are not integers or are
+// negative, and deep pages whose page*limit exceeds maxDepth, with 400 before the request
+// reaches the database. The limit is resolved the way the query handlers do (missing uses
+// defaultLimit, larger than maxLimit is clamped) so the ceiling applies to the real OFFSET.
+// A maxDepth of 0 disables the ceiling, though pages whose OFFSET would overflow are still rejected.
+func PaginationGuard(defaultLimit, maxLimit, maxDepth int) gin.HandlerFunc {
+	ceiling := maxDepth
+	if ceiling <= 0 {
+		ceiling = math.MaxInt
+	}
+
+	return func(c *gin.Context) {
+		page, ok := queryInt(c, "page")
+		if !ok {
+			return
+		}
+		limit, ok := queryInt(c, "limit")
+		if !ok {
+			return
+		}
+
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 {
+			limit = defaultLimit
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+
+		// Compare by division so huge pages can't overflow page*limit
+		if page > ceiling/limit {
+			response.Abort(c, http.StatusBadRequest, response.CodeInvalidParameter,
+				fmt.Sprintf("page * limit must not exceed %d; narrow the query with filters instead of paging this deep", ceiling))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// queryInt parses an optional non-negative integer query parameter, aborting with 400
+// if it is malformed. A missing or empty parameter yields 0.
+func queryInt(c *gin.Context, key string) (int, bool) {
+	raw := c.Query(key)
+	if raw == "" {
+		return 0, true
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		response.Abort(c, http.StatusBadRequest, response.CodeInvalidParameter, key+" must be a non-negative integer")
+		return 0, false
+	}
+
+	return value, true
+}
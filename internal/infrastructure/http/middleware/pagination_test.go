@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func guardedRouter(maxDepth int) *gin.Engine {
+	r := gin.New()
+	r.GET("/users", PaginationGuard(10, 100, maxDepth), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestPaginationGuard(t *testing.T) {
+	r := guardedRouter(10_000)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{"no parameters", "", http.StatusOK},
+		{"ordinary page", "?page=3&limit=20", http.StatusOK},
+		{"at the ceiling", "?page=100&limit=100", http.StatusOK},
+		{"past the ceiling", "?page=101&limit=100", http.StatusBadRequest},
+		{"default limit past the ceiling", "?page=1001", http.StatusBadRequest},
+		{"limit clamped before the ceiling", "?page=100&limit=5000", http.StatusOK},
+		{"non-numeric page", "?page=abc", http.StatusBadRequest},
+		{"non-numeric limit", "?limit=ten", http.StatusBadRequest},
+		{"negative page", "?page=-1", http.StatusBadRequest},
+		{"negative limit", "?limit=-5", http.StatusBadRequest},
+		{"page beyond int range", "?page=99999999999999999999", http.StatusBadRequest},
+		{"absurd page and limit", "?page=999999999&limit=999999999", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, httptest.NewRequest(http.MethodGet, "/users"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if w.Code == http.StatusBadRequest {
+				if body := decodeError(t, w); body.Code != response.CodeInvalidParameter {
+					t.Errorf("code = %s, want INVALID_PARAMETER", body.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginationGuardWithoutCeilingStillPreventsOverflow(t *testing.T) {
+	r := guardedRouter(0)
+
+	if w := serve(r, httptest.NewRequest(http.MethodGet, "/users?page=5000000&limit=100", nil)); w.Code != http.StatusOK {
+		t.Errorf("deep page without a ceiling = %d, want 200", w.Code)
+	}
+
+	overflowing := "/users?limit=100&page=" + strconv.Itoa(math.MaxInt/50)
+	if w := serve(r, httptest.NewRequest(http.MethodGet, overflowing, nil)); w.Code != http.StatusBadRequest {
+		t.Errorf("page whose offset overflows = %d, want 400", w.Code)
+	}
+}
@@ -1,19 +1,34 @@
 package middleware
 
 import (
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 
+	"user-crud/internal/infrastructure/http/response"
+	"user-crud/internal/infrastructure/tracing"
+
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
 
+// Rate limiter modes
+const (
+	// RateLimitModeEnforce rejects requests over the limit with 429
+	RateLimitModeEnforce = "enforce"
+	// RateLimitModeMonitor only reports requests that would be limited and lets them through
+	RateLimitModeMonitor = "monitor"
+)
+
 // RateLimiter implements rate limiting per IP
 type RateLimiter struct {
 	visitors map[string]*rate.Limiter
 	mu       sync.RWMutex
 	r        rate.Limit
 	b        int
+	mode     string
 }
 
 // NewRateLimiter creates a new rate limiter
@@ -24,7 +39,17 @@ func NewRateLimiter(r rate.Limit, b int) *RateLimiter {
 		visitors: make(map[string]*rate.Limiter),
 		r:        r,
 		b:        b,
+		mode:     RateLimitModeEnforce,
+	}
+}
+
+// WithMode sets the limiter mode; unknown modes fall back to enforce
+func (rl *RateLimiter) WithMode(mode string) *RateLimiter {
+	if mode != RateLimitModeMonitor {
+		mode = RateLimitModeEnforce
 	}
+	rl.mode = mode
+	return rl
 }
 
 // getVisitor returns the rate limiter for the given IP
@@ -47,13 +72,23 @@ func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 		ip := c.ClientIP()
 		limiter := rl.getVisitor(ip)
 
-		if !limiter.Allow() {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"status":  "error",
-				"message": "rate limit exceeded",
-				"details": "too many requests, please try again later",
-			})
-			c.Abort()
+		allowed := limiter.Allow()
+		remaining := int(math.Max(0, math.Floor(limiter.Tokens())))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rl.b))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		tracing.RecordRateLimitDecision(c.Request.Context(), rl.mode, !allowed)
+
+		if !allowed && rl.mode == RateLimitModeMonitor {
+			log.Printf("Rate limit would be exceeded for %s %s from %s (monitor mode)", c.Request.Method, c.Request.URL.Path, ip)
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(1/float64(rl.r)))))
+			body := response.NewError(response.CodeRateLimited, "rate limit exceeded")
+			body.Details = "too many requests, please try again later"
+			response.AbortJSON(c, http.StatusTooManyRequests, body)
 			return
 		}
 
@@ -61,6 +96,21 @@ func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	}
 }
 
+// ReadWriteRateLimit limits reads (GET, HEAD, OPTIONS) with read and every other method
+// with write, so expensive writes can be held to a stricter budget than reads
+func ReadWriteRateLimit(read, write *RateLimiter) gin.HandlerFunc {
+	readLimit := read.Middleware()
+	writeLimit := write.Middleware()
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			readLimit(c)
+		default:
+			writeLimit(c)
+		}
+	}
+}
+
 // CleanupVisitors removes old visitors (optional, for memory management)
 func (rl *RateLimiter) CleanupVisitors() {
 	rl.mu.Lock()
@@ -69,4 +119,4 @@ func (rl *RateLimiter) CleanupVisitors() {
 	// Clear all visitors (simple approach)
 	// In production, you might want to track last access time
 	rl.visitors = make(map[string]*rate.Limiter)
-}
\ No newline at end of file
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// limitedRouter allows readBurst reads and writeBurst writes per client; tokens refill
+// too slowly to matter during a test
+func limitedRouter(readBurst, writeBurst int, mode string) *gin.Engine {
+	read := NewRateLimiter(rate.Limit(0.001), readBurst).WithMode(mode)
+	write := NewRateLimiter(rate.Limit(0.001), writeBurst).WithMode(mode)
+
+	r := gin.New()
+	r.Use(ReadWriteRateLimit(read, write))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/users", ok)
+	r.POST("/users", ok)
+	r.DELETE("/users/1", ok)
+	return r
+}
+
+// send issues n requests from addr and returns how many were allowed
+func send(r http.Handler, n int, method, path, addr string) (allowed int, last *httptest.ResponseRecorder) {
+	for range n {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = addr
+		last = serve(r, req)
+		if last.Code == http.StatusOK {
+			allowed++
+		}
+	}
+	return allowed, last
+}
+
+func TestWritesAreLimitedMoreStrictlyThanReads(t *testing.T) {
+	r := limitedRouter(5, 2, RateLimitModeEnforce)
+
+	if allowed, _ := send(r, 4, http.MethodPost, "/users", "10.0.0.1:1"); allowed != 2 {
+		t.Errorf("allowed %d of 4 writes, want the write burst of 2", allowed)
+	}
+	if allowed, _ := send(r, 1, http.MethodDelete, "/users/1", "10.0.0.1:1"); allowed != 0 {
+		t.Error("DELETE was not counted against the write budget")
+	}
+
+	allowed, last := send(r, 7, http.MethodGet, "/users", "10.0.0.1:1")
+	if allowed != 5 {
+		t.Errorf("allowed %d of 7 reads after writes ran out, want the read burst of 5", allowed)
+	}
+	if last.Code != http.StatusTooManyRequests || last.Header().Get("Retry-After") == "" {
+		t.Fatalf("read over the limit = %d with Retry-After %q, want 429 with a Retry-After", last.Code, last.Header().Get("Retry-After"))
+	}
+	if body := decodeError(t, last); body.Code != response.CodeRateLimited {
+		t.Errorf("code = %s, want RATE_LIMITED", body.Code)
+	}
+}
+
+func TestRateLimitIsPerClient(t *testing.T) {
+	r := limitedRouter(5, 1, RateLimitModeEnforce)
+
+	send(r, 2, http.MethodPost, "/users", "10.0.0.1:1")
+	if allowed, _ := send(r, 1, http.MethodPost, "/users", "10.0.0.2:1"); allowed != 1 {
+		t.Error("another client's write was limited")
+	}
+}
+
+func TestRateLimitMonitorModeLetsRequestsThrough(t *testing.T) {
+	r := limitedRouter(1, 1, RateLimitModeMonitor)
+
+	if allowed, last := send(r, 3, http.MethodPost, "/users", "10.0.0.1:1"); allowed != 3 {
+		t.Errorf("monitor mode allowed %d of 3 writes, want all", allowed)
+	} else if remaining := last.Header().Get("X-RateLimit-Remaining"); remaining != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 0 once over the limit", remaining)
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecoveryJSON recovers from panics and responds with a 500 in the standard JSON envelope.
+// It must be registered after TracingMiddleware so the panic is recorded on the request span.
+func RecoveryJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err := fmt.Errorf("panic: %v", rec)
+				span := trace.SpanFromContext(c.Request.Context())
+
+				log.Printf("Panic recovered (request_id=%s) %s %s: %v\n%s",
+					requestID(c), c.Request.Method, c.Request.URL.Path, rec, debug.Stack())
+
+				span.RecordError(err, trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, err.Error())
+
+				response.Abort(c, http.StatusInternalServerError, response.CodeInternalError, "internal server error")
+			}
+		}()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRecoveryJSONReturnsErrorEnvelope(t *testing.T) {
+	r := gin.New()
+	r.Use(RecoveryJSON())
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want JSON", ct)
+	}
+	if body := decodeError(t, w); body.Code != response.CodeInternalError || body.Message != "internal server error" {
+		t.Errorf("body = %+v, want INTERNAL_ERROR without panic details", body)
+	}
+}
+
+func TestRecoveryJSONRecordsPanicOnSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx, span := tracer.Start(c.Request.Context(), "request")
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	r.Use(RecoveryJSON())
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	serve(r, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if s := spans[0]; s.Status().Code != codes.Error || len(s.Events()) == 0 || s.Events()[0].Name != "exception" {
+		t.Errorf("span status %v with events %v, want the panic recorded as an error", s.Status(), s.Events())
+	}
+}
+
+func TestRecoveryJSONPassesThroughNormalResponses(t *testing.T) {
+	r := gin.New()
+	r.Use(RecoveryJSON())
+	r.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "fine") })
+
+	w := serve(r, httptest.NewRequest(http.MethodGet, "/ok", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "fine" {
+		t.Errorf("got %d %q, want the handler's response untouched", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SlowRequestLog logs requests that take longer than threshold and tags their span with
+// slow=true. It must be registered after TracingMiddleware so the request span exists.
+// A non-positive threshold disables it.
+func SlowRequestLog(threshold time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if threshold <= 0 {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+
+		c.Next()
+
+		elapsed := time.Since(start)
+		if elapsed <= threshold {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		trace.SpanFromContext(c.Request.Context()).SetAttributes(attribute.Bool("slow", true))
+		log.Printf("Slow request (request_id=%s) %s %s: status=%d duration=%v threshold=%v",
+			requestID(c), c.Request.Method, route, c.Writer.Status(), elapsed, threshold)
+	}
+}
+
+// requestID returns the client's X-Request-ID, or the trace id when none was sent
+func requestID(c *gin.Context) string {
+	if id := c.GetHeader("X-Request-ID"); id != "" {
+		return id
+	}
+	if sc := trace.SpanFromContext(c.Request.Context()).SpanContext(); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// slowRouter serves /slow after a 30ms sleep and /fast at once, logging requests slower
+// than threshold; it returns the spans and the log output
+func slowRouter(t *testing.T, threshold time.Duration) (*gin.Engine, *tracetest.SpanRecorder, *bytes.Buffer) {
+	t.Helper()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx, span := tracer.Start(c.Request.Context(), "request")
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	r.Use(SlowRequestLog(threshold))
+	r.GET("/slow/:id", func(c *gin.Context) {
+		time.Sleep(30 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	r.GET("/fast", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r, recorder, &logs
+}
+
+// slowTag returns the slow attribute of span, if set
+func slowTag(span sdktrace.ReadOnlySpan) (bool, bool) {
+	for _, attr := range span.Attributes() {
+		if attr.Key == attribute.Key("slow") {
+			return attr.Value.AsBool(), true
+		}
+	}
+	return false, false
+}
+
+func TestSlowRequestIsLoggedAndTagged(t *testing.T) {
+	r, recorder, logs := slowRouter(t, 10*time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/slow/42", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	serve(r, req)
+
+	for _, want := range []string{"Slow request", "request_id=req-123", "GET /slow/:id", "status=200", "threshold=10ms"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("log %q does not contain %q", logs.String(), want)
+		}
+	}
+	if slow, ok := slowTag(recorder.Ended()[0]); !ok || !slow {
+		t.Error("slow request span is not tagged slow=true")
+	}
+}
+
+func TestFastRequestIsNotLogged(t *testing.T) {
+	r, recorder, logs := slowRouter(t, time.Second)
+
+	serve(r, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	serve(r, httptest.NewRequest(http.MethodGet, "/slow/1", nil))
+
+	if logs.Len() != 0 {
+		t.Errorf("requests under the threshold were logged: %q", logs.String())
+	}
+	for _, span := range recorder.Ended() {
+		if _, ok := slowTag(span); ok {
+			t.Errorf("span %q was tagged slow", span.Name())
+		}
+	}
+}
+
+func TestSlowRequestLogDisabled(t *testing.T) {
+	r, _, logs := slowRouter(t, 0)
+
+	serve(r, httptest.NewRequest(http.MethodGet, "/slow/1", nil))
+
+	if logs.Len() != 0 {
+		t.Errorf("disabled slow request log wrote %q", logs.String())
+	}
+}
@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"user-crud/internal/infrastructure/tracing"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -8,11 +10,17 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-// TracingMiddleware creates a tracing middleware
+// TracingMiddleware creates a tracing middleware. Like tracing.StartSpan it does nothing
+// when tracing is not enabled, so requests carry no span of their own either way.
 func TracingMiddleware(serviceName string) gin.HandlerFunc {
 	tracer := otel.Tracer(serviceName)
 
 	return func(c *gin.Context) {
+		if !tracing.IsEnabled() {
+			c.Next()
+			return
+		}
+
 		// Extract context from headers
 		ctx := otel.GetTextMapPropagator().Extract(
 			c.Request.Context(),
@@ -49,4 +57,4 @@ func TracingMiddleware(serviceName string) gin.HandlerFunc {
 			span.RecordError(c.Errors.Last())
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"user-crud/internal/infrastructure/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddlewareWithTracingDisabled(t *testing.T) {
+	if tracing.IsEnabled() {
+		t.Fatal("tracing is enabled without InitTracer")
+	}
+	// A registered provider must not make the middleware trace while tracing is disabled
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var handlerSpan, helperSpan trace.Span
+	r := gin.New()
+	r.Use(TracingMiddleware("test"))
+	r.GET("/users/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanFromContext(c.Request.Context())
+		_, helperSpan = tracing.StartSpan(c.Request.Context(), "GetUser")
+		helperSpan.End()
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if handlerSpan.IsRecording() || handlerSpan.SpanContext().IsValid() {
+		t.Error("middleware started a span with tracing disabled")
+	}
+	if helperSpan.IsRecording() {
+		t.Error("StartSpan recorded a span with tracing disabled")
+	}
+	if spans := recorder.Ended(); len(spans) != 0 {
+		t.Errorf("recorded %d spans with tracing disabled", len(spans))
+	}
+}
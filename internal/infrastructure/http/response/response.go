@@ -0,0 +1,116 @@
+// Package response defines the JSON envelopes shared by HTTP handlers and middleware.
+package response
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code is a stable, machine-readable error identifier clients can branch on
+type Code string
+
+// Error codes
+const (
+	CodeValidationError         Code = "VALIDATION_ERROR"
+	CodeInvalidID               Code = "INVALID_ID"
+	CodeInvalidParameter        Code = "INVALID_PARAMETER"
+	CodeSearchKeywordTooShort   Code = "SEARCH_KEYWORD_TOO_SHORT"
+	CodeSearchKeywordTooLong    Code = "SEARCH_KEYWORD_TOO_LONG"
+	CodeUserNotFound            Code = "USER_NOT_FOUND"
+	CodeNotFound                Code = "NOT_FOUND"
+	CodeMethodNotAllowed        Code = "METHOD_NOT_ALLOWED"
+	CodeEmailTaken              Code = "EMAIL_TAKEN"
+	CodeEmailDomainBlocked      Code = "EMAIL_DOMAIN_BLOCKED"
+	CodeUsernameTaken           Code = "USERNAME_TAKEN"
+	CodeInvalidCredentials      Code = "INVALID_CREDENTIALS"
+	CodeIncorrectPassword       Code = "INCORRECT_PASSWORD"
+	CodeWeakPassword            Code = "WEAK_PASSWORD"
+	CodePasswordReused          Code = "PASSWORD_REUSED"
+	CodeInvalidResetToken       Code = "INVALID_RESET_TOKEN"
+	CodeInvalidEmailChangeToken Code = "INVALID_EMAIL_CHANGE_TOKEN"
+	CodeEmailChangeNotAllowed   Code = "EMAIL_CHANGE_NOT_ALLOWED"
+	CodeAccountSuspended        Code = "ACCOUNT_SUSPENDED"
+	CodeInvalidStatusTransition Code = "INVALID_STATUS_TRANSITION"
+	CodeIdempotencyConflict     Code = "IDEMPOTENCY_CONFLICT"
+	CodeIdempotencyKeyReused    Code = "IDEMPOTENCY_KEY_REUSED"
+	CodePreconditionFailed      Code = "PRECONDITION_FAILED"
+	CodeUnauthorized            Code = "UNAUTHORIZED"
+	CodeForbidden               Code = "FORBIDDEN"
+	CodeUnsupportedMediaType    Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRateLimited             Code = "RATE_LIMITED"
+	CodeMaintenance             Code = "MAINTENANCE"
+	CodeServiceUnavailable      Code = "SERVICE_UNAVAILABLE"
+	CodeRequestTimeout          Code = "REQUEST_TIMEOUT"
+	CodeInternalError           Code = "INTERNAL_ERROR"
+)
+
+// SuccessResponse is the envelope for successful responses
+type SuccessResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ErrorResponse is the envelope for error responses
+type ErrorResponse struct {
+	Status  string `json:"status"`
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+// NewSuccess builds a success envelope around data
+func NewSuccess(data interface{}) SuccessResponse {
+	return SuccessResponse{Status: "success", Data: data}
+}
+
+// NewError builds an error envelope
+func NewError(code Code, message string) ErrorResponse {
+	return ErrorResponse{Status: "error", Code: code, Message: message}
+}
+
+// prettyJSON indents every JSON response; see SetPrettyJSON
+var prettyJSON atomic.Bool
+
+// SetPrettyJSON switches all JSON responses between compact (the default) and indented
+// output, which is easier to read in a terminal while developing
+func SetPrettyJSON(enabled bool) {
+	prettyJSON.Store(enabled)
+}
+
+// JSON writes obj as the response body, indented when SetPrettyJSON is on. Handlers and
+// middleware write every JSON body through it or the helpers below.
+func JSON(c *gin.Context, status int, obj interface{}) {
+	if prettyJSON.Load() {
+		c.IndentedJSON(status, obj)
+		return
+	}
+	c.JSON(status, obj)
+}
+
+// AbortJSON writes obj like JSON and stops the handler chain
+func AbortJSON(c *gin.Context, status int, obj interface{}) {
+	c.Abort()
+	JSON(c, status, obj)
+}
+
+// Success writes data in a success envelope
+func Success(c *gin.Context, status int, data interface{}) {
+	JSON(c, status, NewSuccess(data))
+}
+
+// Message writes a success envelope carrying only a message
+func Message(c *gin.Context, status int, message string) {
+	JSON(c, status, SuccessResponse{Status: "success", Message: message})
+}
+
+// Error writes an error envelope
+func Error(c *gin.Context, status int, code Code, message string) {
+	JSON(c, status, NewError(code, message))
+}
+
+// Abort writes an error envelope and stops the handler chain
+func Abort(c *gin.Context, status int, code Code, message string) {
+	AbortJSON(c, status, NewError(code, message))
+}
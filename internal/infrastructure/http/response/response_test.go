@@ -0,0 +1,41 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// render writes write's response through a test context and returns the body
+func render(write func(c *gin.Context)) string {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	write(c)
+	return w.Body.String()
+}
+
+func TestPrettyJSONSwitchesFormat(t *testing.T) {
+	t.Cleanup(func() { SetPrettyJSON(false) })
+
+	writers := map[string]func(c *gin.Context){
+		"JSON":    func(c *gin.Context) { JSON(c, http.StatusOK, gin.H{"id": 1}) },
+		"Success": func(c *gin.Context) { Success(c, http.StatusOK, gin.H{"id": 1}) },
+		"Error":   func(c *gin.Context) { Error(c, http.StatusNotFound, CodeNotFound, "user not found") },
+		"Abort":   func(c *gin.Context) { Abort(c, http.StatusTooManyRequests, CodeRateLimited, "slow down") },
+	}
+
+	for name, write := range writers {
+		SetPrettyJSON(false)
+		if body := render(write); strings.Contains(body, "\n") {
+			t.Errorf("%s with pretty JSON off = %q, want compact", name, body)
+		}
+
+		SetPrettyJSON(true)
+		if body := render(write); !strings.Contains(body, "{\n    \"") {
+			t.Errorf("%s with pretty JSON on = %q, want it indented", name, body)
+		}
+	}
+}
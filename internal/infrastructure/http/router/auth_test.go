@@ -0,0 +1,52 @@
+package router
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+)
+
+func TestPasswordPolicyEndpointReflectsConfig(t *testing.T) {
+	previous := domain.CurrentPasswordPolicy()
+	t.Cleanup(func() { domain.SetPasswordPolicy(previous) })
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository())
+
+	policies := []struct {
+		policy domain.PasswordPolicy
+		want   domain.PasswordRequirements
+	}{
+		{
+			domain.PasswordPolicy{MinLength: 12, RequireUpper: true, RequireDigit: true, DenylistEnabled: true},
+			domain.PasswordRequirements{MinLength: 12, RequiredClasses: []string{"uppercase", "digit"}, DenylistEnabled: true},
+		},
+		{
+			domain.PasswordPolicy{MinLength: 4},
+			domain.PasswordRequirements{MinLength: 4, RequiredClasses: []string{}},
+		},
+	}
+
+	for _, tt := range policies {
+		domain.SetPasswordPolicy(tt.policy)
+
+		w := srv.do(http.MethodGet, "/api/v1/auth/password-policy", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var body struct {
+			Data domain.PasswordRequirements `json:"data"`
+		}
+		decode(t, w, &body)
+		if !reflect.DeepEqual(body.Data, tt.want) {
+			t.Errorf("password policy = %+v, want %+v", body.Data, tt.want)
+		}
+	}
+
+	// The advertised rules are the ones enforced: the last policy accepts a 4 letter password
+	w := srv.do(http.MethodPost, "/api/v1/users", `{"name":"Alice","email":"alice@example.com","password":"abcd","age":30}`)
+	if w.Code != http.StatusCreated {
+		t.Errorf("create with a password the policy allows = %d, want 201: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/http/handler"
+	"user-crud/internal/infrastructure/http/response"
+)
+
+func TestCanceledRequestIsNotAServerError(t *testing.T) {
+	// Without singleflight the repository sees the request context, as it would in pgx
+	cfg := testConfig()
+	cfg.CacheSingleflight = false
+	repo := domaintest.NewUserRepository(seedUsers(t, 3)...)
+	srv := newTestServer(t, cfg, repo)
+
+	tests := []struct {
+		name       string
+		id         string
+		repoErr    error
+		cancel     bool
+		wantStatus int
+	}{
+		{"client went away", "1", context.Canceled, true, handler.StatusClientClosedRequest},
+		{"deadline exceeded", "2", context.DeadlineExceeded, false, http.StatusRequestTimeout},
+		{"canceled internally", "3", context.Canceled, false, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.Before = func(ctx context.Context, method string) error { return tt.repoErr }
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				repo.Before = func(ctx context.Context, method string) error {
+					cancel()
+					return ctx.Err()
+				}
+			}
+
+			w := srv.doContext(ctx, http.MethodGet, "/api/v1/users/"+tt.id, "")
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			switch tt.wantStatus {
+			case handler.StatusClientClosedRequest:
+				if w.Body.Len() != 0 {
+					t.Errorf("body = %q, want none for a client that is gone", w.Body.String())
+				}
+			case http.StatusRequestTimeout:
+				if code := errorCode(t, w); code != response.CodeRequestTimeout {
+					t.Errorf("code = %s, want REQUEST_TIMEOUT", code)
+				}
+			}
+		})
+	}
+}
+
+func TestConnectionLossIsServiceUnavailable(t *testing.T) {
+	repo := domaintest.NewUserRepository(seedUsers(t, 2)...)
+	down := true
+	repo.Before = func(ctx context.Context, method string) error {
+		if down {
+			return fmt.Errorf("%w: connection reset by peer", domain.ErrDatabaseUnavailable)
+		}
+		return nil
+	}
+	srv := newTestServer(t, testConfig(), repo)
+
+	for _, path := range []string{"/api/v1/users/1", "/api/v1/users?with_total=false"} {
+		w := srv.do(http.MethodGet, path, "")
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Errorf("%s while the database is down = %d with Retry-After %q, want 503 with a Retry-After", path, w.Code, w.Header().Get("Retry-After"))
+			continue
+		}
+		if code := errorCode(t, w); code != response.CodeServiceUnavailable {
+			t.Errorf("%s code = %s, want SERVICE_UNAVAILABLE", path, code)
+		}
+	}
+
+	down = false
+	if w := srv.do(http.MethodGet, "/api/v1/users/2", ""); w.Code != http.StatusOK {
+		t.Errorf("read after the database came back = %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"user-crud/internal/domain/domaintest"
+)
+
+const jsonAPI = "application/vnd.api+json"
+
+// jsonAPIBody is a JSON:API document holding users
+type jsonAPIBody[T any] struct {
+	Data  T                      `json:"data"`
+	Links map[string]string      `json:"links"`
+	Meta  map[string]interface{} `json:"meta"`
+}
+
+type jsonAPIUser struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+func TestGetUserFormatFollowsAccept(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository(seedUsers(t, 1)...))
+
+	w := srv.do(http.MethodGet, "/api/v1/users/1", "")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("default Content-Type = %q, want application/json", ct)
+	}
+	var plain struct {
+		Status string `json:"status"`
+		Data   struct {
+			ID   int64  `json:"id"`
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	decode(t, w, &plain)
+	if plain.Status != "success" || plain.Data.ID != 1 || plain.Data.Name != "User A" {
+		t.Errorf("default body = %s, want the success envelope", w.Body.String())
+	}
+
+	for _, accept := range []string{jsonAPI, "text/html, " + jsonAPI + "; q=0.9"} {
+		w := srv.do(http.MethodGet, "/api/v1/users/1", "", "Accept", accept)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Accept %q = %d", accept, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, jsonAPI) {
+			t.Errorf("Accept %q got Content-Type %q, want %s", accept, ct, jsonAPI)
+		}
+		if vary := w.Header().Get("Vary"); !strings.Contains(vary, "Accept") {
+			t.Errorf("Vary = %q, want Accept", vary)
+		}
+
+		var doc jsonAPIBody[jsonAPIUser]
+		decode(t, w, &doc)
+		if doc.Data.Type != "users" || doc.Data.ID != "1" || doc.Data.Attributes["name"] != "User A" {
+			t.Errorf("Accept %q body = %s, want a users resource", accept, w.Body.String())
+		}
+		if _, ok := doc.Data.Attributes["id"]; ok {
+			t.Error("id is repeated in the attributes")
+		}
+	}
+}
+
+func TestListUsersAsJSONAPI(t *testing.T) {
+	srv := newTestServer(t, testConfig(), pagingRepository(seedUsers(t, 3)))
+
+	w := srv.do(http.MethodGet, "/api/v1/users?limit=2", "", "Accept", jsonAPI)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var doc jsonAPIBody[[]jsonAPIUser]
+	decode(t, w, &doc)
+
+	if len(doc.Data) != 2 || doc.Data[0].Type != "users" || doc.Data[0].ID != "1" {
+		t.Errorf("data = %+v, want the first two users as resources", doc.Data)
+	}
+	for _, rel := range []string{"self", "first", "next", "last"} {
+		if doc.Links[rel] == "" {
+			t.Errorf("links = %v, want %s", doc.Links, rel)
+		}
+	}
+	if !strings.Contains(doc.Links["last"], "page=2") {
+		t.Errorf("last link = %q, want page 2", doc.Links["last"])
+	}
+	if doc.Meta["total"] != 3.0 || doc.Meta["total_pages"] != 2.0 || doc.Meta["has_more"] != true {
+		t.Errorf("meta = %v, want total 3, two pages and more to come", doc.Meta)
+	}
+
+	w = srv.do(http.MethodGet, "/api/v1/users?limit=2&with_total=false", "", "Accept", jsonAPI)
+	doc = jsonAPIBody[[]jsonAPIUser]{}
+	decode(t, w, &doc)
+	if _, ok := doc.Meta["total"]; ok || doc.Links["last"] != "" {
+		t.Errorf("without a total: meta = %v, links = %v; want no total and no last link", doc.Meta, doc.Links)
+	}
+}
+
+func TestListUsersDefaultFormat(t *testing.T) {
+	srv := newTestServer(t, testConfig(), pagingRepository(seedUsers(t, 3)))
+
+	w := srv.do(http.MethodGet, "/api/v1/users?limit=2", "", "Accept", "application/json")
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var page pageBody
+	decode(t, w, &page)
+	if len(page.Data) != 2 || page.Total != 3 || page.TotalPages != 2 {
+		t.Errorf("page = %+v, want the first two of three users", page)
+	}
+}
@@ -0,0 +1,323 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"user-crud/internal/application/query"
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/http/response"
+)
+
+// capturingRepository answers FindWithFilters with every seeded user and records the
+// queries it was asked
+func capturingRepository(t *testing.T, n int) (*domaintest.UserRepository, *[]query.ListUsersQuery) {
+	t.Helper()
+
+	users := seedUsers(t, n)
+	repo := domaintest.NewUserRepository(users...)
+	var seen []query.ListUsersQuery
+	repo.FindWithFiltersFunc = func(filters interface{}) ([]*domain.User, int64, error) {
+		seen = append(seen, filters.(query.ListUsersQuery))
+		return users, int64(len(users)), nil
+	}
+	return repo, &seen
+}
+
+func TestListCombinesIDsWithOtherFilters(t *testing.T) {
+	repo, seen := capturingRepository(t, 3)
+	cfg := testConfig()
+	cfg.ListCacheTTL = 0
+	srv := newTestServer(t, cfg, repo)
+
+	w := srv.do(http.MethodGet, "/api/v1/users?ids=3,1&search=user&age_min=21&ages=21,23", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if len(*seen) != 1 {
+		t.Fatalf("ran %d list queries, want 1", len(*seen))
+	}
+	q := (*seen)[0]
+	if !reflect.DeepEqual(q.IDs, []int64{3, 1}) || q.Search != "user" || q.AgeMin != 21 || !reflect.DeepEqual(q.Ages, []int{21, 23}) {
+		t.Errorf("query = %+v, want ids, search and ages combined", q)
+	}
+}
+
+func TestListByIDsAloneIsABatchLookup(t *testing.T) {
+	users := seedUsers(t, 3)
+	users[1].Status = domain.UserStatusSuspended
+	repo := domaintest.NewUserRepository(users...)
+	srv := newTestServer(t, testConfig(), repo)
+
+	w := srv.do(http.MethodGet, "/api/v1/users?ids=3,1,2,9", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if repo.Calls("FindWithFilters") != 0 || repo.Calls("GetByIDs") != 1 {
+		t.Errorf("ids alone ran %d list queries and %d batch lookups, want one batch lookup", repo.Calls("FindWithFilters"), repo.Calls("GetByIDs"))
+	}
+	if ids, notFound := batchIDs(t, w); !reflect.DeepEqual(ids, []int64{3, 1}) || !reflect.DeepEqual(notFound, []int64{2, 9}) {
+		t.Errorf("listed %v with not_found %v, want the suspended user hidden", ids, notFound)
+	}
+
+	w = srv.do(http.MethodGet, "/api/v1/users?ids=3,1,2,9&include_suspended=true", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if ids, notFound := batchIDs(t, w); !reflect.DeepEqual(ids, []int64{3, 1, 2}) || !reflect.DeepEqual(notFound, []int64{9}) {
+		t.Errorf("listed %v with not_found %v, want the suspended user included", ids, notFound)
+	}
+	if n := repo.Calls("FindWithFilters"); n != 0 {
+		t.Errorf("include_suspended turned the lookup into %d list queries", n)
+	}
+
+	if w := srv.do(http.MethodGet, "/api/v1/users?ids=1&include_suspended=maybe", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid include_suspended = %d, want 400", w.Code)
+	}
+}
+
+// batchIDs returns the ids of the users and the not_found list of an ids lookup
+func batchIDs(t *testing.T, w *httptest.ResponseRecorder) (ids, notFound []int64) {
+	t.Helper()
+	var body struct {
+		Data []struct {
+			ID int64 `json:"id"`
+		} `json:"data"`
+		NotFound []int64 `json:"not_found"`
+	}
+	decode(t, w, &body)
+	for _, user := range body.Data {
+		ids = append(ids, user.ID)
+	}
+	return ids, body.NotFound
+}
+
+func TestListRejectsInvalidIDs(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository())
+
+	tooMany := make([]string, 1001)
+	for i := range tooMany {
+		tooMany[i] = strconv.Itoa(i + 1)
+	}
+
+	for _, ids := range []string{"1,abc", "0", "-2", "1,,2", strings.Join(tooMany, ",")} {
+		w := srv.do(http.MethodGet, "/api/v1/users?search=user&ids="+ids, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ids=%.20s... = %d, want 400", ids, w.Code)
+			continue
+		}
+		if code := errorCode(t, w); code != response.CodeInvalidParameter {
+			t.Errorf("ids=%.20s... code = %s, want INVALID_PARAMETER", ids, code)
+		}
+	}
+}
+
+func TestListWithoutTotalReportsHasMore(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository(seedUsers(t, 3)...))
+
+	w := srv.do(http.MethodGet, "/api/v1/users?with_total=false&limit=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var page pageBody
+	decode(t, w, &page)
+	if len(page.Data) != 2 || !page.HasMore || page.Total != -1 || page.TotalPages != -1 {
+		t.Errorf("got %d users, has_more %t, total %d/%d; want 2, true and unknown totals", len(page.Data), page.HasMore, page.Total, page.TotalPages)
+	}
+	if h := w.Header().Get("X-Total-Count"); h != "" {
+		t.Errorf("X-Total-Count = %q, want it omitted without a total", h)
+	}
+	if link := w.Header().Get("Link"); !strings.Contains(link, `rel="next"`) {
+		t.Errorf("Link = %q, want a next page", link)
+	}
+
+	if w := srv.do(http.MethodGet, "/api/v1/users?with_total=maybe", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("with_total=maybe = %d, want 400", w.Code)
+	}
+}
+
+func TestListOverConcurrencyCapIsUnavailable(t *testing.T) {
+	repo := domaintest.NewUserRepository(seedUsers(t, 1)...)
+	started, release := make(chan struct{}), make(chan struct{})
+	repo.Before = func(ctx context.Context, method string) error {
+		if method == "FindWithFilters" {
+			close(started)
+			<-release
+		}
+		return nil
+	}
+	cfg := testConfig()
+	cfg.ListQueryConcurrency, cfg.ListQueryWait = 1, 20*time.Millisecond
+	cfg.ListCacheTTL = 0
+	srv := newTestServer(t, cfg, repo)
+
+	done := make(chan int)
+	go func() { done <- srv.do(http.MethodGet, "/api/v1/users", "").Code }()
+	<-started
+
+	w := srv.do(http.MethodGet, "/api/v1/users?page=2", "")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("list over the cap = %d with Retry-After %q, want 503 with a Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := srv.do(http.MethodGet, "/api/v1/users/1", ""); w.Code != http.StatusOK {
+		t.Errorf("point lookup while lists are capped = %d, want 200", w.Code)
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("list holding the slot = %d, want 200", code)
+	}
+}
+
+func TestListIncludeSuspendedParameter(t *testing.T) {
+	repo, seen := capturingRepository(t, 1)
+	cfg := testConfig()
+	cfg.ListCacheTTL = 0
+	srv := newTestServer(t, cfg, repo)
+
+	for _, tt := range []struct {
+		query string
+		want  bool
+	}{
+		{"", false},
+		{"?include_suspended=false", false},
+		{"?include_suspended=true", true},
+	} {
+		*seen = nil
+		if w := srv.do(http.MethodGet, "/api/v1/users"+tt.query, ""); w.Code != http.StatusOK {
+			t.Fatalf("%q = %d: %s", tt.query, w.Code, w.Body.String())
+		}
+		if len(*seen) != 1 || (*seen)[0].IncludeSuspended != tt.want {
+			t.Errorf("%q listed with include_suspended %v, want %t", tt.query, *seen, tt.want)
+		}
+	}
+
+	if w := srv.do(http.MethodGet, "/api/v1/users?include_suspended=yes", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("include_suspended=yes = %d, want 400", w.Code)
+	}
+}
+
+// waitForCachedList waits for the background write of a list page under gen
+func waitForCachedList(t *testing.T, srv *testServer, gen int) {
+	t.Helper()
+	pattern := "users:list:*:g" + strconv.Itoa(gen) + ":*"
+	for deadline := time.Now().Add(5 * time.Second); len(srv.redis.Keys(pattern)) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatalf("no list page was cached under generation %d", gen)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWritesBumpTheListGeneration(t *testing.T) {
+	repo := pagingRepository(seedUsers(t, 3))
+	srv := newTestServer(t, testConfig(), repo)
+
+	writes := []struct {
+		name, method, path, body string
+	}{
+		{"create", http.MethodPost, "/api/v1/users", `{"name":"New","email":"new@example.com","password":"Str0ng!pass","age":30}`},
+		{"update", http.MethodPut, "/api/v1/users/1", `{"name":"Renamed","email":"usera@example.com","age":30}`},
+		{"delete", http.MethodDelete, "/api/v1/users/2", ""},
+	}
+	for i, write := range writes {
+		if w := srv.do(http.MethodGet, "/api/v1/users", ""); w.Code != http.StatusOK {
+			t.Fatalf("list before %s = %d", write.name, w.Code)
+		}
+		waitForCachedList(t, srv, i)
+		lists := repo.Calls("FindWithFilters")
+		srv.do(http.MethodGet, "/api/v1/users", "")
+		if repo.Calls("FindWithFilters") != lists {
+			t.Fatalf("list before %s was not cached", write.name)
+		}
+
+		if w := srv.do(write.method, write.path, write.body); w.Code >= 300 {
+			t.Fatalf("%s = %d: %s", write.name, w.Code, w.Body.String())
+		}
+		if gen, _ := srv.redis.Get("users:list:gen"); gen != strconv.Itoa(i+1) {
+			t.Errorf("generation after %s = %q, want %d", write.name, gen, i+1)
+		}
+		srv.do(http.MethodGet, "/api/v1/users", "")
+		if repo.Calls("FindWithFilters") != lists+1 {
+			t.Errorf("list after %s was served from the cache", write.name)
+		}
+	}
+}
+
+func TestListExactAgesParameter(t *testing.T) {
+	repo, seen := capturingRepository(t, 3)
+	srv := newTestServer(t, testConfig(), repo)
+
+	for _, path := range []string{"/api/v1/users?ages=18,%2021,65", "/api/v1/users?ages=21&search=user&age_max=30"} {
+		if w := srv.do(http.MethodGet, path, ""); w.Code != http.StatusOK {
+			t.Fatalf("%s = %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+	if len(*seen) != 2 {
+		t.Fatalf("ran %d list queries, want 2", len(*seen))
+	}
+	if q := (*seen)[0]; !reflect.DeepEqual(q.Ages, []int{18, 21, 65}) {
+		t.Errorf("ages alone = %v, want [18 21 65]", q.Ages)
+	}
+	if q := (*seen)[1]; !reflect.DeepEqual(q.Ages, []int{21}) || q.Search != "user" || q.AgeMax != 30 {
+		t.Errorf("query = %+v, want ages combined with search and age_max", q)
+	}
+
+	tooMany := strings.TrimSuffix(strings.Repeat("1,", 51), ",")
+	for _, ages := range []string{"abc", "-1", "18,,21", tooMany} {
+		w := srv.do(http.MethodGet, "/api/v1/users?ages="+ages, "")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ages=%.20s = %d, want 400", ages, w.Code)
+			continue
+		}
+		if code := errorCode(t, w); code != response.CodeInvalidParameter {
+			t.Errorf("ages=%.20s code = %s, want INVALID_PARAMETER", ages, code)
+		}
+	}
+}
+
+func TestHeadListReturnsCountHeadersWithoutBody(t *testing.T) {
+	repo, seen := capturingRepository(t, 5)
+	srv := newTestServer(t, testConfig(), repo)
+
+	w := srv.do(http.MethodHead, "/api/v1/users?limit=2&search=user&ages=21,23", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("HEAD wrote a body: %q", w.Body.String())
+	}
+	want := map[string]string{"X-Total-Count": "5", "X-Total-Pages": "3", "X-Page": "1", "X-Limit": "2"}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if repo.Calls("CountWithFilters") != 1 || repo.Calls("FindWithFilters") != 0 {
+		t.Errorf("HEAD ran %d counts and %d list queries, want only the count", repo.Calls("CountWithFilters"), repo.Calls("FindWithFilters"))
+	}
+
+	if w := srv.do(http.MethodGet, "/api/v1/users?limit=2&search=user&ages=21,23", ""); w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(*seen) != 2 {
+		t.Fatalf("ran %d queries, want 2", len(*seen))
+	}
+	head, get := (*seen)[0], (*seen)[1]
+	if head.Search != get.Search || !reflect.DeepEqual(head.Ages, get.Ages) || head.IncludeSuspended != get.IncludeSuspended {
+		t.Errorf("HEAD filtered by %+v, GET by %+v; want the same filters", head, get)
+	}
+
+	if w := srv.do(http.MethodHead, "/api/v1/users?ages=abc", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("HEAD with an invalid filter = %d, want 400", w.Code)
+	}
+}
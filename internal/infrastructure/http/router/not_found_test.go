@@ -0,0 +1,48 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/http/response"
+)
+
+func TestUnmatchedRoutesAnswerInJSON(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository())
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantCode   response.Code
+	}{
+		{"unknown path", http.MethodGet, "/api/v1/nope", http.StatusNotFound, response.CodeNotFound},
+		{"unknown root path", http.MethodGet, "/favicon.ico", http.StatusNotFound, response.CodeNotFound},
+		{"unsupported method", http.MethodPatch, "/api/v1/users", http.StatusMethodNotAllowed, response.CodeMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := srv.do(tt.method, tt.path, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", ct)
+			}
+			var body response.ErrorResponse
+			decode(t, w, &body)
+			if body.Status != "error" || body.Code != tt.wantCode {
+				t.Errorf("body = %s, want an error envelope with code %s", w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+
+	w := srv.do(http.MethodPatch, "/api/v1/users", "")
+	if allow := w.Header().Get("Allow"); !strings.Contains(allow, http.MethodGet) || !strings.Contains(allow, http.MethodPost) {
+		t.Errorf("Allow = %q, want the methods the path supports", allow)
+	}
+}
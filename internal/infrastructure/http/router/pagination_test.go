@@ -0,0 +1,179 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/http/response"
+)
+
+func TestNegativePageIsRejected(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository(seedUsers(t, 3)...))
+
+	for _, path := range []string{"/api/v1/users?page=-1", "/api/v1/users/search?q=user&page=-1"} {
+		w := srv.do(http.MethodGet, path, "")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("GET %s = %d, want 400", path, w.Code)
+		}
+		if code := errorCode(t, w); code != response.CodeInvalidParameter {
+			t.Errorf("GET %s code = %s, want INVALID_PARAMETER", path, code)
+		}
+	}
+}
+
+func TestListUsesConfiguredLimits(t *testing.T) {
+	cfg := testConfig()
+	cfg.PaginationDefaultLimit, cfg.PaginationMaxLimit = 2, 3
+	srv := newTestServer(t, cfg, domaintest.NewUserRepository(seedUsers(t, 5)...))
+
+	tests := []struct {
+		path      string
+		wantLimit string
+	}{
+		{"/api/v1/users", "2"},
+		{"/api/v1/users?limit=3", "3"},
+		{"/api/v1/users/search?q=user", "2"},
+		{"/api/v1/users/search?q=user&limit=3", "3"},
+	}
+
+	for _, tt := range tests {
+		w := srv.do(http.MethodGet, tt.path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", tt.path, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("X-Limit"); got != tt.wantLimit {
+			t.Errorf("GET %s limit = %s, want %s", tt.path, got, tt.wantLimit)
+		}
+	}
+}
+
+func TestSearchEmptyAndPastTheEndPages(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository(seedUsers(t, 2)...))
+
+	tests := []struct {
+		name           string
+		path           string
+		wantTotalPages int
+		wantOutOfRange bool
+	}{
+		{"no matches", "/api/v1/users/search?q=nobody", 1, false},
+		{"past the last page", "/api/v1/users/search?q=user&page=5", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := srv.do(http.MethodGet, tt.path, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			var body pageBody
+			decode(t, w, &body)
+			if body.Data == nil || len(body.Data) != 0 {
+				t.Errorf("data = %v, want an empty array", body.Data)
+			}
+			if body.TotalPages != tt.wantTotalPages || body.OutOfRange != tt.wantOutOfRange {
+				t.Errorf("total_pages = %d, out_of_range = %t; want %d, %t", body.TotalPages, body.OutOfRange, tt.wantTotalPages, tt.wantOutOfRange)
+			}
+		})
+	}
+}
+
+func TestCursorWalkVisitsEveryUserOnce(t *testing.T) {
+	srv := newTestServer(t, testConfig(), pagingRepository(seedUsers(t, 5)))
+
+	var ids []int64
+	path := "/api/v1/users?paginate=cursor&limit=2"
+	for pages := 0; ; pages++ {
+		if pages == 5 {
+			t.Fatalf("cursor walk did not end; saw %v", ids)
+		}
+		w := srv.do(http.MethodGet, path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, w.Code, w.Body.String())
+		}
+
+		var body map[string]json.RawMessage
+		decode(t, w, &body)
+		for _, field := range []string{"total", "total_pages", "page"} {
+			if _, ok := body[field]; ok {
+				t.Errorf("cursor page has %q", field)
+			}
+		}
+		var page struct {
+			Data       []struct{ ID int64 } `json:"data"`
+			NextCursor *string              `json:"next_cursor"`
+		}
+		decode(t, w, &page)
+		for _, u := range page.Data {
+			ids = append(ids, u.ID)
+		}
+
+		if page.NextCursor == nil {
+			if raw, ok := body["next_cursor"]; !ok || string(raw) != "null" {
+				t.Errorf("last page next_cursor = %s, want null", raw)
+			}
+			if link := w.Header().Get("Link"); link != "" {
+				t.Errorf("last page Link = %q, want none", link)
+			}
+			break
+		}
+		path = "/api/v1/users?paginate=cursor&limit=2&cursor=" + url.QueryEscape(*page.NextCursor)
+	}
+
+	if want := []int64{1, 2, 3, 4, 5}; !slices.Equal(ids, want) {
+		t.Errorf("walk visited %v, want %v", ids, want)
+	}
+}
+
+func TestCursorIsRejectedForAnotherSort(t *testing.T) {
+	srv := newTestServer(t, testConfig(), pagingRepository(seedUsers(t, 3)))
+
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	decode(t, srv.do(http.MethodGet, "/api/v1/users?paginate=cursor&limit=1", ""), &page)
+
+	for _, path := range []string{
+		"/api/v1/users?paginate=cursor&sort=name&cursor=" + page.NextCursor,
+		"/api/v1/users?paginate=cursor&cursor=garbage",
+		"/api/v1/users?paginate=sideways",
+	} {
+		if w := srv.do(http.MethodGet, path, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", path, w.Code)
+		}
+	}
+}
+
+func TestSearchKeywordErrorCodes(t *testing.T) {
+	cfg := testConfig()
+	cfg.SearchKeywordMinLength, cfg.SearchKeywordMaxLength = 2, 10
+	srv := newTestServer(t, cfg, domaintest.NewUserRepository(seedUsers(t, 2)...))
+
+	tests := []struct {
+		keyword string
+		status  int
+		code    response.Code
+	}{
+		{"a", http.StatusBadRequest, response.CodeSearchKeywordTooShort},
+		{"abcdefghijk", http.StatusBadRequest, response.CodeSearchKeywordTooLong},
+		{"", http.StatusBadRequest, response.CodeInvalidParameter},
+		{"user", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		w := srv.do(http.MethodGet, "/api/v1/users/search?q="+url.QueryEscape(tt.keyword), "")
+		if w.Code != tt.status {
+			t.Errorf("q=%q: status = %d, want %d: %s", tt.keyword, w.Code, tt.status, w.Body.String())
+			continue
+		}
+		if tt.code != "" {
+			if code := errorCode(t, w); code != tt.code {
+				t.Errorf("q=%q: code = %s, want %s", tt.keyword, code, tt.code)
+			}
+		}
+	}
+}
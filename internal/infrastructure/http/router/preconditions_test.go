@@ -0,0 +1,58 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/http/response"
+)
+
+func TestIfUnmodifiedSince(t *testing.T) {
+	// Seeded user 1 was last updated at midnight on 2024-01-01
+	updatedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := updatedAt.Add(-time.Hour).Format(http.TimeFormat)
+	at := updatedAt.Format(http.TimeFormat)
+	after := updatedAt.Add(time.Hour).Format(http.TimeFormat)
+	update := `{"name":"Renamed","email":"usera@example.com","age":30}`
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		wantStatus int
+	}{
+		{"update of a user changed since", http.MethodPut, before, http.StatusPreconditionFailed},
+		{"update of a user unchanged since", http.MethodPut, at, http.StatusOK},
+		{"update with a later date", http.MethodPut, after, http.StatusOK},
+		{"update with a malformed date", http.MethodPut, "yesterday", http.StatusOK},
+		{"delete of a user changed since", http.MethodDelete, before, http.StatusPreconditionFailed},
+		{"delete of a user unchanged since", http.MethodDelete, at, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := domaintest.NewUserRepository(seedUsers(t, 1)...)
+			srv := newTestServer(t, testConfig(), repo)
+
+			body := ""
+			if tt.method == http.MethodPut {
+				body = update
+			}
+			w := srv.do(tt.method, "/api/v1/users/1", body, "If-Unmodified-Since", tt.header)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusPreconditionFailed {
+				if code := errorCode(t, w); code != response.CodePreconditionFailed {
+					t.Errorf("code = %s, want PRECONDITION_FAILED", code)
+				}
+				if u := repo.User(1); u == nil || u.Name != "User A" {
+					t.Errorf("user after a failed precondition = %+v, want it untouched", u)
+				}
+			}
+		})
+	}
+}
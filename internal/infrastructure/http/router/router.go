@@ -1,8 +1,15 @@
 package router
 
 import (
+	"log"
+	"log/slog"
+	"os"
+
+	"user-crud/internal/config"
+	"user-crud/internal/infrastructure/cache"
 	"user-crud/internal/infrastructure/http/handler"
 	"user-crud/internal/infrastructure/http/middleware"
+	"user-crud/internal/infrastructure/http/response"
 
 	_ "user-crud/docs"
 
@@ -12,45 +19,129 @@ import (
 	"golang.org/x/time/rate"
 )
 
-func SetupRouter(h *handler.Handler) *gin.Engine {
+func SetupRouter(h *handler.Handler, cfg *config.Config, redisCache *cache.RedisCache) *gin.Engine {
 	// Release mode
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	response.SetPrettyJSON(cfg.PrettyJSON)
+
+	// Only believe X-Forwarded-For from configured proxies, otherwise clients could
+	// spoof their IP and evade per-IP rate limiting
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Printf("Warning: invalid trusted proxies, trusting none: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+
+	breakers := middleware.NewCircuitBreakers(middleware.CircuitBreakerConfig{
+		MaxRequests:  uint32(cfg.CircuitBreakerMaxRequests),
+		MinRequests:  uint32(cfg.CircuitBreakerMinRequests),
+		FailureRatio: cfg.CircuitBreakerFailureRatio,
+		Timeout:      cfg.CircuitBreakerTimeout,
+	})
 
 	// Global middleware
 	r.Use(
-		gin.Recovery(),
 		gin.Logger(),
 		middleware.TracingMiddleware("user-crud-api"),
-		middleware.CircuitBreakerMiddleware(),
+		middleware.MetricsMiddleware(),
+		middleware.SlowRequestLog(cfg.SlowRequestThreshold),
+		middleware.RecoveryJSON(),
+		// Probes must keep answering while the service sheds load
+		middleware.MaxInFlight(cfg.MaxInFlight, "/health", "/ready"),
 	)
 
-	// Rate limiter global
-	rateLimiter := middleware.NewRateLimiter(rate.Limit(10), 20)
-	r.Use(rateLimiter.Middleware())
+	// Opt-in body logging for debugging client integrations; secrets are redacted
+	if cfg.DebugBodyLog {
+		bodyLogger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		r.Use(middleware.BodyDump(bodyLogger, cfg.DebugBodyLogMaxBytes))
+		log.Printf("⚠️  DEBUG_BODY_LOG is on: request and response bodies are logged")
+	}
+
+	// Reads and writes have separate per-IP budgets; writes are more expensive
+	readLimiter := middleware.NewRateLimiter(rate.Limit(cfg.RateLimitReadRPS), cfg.RateLimitReadBurst).WithMode(cfg.RateLimitMode)
+	writeLimiter := middleware.NewRateLimiter(rate.Limit(cfg.RateLimitWriteRPS), cfg.RateLimitWriteBurst).WithMode(cfg.RateLimitMode)
+	r.Use(middleware.ReadWriteRateLimit(readLimiter, writeLimiter))
+
+	// The admin endpoint must stay writable so maintenance mode can be turned off
+	maintenanceAllowlist := append([]string{"/api/v1/admin/maintenance"}, cfg.MaintenanceAllowlist...)
+	r.Use(middleware.Maintenance(redisCache, cfg.MaintenanceMode, cfg.MaintenanceRetryAfter, maintenanceAllowlist))
+
+	// After maintenance mode, so its deliberate 503s never count as failures and trip
+	// the write breakers that would keep rejecting writes once maintenance ends
+	r.Use(breakers.Middleware())
+
+	// Unmatched routes get the same JSON envelope as every other error
+	r.NoRoute(middleware.NotFound())
+	r.NoMethod(middleware.MethodNotAllowed())
 
 	// ===== Infra endpoints (ROOT) =====
 	r.GET("/health", h.HealthCheck)
+	r.GET("/ready", h.ReadinessCheck)
 	r.GET("/metrics", h.Metrics)
+	r.GET("/debug/circuit", breakers.StatsHandler)
 
 	// Swagger (infra, bukan API bisnis)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	idempotency := middleware.Idempotency(redisCache, cfg.IdempotencyTTL)
+	// Batch endpoints are limited to internal services once API keys are configured
+	serviceOnly := func(c *gin.Context) { c.Next() }
+	if len(cfg.APIKeys) > 0 {
+		serviceOnly = middleware.APIKeyAuth(cfg.APIKeys)
+	}
+	// Admin tooling; batch updates can suspend users, so they need it as much as /admin does
+	adminAuth := middleware.AdminAuth(cfg.AdminToken, cfg.APIKeys)
+	paginationGuard := middleware.PaginationGuard(cfg.PaginationDefaultLimit, cfg.PaginationMaxLimit, cfg.PaginationMaxDepth)
+
 	// ===== API v1 =====
 	api := r.Group("/api")
 	{
-		v1 := api.Group("/v1")
+		v1 := api.Group("/v1", middleware.RequireJSON())
 		{
 			users := v1.Group("/users")
 			{
-				users.POST("", h.CreateUser)
-				users.GET("", h.ListUsers)
-				users.GET("/search", h.SearchUsers)
+				users.POST("", idempotency, h.CreateUser)
+				users.POST("/validate", h.ValidateUser)
+				users.GET("", paginationGuard, h.ListUsers)
+				users.HEAD("", paginationGuard, h.CountUsers)
+				users.DELETE("", serviceOnly, h.BatchDeleteUsers)
+				users.PATCH("/batch", adminAuth, h.BatchUpdateUsers)
+				users.GET("/search", paginationGuard, h.SearchUsers)
+				users.GET("/by-username", h.GetUserByUsername)
+				users.GET("/stats", h.GetUserStats)
+				users.GET("/recent", h.GetRecentUsers)
 				users.GET("/:id", h.GetUser)
+				users.HEAD("/:id", h.HeadUser)
+				users.GET("/:id/exists", h.UserExists)
+				users.GET("/:id/activity", h.GetUserActivity)
 				users.PUT("/:id", h.UpdateUser)
 				users.DELETE("/:id", h.DeleteUser)
 				users.PUT("/:id/change-password", h.ChangePassword)
+				users.POST("/:id/email-change", h.RequestEmailChange)
+				users.POST("/:id/email-change/confirm", h.ConfirmEmailChange)
+				users.GET("/:id/password-history", paginationGuard, h.GetPasswordHistory)
+			}
+
+			auth := v1.Group("/auth")
+			{
+				auth.POST("/login", h.Login)
+				auth.POST("/forgot-password", h.ForgotPassword)
+				auth.POST("/reset-password", h.ResetPassword)
+				auth.GET("/password-policy", h.GetPasswordPolicy)
+			}
+
+			admin := v1.Group("/admin", adminAuth)
+			{
+				admin.GET("/maintenance", h.GetMaintenance)
+				admin.PUT("/maintenance", h.SetMaintenance)
+				admin.GET("/cache/users/:id", h.GetCachedUser)
+				admin.DELETE("/cache/users/:id", h.EvictCachedUser)
+				admin.DELETE("/cache", h.FlushCache)
+				admin.POST("/users/merge", h.MergeUsers)
+				admin.POST("/users/:id/suspend", h.SuspendUser)
+				admin.POST("/users/:id/activate", h.ActivateUser)
 			}
 		}
 	}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"user-crud/internal/application/command"
+	"user-crud/internal/application/query"
+	"user-crud/internal/config"
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/cache"
+	"user-crud/internal/infrastructure/cache/cachetest"
+	"user-crud/internal/infrastructure/http/handler"
+	"user-crud/internal/infrastructure/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testConfig returns the default configuration with rate limits high enough that tests
+// sending many requests are not throttled
+func testConfig() *config.Config {
+	cfg := config.Load()
+	cfg.RateLimitReadRPS, cfg.RateLimitReadBurst = 1000, 1000
+	cfg.RateLimitWriteRPS, cfg.RateLimitWriteBurst = 1000, 1000
+	return cfg
+}
+
+// testServer is the full router wired like main.go, backed by an in-memory repository
+// and cache. There is no database pool, so the health and readiness probes can't be used.
+type testServer struct {
+	*gin.Engine
+	repo  *domaintest.UserRepository
+	redis *cachetest.Server
+	async *cache.WorkerPool
+}
+
+// newTestServer builds the router for cfg over repo
+func newTestServer(t *testing.T, cfg *config.Config, repo *domaintest.UserRepository) *testServer {
+	t.Helper()
+
+	redisCache, redis := cachetest.NewRedisCache(t, 5*time.Minute)
+	async := cache.NewWorkerPool(1, 64, nil)
+	t.Cleanup(func() { async.Shutdown(context.Background()) })
+	t.Cleanup(func() { response.SetPrettyJSON(false) })
+	logger := slog.New(slog.DiscardHandler)
+
+	pagination := query.Pagination{DefaultLimit: cfg.PaginationDefaultLimit, MaxLimit: cfg.PaginationMaxLimit}
+	limiter := query.NewQueryLimiter(cfg.ListQueryConcurrency, cfg.ListQueryWait)
+	keywords := query.KeywordLength{Min: cfg.SearchKeywordMinLength, Max: cfg.SearchKeywordMaxLength}
+
+	h := handler.NewHandler(
+		command.NewCreateUserHandler(repo, redisCache),
+		command.NewValidateUserHandler(repo, cfg.HideUserEnumeration),
+		command.NewUpdateUserHandler(repo, redisCache, async),
+		command.NewDeleteUserHandler(repo, redisCache, async),
+		command.NewBatchDeleteUsersHandler(repo, redisCache, async),
+		command.NewBatchUpdateUsersHandler(repo, redisCache, async),
+		command.NewMergeUsersHandler(repo, redisCache, async),
+		command.NewChangePasswordHandler(repo, redisCache, async, cfg.PasswordReuseLimit),
+		command.NewSuspendUserHandler(repo, redisCache, async),
+		command.NewActivateUserHandler(repo, redisCache, async),
+		command.NewForgotPasswordHandler(repo, redisCache, cfg.PasswordResetTokenTTL),
+		command.NewResetPasswordHandler(repo, redisCache, async, cfg.PasswordReuseLimit),
+		command.NewLoginHandler(repo, redisCache, async, cfg.HideUserEnumeration),
+		query.NewGetUserHandler(repo, redisCache, async, cfg.CacheSingleflight, logger),
+		query.NewUserExistsHandler(repo, redisCache, logger),
+		query.NewGetUserByUsernameHandler(repo),
+		query.NewGetUsersByIDsHandler(repo, redisCache, async, logger),
+		query.NewGetUserActivityHandler(repo),
+		query.NewUserStatsHandler(repo, redisCache, async, cfg.UserStatsCacheTTL, logger),
+		query.NewRecentUsersHandler(repo, redisCache, async, cfg.RecentUsersCacheTTL, logger),
+		query.NewGetPasswordHistoryHandler(repo, pagination),
+		command.NewRequestEmailChangeHandler(repo, redisCache, cfg.EmailChangeTokenTTL),
+		command.NewConfirmEmailChangeHandler(repo, redisCache, async),
+		query.NewListUsersHandler(repo, redisCache, async, pagination, cfg.SortDefaultOrders, limiter, cfg.ListCacheTTL, logger),
+		query.NewSearchUsersHandler(repo, cfg.FuzzySearchThreshold, keywords, pagination, limiter),
+		nil,
+		redisCache,
+		cfg.HideUserEnumeration,
+	)
+
+	return &testServer{Engine: SetupRouter(h, cfg, redisCache), repo: repo, redis: redis, async: async}
+}
+
+// do sends a request with an optional JSON body and returns the recorded response
+func (s *testServer) do(method, path, body string, header ...string) *httptest.ResponseRecorder {
+	return s.doContext(context.Background(), method, path, body, header...)
+}
+
+// doContext is do with the request bound to ctx; header holds name, value pairs
+func (s *testServer) doContext(ctx context.Context, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequestWithContext(ctx, method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+// decode unmarshals the response body into v, failing the test if it is not JSON
+func decode(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("response body %q is not JSON: %v", w.Body.String(), err)
+	}
+}
+
+// errorCode returns the code of the error envelope in w
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) response.Code {
+	t.Helper()
+	var body response.ErrorResponse
+	decode(t, w, &body)
+	return body.Code
+}
+
+// pageBody is a paginated list response
+type pageBody struct {
+	Data       []json.RawMessage `json:"data"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	TotalPages int               `json:"total_pages"`
+	OutOfRange bool              `json:"out_of_range"`
+	HasMore    bool              `json:"has_more"`
+	NextCursor string            `json:"next_cursor"`
+}
+
+// testPassword is the password of every seeded user
+const testPassword = "Str0ng!pass"
+
+// testPasswordHash hashes testPassword once; hashing is deliberately slow
+var testPasswordHash = sync.OnceValues(func() (string, error) {
+	return domain.HashPassword(testPassword)
+})
+
+// seedUsers returns active users 1..n named "User A", "User B", ... with the emails
+// usera@example.com, userb@example.com, ..., aged 21, 22, ... and password testPassword
+func seedUsers(t *testing.T, n int) []*domain.User {
+	t.Helper()
+
+	hash, err := testPasswordHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := make([]*domain.User, n)
+	for i := range users {
+		letter := string(rune('a' + i))
+		users[i] = &domain.User{
+			ID:           int64(i + 1),
+			Name:         "User " + strings.ToUpper(letter),
+			Email:        "user" + letter + "@example.com",
+			PasswordHash: hash,
+			Age:          21 + i,
+			Status:       domain.UserStatusActive,
+			CreatedAt:    time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC),
+			UpdatedAt:    time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	return users
+}
+
+// pagingRepository holds users in id order and answers FindWithFilters with the requested
+// page of them, continuing after the cursor of an ascending id listing; the in-memory
+// repository doesn't page on its own
+func pagingRepository(users []*domain.User) *domaintest.UserRepository {
+	repo := domaintest.NewUserRepository(users...)
+	repo.FindWithFiltersFunc = func(filters interface{}) ([]*domain.User, int64, error) {
+		q := filters.(query.ListUsersQuery)
+		rest := users
+		if q.After != nil {
+			for len(rest) > 0 && rest[0].ID <= q.After.ID {
+				rest = rest[1:]
+			}
+		}
+		limit := q.Limit
+		if q.SkipTotal {
+			limit++
+		}
+		start := min(max(q.Page-1, 0)*q.Limit, len(rest))
+		return rest[start:min(start+limit, len(rest))], int64(len(users)), nil
+	}
+	return repo
+}
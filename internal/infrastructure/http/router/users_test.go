@@ -0,0 +1,303 @@
+package router
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"user-crud/internal/config"
+	"user-crud/internal/domain"
+	"user-crud/internal/domain/domaintest"
+	"user-crud/internal/infrastructure/http/response"
+)
+
+func TestCreateUserWithBlockedEmailDomain(t *testing.T) {
+	domain.SetBlockedEmailDomains([]string{"*.mailinator.com"})
+	t.Cleanup(func() { domain.SetBlockedEmailDomains(nil) })
+	repo := domaintest.NewUserRepository()
+	srv := newTestServer(t, testConfig(), repo)
+
+	w := srv.do(http.MethodPost, "/api/v1/users", `{"name":"Alice","email":"alice@eu.mailinator.com","password":"Str0ng!pass","age":30}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400: %s", w.Code, w.Body.String())
+	}
+	if code := errorCode(t, w); code != response.CodeEmailDomainBlocked {
+		t.Errorf("code = %s, want EMAIL_DOMAIN_BLOCKED", code)
+	}
+	if n := repo.Calls("Create"); n != 0 {
+		t.Errorf("blocked user was created %d times", n)
+	}
+
+	w = srv.do(http.MethodPost, "/api/v1/users", `{"name":"Alice","email":"alice@mailinator.com","password":"Str0ng!pass","age":30}`)
+	if w.Code != http.StatusCreated {
+		t.Errorf("parent domain of a wildcard = %d, want 201: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWriteRoutesRequireJSON(t *testing.T) {
+	repo := domaintest.NewUserRepository(seedUsers(t, 1)...)
+	srv := newTestServer(t, testConfig(), repo)
+
+	w := srv.do(http.MethodPut, "/api/v1/users/1", `{"name":"Renamed","email":"usera@example.com","age":30}`, "Content-Type", "text/plain")
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415: %s", w.Code, w.Body.String())
+	}
+	if code := errorCode(t, w); code != response.CodeUnsupportedMediaType {
+		t.Errorf("code = %s, want UNSUPPORTED_MEDIA_TYPE", code)
+	}
+	if n := repo.Calls("Update"); n != 0 {
+		t.Errorf("rejected request reached the repository %d times", n)
+	}
+}
+
+func TestConfiguredWriteLimitIsStricterThanReads(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimitWriteRPS, cfg.RateLimitWriteBurst = 0.001, 1
+	srv := newTestServer(t, cfg, domaintest.NewUserRepository(seedUsers(t, 1)...))
+
+	update := `{"name":"Renamed","email":"usera@example.com","age":30}`
+	if w := srv.do(http.MethodPut, "/api/v1/users/1", update); w.Code != http.StatusOK {
+		t.Fatalf("first write = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if w := srv.do(http.MethodPut, "/api/v1/users/1", update); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second write = %d, want 429", w.Code)
+	}
+	for range 5 {
+		if w := srv.do(http.MethodGet, "/api/v1/users/1", ""); w.Code != http.StatusOK {
+			t.Fatalf("read after writes were limited = %d, want 200", w.Code)
+		}
+	}
+}
+
+func TestAgeZeroIsAValidAge(t *testing.T) {
+	repo := domaintest.NewUserRepository()
+	srv := newTestServer(t, testConfig(), repo)
+
+	w := srv.do(http.MethodPost, "/api/v1/users", `{"name":"Baby","email":"baby@example.com","password":"Str0ng!pass","age":0}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create with age 0 = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data struct {
+			ID  int64 `json:"id"`
+			Age *int  `json:"age"`
+		} `json:"data"`
+	}
+	decode(t, w, &created)
+	if created.Data.Age == nil || *created.Data.Age != 0 {
+		t.Errorf("created user age = %v, want 0 in the response", created.Data.Age)
+	}
+
+	path := "/api/v1/users/" + strconv.FormatInt(created.Data.ID, 10)
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"update to age 0", `{"name":"Baby","email":"baby@example.com","age":0}`, http.StatusOK},
+		{"update without age", `{"name":"Baby","email":"baby@example.com"}`, http.StatusBadRequest},
+		{"update with null age", `{"name":"Baby","email":"baby@example.com","age":null}`, http.StatusBadRequest},
+		{"update to a negative age", `{"name":"Baby","email":"baby@example.com","age":-1}`, http.StatusBadRequest},
+		{"update past the maximum", `{"name":"Baby","email":"baby@example.com","age":151}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := srv.do(http.MethodPut, path, tt.body); w.Code != tt.want {
+			t.Errorf("%s = %d, want %d: %s", tt.name, w.Code, tt.want, w.Body.String())
+		}
+	}
+
+	w = srv.do(http.MethodPost, "/api/v1/users", `{"name":"Nobody","email":"nobody@example.com","password":"Str0ng!pass"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("create without age = %d, want 400", w.Code)
+	}
+}
+
+func TestBatchRoutesRequireAPIKeyWhenConfigured(t *testing.T) {
+	cfg := testConfig()
+	cfg.APIKeys = []string{"service-key"}
+	repo := domaintest.NewUserRepository(seedUsers(t, 2)...)
+	srv := newTestServer(t, cfg, repo)
+
+	for _, key := range []string{"", "wrong-key"} {
+		w := srv.do(http.MethodDelete, "/api/v1/users", `{"ids":[1]}`, "X-API-Key", key)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("batch delete with key %q = %d, want 401", key, w.Code)
+		}
+	}
+	if n := repo.Calls("DeleteMany"); n != 0 {
+		t.Errorf("unauthenticated batch deleted users %d times", n)
+	}
+
+	if w := srv.do(http.MethodDelete, "/api/v1/users", `{"ids":[1]}`, "X-API-Key", "service-key"); w.Code != http.StatusOK {
+		t.Errorf("batch delete with a valid key = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if w := srv.do(http.MethodGet, "/api/v1/users/2", ""); w.Code != http.StatusOK {
+		t.Errorf("public route = %d without a key, want 200", w.Code)
+	}
+}
+
+func TestRecentUsersEndpoint(t *testing.T) {
+	srv := newTestServer(t, testConfig(), domaintest.NewUserRepository(seedUsers(t, 3)...))
+
+	w := srv.do(http.MethodGet, "/api/v1/users/recent?limit=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	decode(t, w, &body)
+	if len(body.Data) != 2 || body.Data[0]["id"] != 3.0 || body.Data[1]["id"] != 2.0 {
+		t.Errorf("recent users = %v, want users 3 and 2", body.Data)
+	}
+	if _, ok := body.Data[0]["password_hash"]; ok {
+		t.Error("recent users are not public users")
+	}
+
+	for _, limit := range []string{"0", "-1", "ten"} {
+		if w := srv.do(http.MethodGet, "/api/v1/users/recent?limit="+limit, ""); w.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s = %d, want 400", limit, w.Code)
+		}
+	}
+	if w := srv.do(http.MethodGet, "/api/v1/users/recent?limit=100000", ""); w.Code != http.StatusOK {
+		t.Errorf("limit above the cap = %d, want 200 capped", w.Code)
+	}
+}
+
+// testAdminToken is the admin token of servers built with adminConfig
+const testAdminToken = "admin-token"
+
+// adminConfig is testConfig with admin endpoints enabled under testAdminToken
+func adminConfig() *config.Config {
+	cfg := testConfig()
+	cfg.AdminToken = testAdminToken
+	return cfg
+}
+
+func TestBatchUpdateEndpoint(t *testing.T) {
+	repo := domaintest.NewUserRepository(seedUsers(t, 2)...)
+	srv := newTestServer(t, adminConfig(), repo)
+	admin := []string{"Authorization", "Bearer " + testAdminToken}
+	srv.redis.Set("user:v2:1", `{"id":1}`)
+	srv.redis.Set("user:v2:2", `{"id":2}`)
+
+	w := srv.do(http.MethodPatch, "/api/v1/users/batch", `{"ids":[1,2,99],"patch":{"name":"Renamed"},"mode":"best_effort"}`, admin...)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Data struct {
+			Committed bool `json:"committed"`
+			Results   []struct {
+				ID     int64  `json:"id"`
+				Status string `json:"status"`
+			} `json:"results"`
+		} `json:"data"`
+	}
+	decode(t, w, &body)
+	srv.async.Shutdown(context.Background())
+
+	got := make(map[int64]string)
+	for _, item := range body.Data.Results {
+		got[item.ID] = item.Status
+	}
+	want := map[int64]string{1: "updated", 2: "updated", 99: "not_found"}
+	if !maps.Equal(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	if !body.Data.Committed {
+		t.Error("best-effort batch was not committed")
+	}
+	if repo.User(1).Name != "Renamed" || repo.User(2).Name != "Renamed" {
+		t.Error("patch was not saved")
+	}
+	if keys := srv.redis.Keys("user:*"); len(keys) != 0 {
+		t.Errorf("cached users %v were not invalidated", keys)
+	}
+
+	for _, bad := range []string{`{"ids":[1],"patch":{}}`, `{"ids":[1],"patch":{"name":"X"},"mode":"some"}`, `{"patch":{"name":"X"}}`} {
+		if w := srv.do(http.MethodPatch, "/api/v1/users/batch", bad, admin...); w.Code != http.StatusBadRequest {
+			t.Errorf("batch update %s = %d, want 400", bad, w.Code)
+		}
+	}
+}
+
+func TestOverlongNameAndEmailAreBadRequests(t *testing.T) {
+	repo := domaintest.NewUserRepository(seedUsers(t, 1)...)
+	srv := newTestServer(t, testConfig(), repo)
+	longName := strings.Repeat("a", 256)
+	longEmail := strings.Repeat("a", 256-len("@example.com")) + "@example.com"
+
+	requests := []struct {
+		name, method, path, body string
+	}{
+		{"create with a long name", http.MethodPost, "/api/v1/users", `{"name":"` + longName + `","email":"new@example.com","password":"Str0ng!pass","age":30}`},
+		{"create with a long email", http.MethodPost, "/api/v1/users", `{"name":"New","email":"` + longEmail + `","password":"Str0ng!pass","age":30}`},
+		{"update with a long name", http.MethodPut, "/api/v1/users/1", `{"name":"` + longName + `","email":"usera@example.com","age":30}`},
+	}
+	for _, tt := range requests {
+		if w := srv.do(tt.method, tt.path, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400: %s", tt.name, w.Code, w.Body.String())
+		}
+	}
+	if n := repo.Calls("Create") + repo.Calls("Update"); n != 0 {
+		t.Errorf("overlong values reached the repository %d times", n)
+	}
+}
+
+func TestPrettyJSONConfig(t *testing.T) {
+	for _, pretty := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.PrettyJSON = pretty
+		srv := newTestServer(t, cfg, domaintest.NewUserRepository(seedUsers(t, 1)...))
+
+		for _, path := range []string{"/api/v1/users/1", "/api/v1/users/99"} {
+			w := srv.do(http.MethodGet, path, "")
+			if indented := strings.Contains(w.Body.String(), "\n  "); indented != pretty {
+				t.Errorf("PRETTY_JSON=%t: %s body indented = %t: %s", pretty, path, indented, w.Body.String())
+			}
+		}
+	}
+}
+
+func TestBatchUpdateRequiresAdmin(t *testing.T) {
+	suspend := `{"ids":[1,2],"patch":{"status":"suspended"}}`
+
+	tests := []struct {
+		name   string
+		cfg    *config.Config
+		header []string
+		want   int
+	}{
+		{"admin disabled", testConfig(), nil, http.StatusForbidden},
+		{"no token", adminConfig(), nil, http.StatusUnauthorized},
+		{"wrong token", adminConfig(), []string{"Authorization", "Bearer wrong"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := domaintest.NewUserRepository(seedUsers(t, 2)...)
+			srv := newTestServer(t, tt.cfg, repo)
+
+			if w := srv.do(http.MethodPatch, "/api/v1/users/batch", suspend, tt.header...); w.Code != tt.want {
+				t.Errorf("status patch = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+			if repo.User(1).Status != domain.UserStatusActive || repo.User(2).Status != domain.UserStatusActive {
+				t.Error("unauthenticated batch suspended users")
+			}
+		})
+	}
+
+	cfg := adminConfig()
+	cfg.APIKeys = []string{"service-key"}
+	srv := newTestServer(t, cfg, domaintest.NewUserRepository(seedUsers(t, 2)...))
+	if w := srv.do(http.MethodPatch, "/api/v1/users/batch", suspend, "X-API-Key", "service-key"); w.Code != http.StatusOK {
+		t.Errorf("status patch with an API key = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if srv.repo.User(1).Status != domain.UserStatusSuspended {
+		t.Error("authorized batch did not suspend users")
+	}
+}
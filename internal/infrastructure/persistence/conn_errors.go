@@ -0,0 +1,100 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"user-crud/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// isConnectionError reports whether err means the database connection was lost or could
+// not be made, as opposed to a failed statement. Cancellations and deadlines of the
+// caller's own context are not connection errors.
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exception; 57P01-57P03 are the server shutting down or starting up
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// classifyError marks connection errors with domain.ErrDatabaseUnavailable and returns
+// every other error unchanged
+func classifyError(err error) error {
+	if isConnectionError(err) {
+		return fmt.Errorf("%w: %w", domain.ErrDatabaseUnavailable, err)
+	}
+	return err
+}
+
+// classifyingQuerier wraps a Querier so connection errors surface as
+// domain.ErrDatabaseUnavailable wherever the repository reports them
+type classifyingQuerier struct {
+	Querier
+}
+
+func (q classifyingQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tag, err := q.Querier.Exec(ctx, sql, args...)
+	return tag, classifyError(err)
+}
+
+func (q classifyingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	rows, err := q.Querier.Query(ctx, sql, args...)
+	if err != nil {
+		return rows, classifyError(err)
+	}
+	return classifyingRows{rows}, nil
+}
+
+func (q classifyingQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return classifyingRow{q.Querier.QueryRow(ctx, sql, args...)}
+}
+
+func (q classifyingQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := q.Querier.Begin(ctx)
+	return tx, classifyError(err)
+}
+
+// classifyingRow classifies the error reported by Scan
+type classifyingRow struct {
+	pgx.Row
+}
+
+func (r classifyingRow) Scan(dest ...any) error {
+	return classifyError(r.Row.Scan(dest...))
+}
+
+// classifyingRows classifies errors reported while reading a result set
+type classifyingRows struct {
+	pgx.Rows
+}
+
+func (r classifyingRows) Scan(dest ...any) error {
+	return classifyError(r.Rows.Scan(dest...))
+}
+
+func (r classifyingRows) Err() error {
+	return classifyError(r.Rows.Err())
+}
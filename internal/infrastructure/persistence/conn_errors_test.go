@@ -0,0 +1,103 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"user-crud/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// connReset is the error a query gets when Postgres restarts under it
+var connReset = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"cannot connect now", &pgconn.PgError{Code: "57P03"}, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"query canceled", &pgconn.PgError{Code: "57014"}, false},
+		{"network error", connReset, true},
+		{"wrapped reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"refused", syscall.ECONNREFUSED, true},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true},
+		{"caller canceled", context.Canceled, false},
+		{"caller deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"other", errors.New("syntax error"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isConnectionError(tt.err); got != tt.want {
+			t.Errorf("%s: isConnectionError(%v) = %t, want %t", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRepositoryReportsConnectionLossAsUnavailable(t *testing.T) {
+	ctx := context.Background()
+	db := &recordingQuerier{err: connReset}
+	q := classifyingQuerier{db}
+	repo := &PostgresUserRepository{db: q, read: q}
+
+	_, getErr := repo.GetByID(ctx, 1)
+	_, listErr := repo.GetAllPaged(ctx, 10, 0)
+	deleteErr := repo.Delete(ctx, 1)
+	txErr := repo.WithinTransaction(ctx, func(domain.UserRepository) error { return nil })
+
+	for name, err := range map[string]error{"GetByID": getErr, "GetAllPaged": listErr, "Delete": deleteErr, "WithinTransaction": txErr} {
+		if !errors.Is(err, domain.ErrDatabaseUnavailable) {
+			t.Errorf("%s = %v, want ErrDatabaseUnavailable", name, err)
+		}
+		if !errors.Is(err, syscall.ECONNRESET) {
+			t.Errorf("%s = %v, want the cause kept", name, err)
+		}
+	}
+}
+
+func TestRepositoryKeepsStatementErrors(t *testing.T) {
+	db := &recordingQuerier{err: &pgconn.PgError{Code: "23505"}}
+	q := classifyingQuerier{db}
+	repo := &PostgresUserRepository{db: q, read: q}
+
+	if _, err := repo.GetByID(context.Background(), 1); errors.Is(err, domain.ErrDatabaseUnavailable) {
+		t.Errorf("statement error %v was reported as the database being unavailable", err)
+	}
+}
+
+// droppedTxQuerier begins transactions whose statements fail with connReset, like a
+// connection lost after BEGIN
+type droppedTxQuerier struct {
+	recordingQuerier
+}
+
+func (q *droppedTxQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &recordingTx{q: &recordingQuerier{err: connReset}}, nil
+}
+
+func TestOwnTransactionsReportConnectionLossAsUnavailable(t *testing.T) {
+	ctx := context.Background()
+	q := classifyingQuerier{&droppedTxQuerier{}}
+	repo := &PostgresUserRepository{db: q, read: q}
+
+	_, deleteErr := repo.DeleteMany(ctx, []int64{1, 2})
+	_, _, searchErr := repo.FuzzySearch(ctx, "alice", 0.3, 1, 10)
+
+	for name, err := range map[string]error{"DeleteMany": deleteErr, "FuzzySearch": searchErr} {
+		if !errors.Is(err, domain.ErrDatabaseUnavailable) {
+			t.Errorf("%s = %v, want ErrDatabaseUnavailable", name, err)
+		}
+	}
+}
@@ -4,54 +4,125 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"time"
 
+	"user-crud/internal/config"
+	"user-crud/internal/infrastructure/retry"
+	"user-crud/internal/infrastructure/tracing"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// NewPostgresPool creates a new PostgreSQL connection pool with retry logic
-func NewPostgresPool(host, port, user, password, dbname string) (*pgxpool.Pool, error) {
-	// Build connection string
-	connStr := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname,
-	)
+// maxConnectAttempts is the number of connection attempts made before giving up
+const maxConnectAttempts = 5
+
+// connectBaseDelay is the backoff before the second connection attempt; it doubles after each failure
+const connectBaseDelay = time.Second
+
+// ConnectError is returned by NewPool when the database is still unreachable after all retries
+type ConnectError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("failed to connect to database after %d attempts: %v", e.Attempts, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// BuildDSN builds the PostgreSQL connection URL from the configuration
+func BuildDSN(cfg *config.Config) (string, error) {
+	if err := cfg.ValidateSSLMode(); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("sslmode", cfg.DBSSLMode)
+	if cfg.DBSSLRootCert != "" {
+		params.Set("sslrootcert", cfg.DBSSLRootCert)
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.DBUser, cfg.DBPassword),
+		Host:     net.JoinHostPort(cfg.DBHost, cfg.DBPort),
+		Path:     "/" + cfg.DBName,
+		RawQuery: params.Encode(),
+	}
+
+	return dsn.String(), nil
+}
 
-	log.Printf("📡 Attempting database connection to %s:%s", host, port)
-	log.Printf("🔧 Database: %s, User: %s", dbname, user)
+// NewPool creates a new PostgreSQL connection pool with retry logic.
+// Retrying stops early when ctx is done, so callers can bound startup time.
+func NewPool(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	dsn, err := BuildDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse database config: %w", err)
+	}
 
-	// Retry logic dengan exponential backoff
-	maxRetries := 5
+	poolConfig.MaxConns = int32(cfg.DBMaxConns)
+	poolConfig.MinConns = int32(cfg.DBMinConns)
+	poolConfig.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolConfig.ConnConfig.Tracer = &queryTracer{slowThreshold: cfg.SlowQueryThreshold}
+
+	log.Printf("📡 Attempting database connection to %s:%s", cfg.DBHost, cfg.DBPort)
+	log.Printf("🔧 Database: %s, User: %s, SSL mode: %s", cfg.DBName, cfg.DBUser, cfg.DBSSLMode)
+	log.Printf("🔧 Pool settings: max_conns=%d min_conns=%d max_conn_lifetime=%v",
+		poolConfig.MaxConns, poolConfig.MinConns, poolConfig.MaxConnLifetime)
+
+	// Retry with jittered exponential backoff, bounded by ctx
 	var pool *pgxpool.Pool
-	var err error
-
-	for i := 0; i < maxRetries; i++ {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		
-		// Try to create connection pool
-		pool, err = pgxpool.New(ctx, connStr)
-		if err == nil {
-			// Test connection dengan ping
-			err = pool.Ping(ctx)
-			if err == nil {
-				cancel()
-				log.Printf("✅ Successfully connected to database at %s:%s", host, port)
-				return pool, nil
-			}
-		}
-		
-		cancel()
-		
-		waitTime := time.Duration((i+1)*2) * time.Second
-		log.Printf("❌ Failed to connect to database, retrying in %v... (attempt %d/%d)", 
-			waitTime, i+1, maxRetries)
-		
+	attempts := 0
+	err = retry.Do(ctx, maxConnectAttempts, connectBaseDelay, func(ctx context.Context) error {
+		attempts++
+		attemptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		p, err := pgxpool.NewWithConfig(attemptCtx, poolConfig)
 		if err != nil {
-			log.Printf("   Error: %v", err)
+			log.Printf("❌ Failed to connect to database (attempt %d/%d): %v", attempts, maxConnectAttempts, err)
+			return err
+		}
+
+		// Test connection dengan ping
+		if err := p.Ping(attemptCtx); err != nil {
+			p.Close()
+			log.Printf("❌ Failed to connect to database (attempt %d/%d): %v", attempts, maxConnectAttempts, err)
+			return err
 		}
-		
-		time.Sleep(waitTime)
+
+		pool = p
+		return nil
+	})
+	if err != nil {
+		return nil, &ConnectError{Attempts: attempts, Err: err}
 	}
 
-	return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", maxRetries, err)
-}
\ No newline at end of file
+	log.Printf("✅ Successfully connected to database at %s:%s", cfg.DBHost, cfg.DBPort)
+	return pool, nil
+}
+
+// PoolStats converts the pool's current statistics for metrics and health reporting
+func PoolStats(pool *pgxpool.Pool) tracing.DBPoolStats {
+	s := pool.Stat()
+	return tracing.DBPoolStats{
+		AcquiredConns:        s.AcquiredConns(),
+		IdleConns:            s.IdleConns(),
+		TotalConns:           s.TotalConns(),
+		MaxConns:             s.MaxConns(),
+		AcquireCount:         s.AcquireCount(),
+		EmptyAcquireCount:    s.EmptyAcquireCount(),
+		CanceledAcquireCount: s.CanceledAcquireCount(),
+	}
+}
@@ -0,0 +1,71 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"user-crud/internal/domain"
+	"user-crud/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDatabaseURLEnv names the PostgreSQL database the integration tests run against;
+// they are skipped when it is unset. Each test drops and recreates the public schema,
+// so never point it at a database holding real data.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+// testPool connects to the test database with an empty public schema
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := pool.Exec(ctx, `DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+		t.Fatalf("reset test database: %v", err)
+	}
+	return pool
+}
+
+// migratedPool returns a testPool with every migration applied
+func migratedPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	pool := testPool(t)
+	if err := Migrate(context.Background(), pool, migrations.FS); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return pool
+}
+
+// testUser returns an active user with the given email, ready to Create
+func testUser(email string) *domain.User {
+	return &domain.User{Name: "Test User", Email: email, PasswordHash: "hash", Age: 30, Status: domain.UserStatusActive}
+}
+
+// createUsers inserts n users named "User <i>" with the email user<i>@example.com
+func createUsers(t *testing.T, repo *PostgresUserRepository, n int) []*domain.User {
+	t.Helper()
+
+	users := make([]*domain.User, n)
+	for i := range users {
+		users[i] = testUser(fmt.Sprintf("user%d@example.com", i+1))
+		users[i].Name = fmt.Sprintf("User %d", i+1)
+		if err := repo.Create(context.Background(), users[i]); err != nil {
+			t.Fatalf("create user %d: %v", i+1, err)
+		}
+	}
+	return users
+}
@@ -0,0 +1,155 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID is the advisory lock key that serializes migrations across instances
+const migrationLockID = 7_245_001
+
+// optionalMarker on the first line of a migration lets startup continue when it fails,
+// e.g. for extensions the application user may lack privileges to create. A failed
+// optional migration is not recorded, so it is retried on the next start.
+const optionalMarker = "-- migrate:optional"
+
+// migration is a numbered SQL file from the migrations directory
+type migration struct {
+	version  int64
+	name     string
+	sql      string
+	optional bool
+}
+
+// Migrate applies the NNN_description.sql files in fsys that are not yet recorded in
+// schema_migrations, in version order, each in its own transaction
+func Migrate(ctx context.Context, db *pgxpool.Pool, fsys fs.FS) error {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	// Only one instance migrates at a time; the others wait and then find nothing pending
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	createTable := `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		)
+	`
+	if _, err := conn.Exec(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied := make(map[int64]bool)
+	rows, err := conn.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		err := func() error {
+			tx, err := conn.Begin(ctx)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback(ctx)
+
+			if _, err := tx.Exec(ctx, m.sql); err != nil {
+				return err
+			}
+			insert := `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+			if _, err := tx.Exec(ctx, insert, m.version, m.name); err != nil {
+				return err
+			}
+			return tx.Commit(ctx)
+		}()
+		if err != nil {
+			if m.optional {
+				log.Printf("Warning: optional migration %s failed, will retry on next start: %v", m.name, err)
+				continue
+			}
+			return fmt.Errorf("migration %s failed: %w", m.name, err)
+		}
+
+		log.Printf("Applied migration %s", m.name)
+	}
+
+	return nil
+}
+
+// loadMigrations reads the .sql files in fsys, sorted by their numeric prefix
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(files))
+	seen := make(map[int64]string, len(files))
+	for _, file := range files {
+		prefix, _, ok := strings.Cut(path.Base(file), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("migration %s must be named NNN_description.sql", file)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, file, version)
+		}
+		seen[version] = file
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		sql := string(content)
+
+		migrations = append(migrations, migration{
+			version:  version,
+			name:     strings.TrimSuffix(file, ".sql"),
+			sql:      sql,
+			optional: strings.HasPrefix(sql, optionalMarker),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
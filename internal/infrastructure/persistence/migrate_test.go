@@ -0,0 +1,86 @@
+package persistence
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"user-crud/migrations"
+)
+
+func TestLoadMigrationsSortsByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_later.sql":    {Data: []byte("SELECT 10")},
+		"002_second.sql":   {Data: []byte("SELECT 2")},
+		"001_first.sql":    {Data: []byte("SELECT 1")},
+		"003_optional.sql": {Data: []byte(optionalMarker + "\nCREATE EXTENSION x")},
+		"README.md":        {Data: []byte("not a migration")},
+	}
+
+	loaded, err := loadMigrations(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []int64
+	for _, m := range loaded {
+		versions = append(versions, m.version)
+	}
+	if len(versions) != 4 || versions[0] != 1 || versions[1] != 2 || versions[2] != 3 || versions[3] != 10 {
+		t.Errorf("versions = %v, want [1 2 3 10]", versions)
+	}
+	if loaded[0].name != "001_first" || loaded[0].optional || !loaded[2].optional {
+		t.Errorf("migrations = %+v, want names without .sql and only 003 optional", loaded)
+	}
+}
+
+func TestLoadMigrationsRejectsBadNames(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"no version":     {"create_users.sql": {}},
+		"no description": {"001.sql": {}},
+		"shared version": {"001_users.sql": {}, "01_outbox.sql": {}},
+	}
+
+	for name, fsys := range tests {
+		if _, err := loadMigrations(fsys); err == nil {
+			t.Errorf("%s: loadMigrations accepted the files", name)
+		}
+	}
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	loaded, err := loadMigrations(migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range loaded {
+		if m.version != int64(i+1) {
+			t.Fatalf("migration %s has version %d, want versions numbered from 1 without gaps", m.name, m.version)
+		}
+	}
+}
+
+func TestMigrateTwiceIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	pool := migratedPool(t)
+
+	var applied int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(ctx, pool, migrations.FS); err != nil {
+		t.Fatalf("second Migrate = %v", err)
+	}
+
+	var reapplied int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&reapplied); err != nil {
+		t.Fatal(err)
+	}
+	if reapplied != applied {
+		t.Errorf("schema_migrations has %d rows after migrating twice, want %d", reapplied, applied)
+	}
+	if ready, err := SchemaReady(ctx, pool); !ready || err != nil {
+		t.Errorf("schema not ready after migrating twice (%v)", err)
+	}
+}
@@ -0,0 +1,169 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"user-crud/internal/application/event"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// outboxBatchSize is the maximum number of events relayed per transaction
+const outboxBatchSize = 100
+
+// OutboxRelay publishes events stored in the outbox table and marks them sent.
+// Rows are only marked after a successful publish, so delivery is at-least-once.
+type OutboxRelay struct {
+	db        *pgxpool.Pool
+	publisher event.Publisher
+	interval  time.Duration
+	retention time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewOutboxRelay creates a relay that polls every interval and purges sent rows older than retention
+func NewOutboxRelay(db *pgxpool.Pool, publisher event.Publisher, interval, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		db:        db,
+		publisher: publisher,
+		interval:  interval,
+		retention: retention,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Start runs the relay loop in the background until Stop is called
+func (r *OutboxRelay) Start() {
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.relayPending(context.Background())
+			}
+		}
+	}()
+}
+
+// Stop signals the relay to exit and waits for the current batch to finish or ctx to be done
+func (r *OutboxRelay) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// relayPending relays batches until the outbox is drained or publishing fails, then purges old rows
+func (r *OutboxRelay) relayPending(ctx context.Context) {
+	for {
+		relayed, err := r.relayBatch(ctx)
+		if err != nil {
+			log.Printf("Outbox relay error: %v", err)
+			break
+		}
+		if relayed < outboxBatchSize {
+			break
+		}
+	}
+
+	query := `DELETE FROM outbox WHERE sent_at < $1`
+	if _, err := r.db.Exec(ctx, query, time.Now().Add(-r.retention)); err != nil {
+		log.Printf("Failed to purge outbox: %v", err)
+	}
+}
+
+// relayBatch publishes one batch of unsent events in id order. It stops at the first
+// publish failure so events are not reordered, and returns how many were sent.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// SKIP LOCKED lets several instances relay concurrently without sending a row twice
+	query := `
+		SELECT id, payload
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	type pendingEvent struct {
+		id      int64
+		payload []byte
+	}
+	var pending []pendingEvent
+	for rows.Next() {
+		var p pendingEvent
+		if err := rows.Scan(&p.id, &p.payload); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var sent []int64
+	var publishErr error
+	for _, p := range pending {
+		var e event.Event
+		if err := json.Unmarshal(p.payload, &e); err != nil {
+			// A malformed payload will never publish; mark it sent so it doesn't block the queue
+			log.Printf("Dropping malformed outbox event %d: %v", p.id, err)
+			sent = append(sent, p.id)
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, e); err != nil {
+			publishErr = err
+			update := `UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`
+			if _, err := tx.Exec(ctx, update, p.id, err.Error()); err != nil {
+				return 0, err
+			}
+			break
+		}
+		sent = append(sent, p.id)
+	}
+
+	if len(sent) > 0 {
+		update := `UPDATE outbox SET sent_at = NOW() WHERE id = ANY($1)`
+		if _, err := tx.Exec(ctx, update, sent); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(sent), publishErr
+}
@@ -0,0 +1,151 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/domain"
+)
+
+// flakyPublisher records published events and fails every publish while down
+type flakyPublisher struct {
+	mu        sync.Mutex
+	down      bool
+	published []event.Event
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, e event.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down {
+		return errors.New("publisher unavailable")
+	}
+	p.published = append(p.published, e)
+	return nil
+}
+
+func (p *flakyPublisher) setDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+}
+
+func (p *flakyPublisher) events() []event.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]event.Event(nil), p.published...)
+}
+
+// enqueue commits one outbox event per user id
+func enqueue(t *testing.T, repo *PostgresUserRepository, eventType string, userIDs ...int64) {
+	t.Helper()
+
+	err := repo.WithinTransaction(context.Background(), func(tx domain.UserRepository) error {
+		for _, id := range userIDs {
+			payload, _ := json.Marshal(event.New(eventType, id))
+			if err := tx.EnqueueEvent(context.Background(), eventType, id, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutboxEventsSurvivePublisherOutage(t *testing.T) {
+	ctx := context.Background()
+	pool := migratedPool(t)
+	repo := NewPostgresUserRepository(pool)
+	publisher := &flakyPublisher{down: true}
+	relay := NewOutboxRelay(pool, publisher, time.Hour, time.Hour)
+
+	enqueue(t, repo, event.UserCreated, 1, 2, 3)
+	relay.relayPending(ctx)
+
+	if n := len(publisher.events()); n != 0 {
+		t.Fatalf("%d events published during the outage", n)
+	}
+	var unsent, attempts int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*), COALESCE(SUM(attempts), 0) FROM outbox WHERE sent_at IS NULL`).Scan(&unsent, &attempts); err != nil {
+		t.Fatal(err)
+	}
+	if unsent != 3 || attempts != 1 {
+		t.Errorf("after the outage %d events are unsent with %d attempts, want 3 unsent and 1 attempt", unsent, attempts)
+	}
+
+	publisher.setDown(false)
+	relay.relayPending(ctx)
+
+	published := publisher.events()
+	if len(published) != 3 {
+		t.Fatalf("published %d events after recovery, want 3", len(published))
+	}
+	for i, e := range published {
+		if e.Type != event.UserCreated || e.UserID != int64(i+1) {
+			t.Errorf("event %d = %s for user %d, want user.created for user %d in order", i, e.Type, e.UserID, i+1)
+		}
+	}
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM outbox WHERE sent_at IS NULL`).Scan(&unsent); err != nil || unsent != 0 {
+		t.Errorf("%d events still unsent after recovery (%v)", unsent, err)
+	}
+
+	relay.relayPending(ctx)
+	if n := len(publisher.events()); n != 3 {
+		t.Errorf("sent events were published again: %d events", n)
+	}
+}
+
+func TestOutboxRelayDropsRolledBackEvents(t *testing.T) {
+	ctx := context.Background()
+	pool := migratedPool(t)
+	repo := NewPostgresUserRepository(pool)
+	publisher := &flakyPublisher{}
+
+	errAbort := errors.New("abort")
+	err := repo.WithinTransaction(ctx, func(tx domain.UserRepository) error {
+		payload, _ := json.Marshal(event.New(event.UserDeleted, 1))
+		if err := tx.EnqueueEvent(ctx, event.UserDeleted, 1, payload); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatal(err)
+	}
+
+	NewOutboxRelay(pool, publisher, time.Hour, time.Hour).relayPending(ctx)
+	if n := len(publisher.events()); n != 0 {
+		t.Errorf("published %d events from a rolled back transaction", n)
+	}
+}
+
+func TestOutboxRelayStartStop(t *testing.T) {
+	pool := migratedPool(t)
+	repo := NewPostgresUserRepository(pool)
+	publisher := &flakyPublisher{}
+	relay := NewOutboxRelay(pool, publisher, 10*time.Millisecond, time.Hour)
+
+	relay.Start()
+	enqueue(t, repo, event.UserUpdated, 7)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(publisher.events()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := relay.Stop(ctx); err != nil {
+		t.Fatalf("Stop = %v", err)
+	}
+	if published := publisher.events(); len(published) != 1 || published[0].UserID != 7 {
+		t.Errorf("relay published %v, want the one enqueued event", published)
+	}
+}
@@ -5,63 +5,152 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"user-crud/internal/domain"
+	"time"
+
 	"user-crud/internal/application/query"
+	"user-crud/internal/domain"
+	"user-crud/internal/infrastructure/tracing"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Querier is the subset of pgx shared by *pgxpool.Pool and pgx.Tx
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// userColumns is the column list scanned by scanUser, in order
+const userColumns = "id, name, username, email, password_hash, age, avatar_url, status, last_login_at, password_changed_at, created_at, updated_at"
+
+// notDeleted restricts a query to users that have not been soft-deleted, e.g. by a merge.
+// Soft-deleted rows keep their email and username, so those stay taken.
+const notDeleted = "deleted_at IS NULL"
+
+// scanUser scans a row selected with userColumns, followed by any extra destinations
+func scanUser(row pgx.Row, extra ...any) (*domain.User, error) {
+	var user domain.User
+	var username, avatarURL *string
+
+	dest := []any{
+		&user.ID,
+		&user.Name,
+		&username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Age,
+		&avatarURL,
+		&user.Status,
+		&user.LastLoginAt,
+		&user.PasswordChangedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	}
+
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if username != nil {
+		user.Username = *username
+	}
+	if avatarURL != nil {
+		user.AvatarURL = *avatarURL
+	}
+
+	return &user, nil
+}
+
+// nullIfEmpty maps an empty string to NULL for optional columns
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 type PostgresUserRepository struct {
-	db *pgxpool.Pool
+	db   Querier // primary; all writes go here
+	read Querier // replica for SELECTs; the primary when no replica is configured
 }
 
 func NewPostgresUserRepository(db *pgxpool.Pool) *PostgresUserRepository {
-	return &PostgresUserRepository{db: db}
+	return NewPostgresUserRepositoryRW(db, db)
+}
+
+// NewPostgresUserRepositoryRW creates a repository that sends SELECTs to read and
+// INSERT/UPDATE/DELETE statements to write. Reads made inside WithinTransaction use the
+// transaction, so they always see the primary.
+func NewPostgresUserRepositoryRW(write, read *pgxpool.Pool) *PostgresUserRepository {
+	return &PostgresUserRepository{db: classifyingQuerier{write}, read: classifyingQuerier{read}}
+}
+
+// WithinTransaction runs fn with a repository bound to a new transaction.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// When called on a repository that is already inside a transaction, a savepoint is used.
+func (r *PostgresUserRepository) WithinTransaction(ctx context.Context, fn func(repo domain.UserRepository) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	txq := classifyingQuerier{tx}
+	if err := fn(&PostgresUserRepository{db: txq, read: txq}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", classifyError(err))
+	}
+
+	return nil
 }
 
+// Create inserts the user and scans back the ID and the database-assigned timestamps
 func (r *PostgresUserRepository) Create(ctx context.Context, user *domain.User) error {
+	defer observeQuery(ctx, "Create", time.Now())
+
+	// Timestamps come from the database clock so ordering stays consistent across app servers
 	query := `
-		INSERT INTO users (name, email, password_hash, age, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id
+		INSERT INTO users (name, username, email, password_hash, age, avatar_url, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		RETURNING id, created_at, updated_at
 	`
 
 	err := r.db.QueryRow(
 		ctx,
 		query,
 		user.Name,
+		nullIfEmpty(user.Username),
 		user.Email,
 		user.PasswordHash,
 		user.Age,
-		user.CreatedAt,
-		user.UpdatedAt,
-	).Scan(&user.ID)
+		nullIfEmpty(user.AvatarURL),
+		user.Status,
+	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 
 	if err != nil {
-		return err
+		return uniqueViolationError(err)
 	}
 
 	return nil
 }
 
 func (r *PostgresUserRepository) GetByID(ctx context.Context, id int64) (*domain.User, error) {
+	defer observeQuery(ctx, "GetByID", time.Now())
+
 	query := `
-		SELECT id, name, email, password_hash, age, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
-		WHERE id = $1
+		WHERE id = $1 AND ` + notDeleted + `
 	`
 
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Age,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.read.QueryRow(ctx, query, id))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -70,26 +159,137 @@ func (r *PostgresUserRepository) GetByID(ctx context.Context, id int64) (*domain
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// GetByIDs loads all users with the given ids in a single round-trip
+func (r *PostgresUserRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*domain.User, error) {
+	defer observeQuery(ctx, "GetByIDs", time.Now())
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = ANY($1) AND ` + notDeleted + `
+	`
+
+	rows, err := r.read.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[int64]*domain.User, len(ids))
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users[user.ID] = user
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetRecentlyActive returns up to limit users ordered by their latest login or update
+func (r *PostgresUserRepository) GetRecentlyActive(ctx context.Context, limit int) ([]*domain.User, error) {
+	defer observeQuery(ctx, "GetRecentlyActive", time.Now())
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ` + notDeleted + `
+		ORDER BY GREATEST(last_login_at, updated_at) DESC, id DESC
+		LIMIT $1
+	`
+
+	rows, err := r.read.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0, limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetRecentlyCreated returns up to limit users, newest first; idx_users_created_at serves it
+func (r *PostgresUserRepository) GetRecentlyCreated(ctx context.Context, limit int) ([]*domain.User, error) {
+	defer observeQuery(ctx, "GetRecentlyCreated", time.Now())
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ` + notDeleted + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`
+
+	rows, err := r.read.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0, limit)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Exists reports whether a user with the given ID exists without loading the row
+func (r *PostgresUserRepository) Exists(ctx context.Context, id int64) (bool, error) {
+	defer observeQuery(ctx, "Exists", time.Now())
+
+	query := `SELECT 1 FROM users WHERE id = $1 AND ` + notDeleted
+
+	var one int
+	err := r.read.QueryRow(ctx, query, id).Scan(&one)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
 }
 
 func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	defer observeQuery(ctx, "GetByEmail", time.Now())
+
 	query := `
-		SELECT id, name, email, password_hash, age, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
-		WHERE email = $1
+		WHERE email = $1 AND ` + notDeleted + `
 	`
 
-	var user domain.User
-	err := r.db.QueryRow(ctx, query, email).Scan(
-		&user.ID,
-		&user.Name,
-		&user.Email,
-		&user.PasswordHash,
-		&user.Age,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	user, err := scanUser(r.read.QueryRow(ctx, query, email))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -98,17 +298,43 @@ func (r *PostgresUserRepository) GetByEmail(ctx context.Context, email string) (
 		return nil, err
 	}
 
-	return &user, nil
+	return user, nil
+}
+
+// GetByUsername looks a user up by normalized username
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
+	defer observeQuery(ctx, "GetByUsername", time.Now())
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE username = $1 AND ` + notDeleted + `
+	`
+
+	user, err := scanUser(r.read.QueryRow(ctx, query, domain.NormalizeUsername(username)))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return user, nil
 }
 
+// Deprecated: GetAll loads every user into memory; use GetAllPaged instead.
 func (r *PostgresUserRepository) GetAll(ctx context.Context) ([]*domain.User, error) {
+	defer observeQuery(ctx, "GetAll", time.Now())
+
 	query := `
-		SELECT id, name, email, password_hash, age, created_at, updated_at
+		SELECT ` + userColumns + `
 		FROM users
+		WHERE ` + notDeleted + `
 		ORDER BY id
 	`
 
-	rows, err := r.db.Query(ctx, query)
+	rows, err := r.read.Query(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -116,20 +342,11 @@ func (r *PostgresUserRepository) GetAll(ctx context.Context) ([]*domain.User, er
 
 	var users []*domain.User
 	for rows.Next() {
-		var user domain.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.PasswordHash,
-			&user.Age,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, err
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -139,24 +356,88 @@ func (r *PostgresUserRepository) GetAll(ctx context.Context) ([]*domain.User, er
 	return users, nil
 }
 
+// GetAllPaged returns at most limit users ordered by id, starting at offset
+func (r *PostgresUserRepository) GetAllPaged(ctx context.Context, limit, offset int) ([]*domain.User, error) {
+	defer observeQuery(ctx, "GetAllPaged", time.Now())
+
+	if limit < 1 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative, got %d", offset)
+	}
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE ` + notDeleted + `
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.read.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Update saves the user and scans back the database-assigned updated_at
 func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User) error {
+	defer observeQuery(ctx, "Update", time.Now())
+
 	query := `
 		UPDATE users
-		SET name = $1, email = $2, password_hash = $3, age = $4, updated_at = $5
-		WHERE id = $6
+		SET name = $1, username = $2, email = $3, password_hash = $4, age = $5, avatar_url = $6, status = $7, password_changed_at = $8, updated_at = NOW()
+		WHERE id = $9 AND ` + notDeleted + `
+		RETURNING updated_at
 	`
 
-	result, err := r.db.Exec(
+	err := r.db.QueryRow(
 		ctx,
 		query,
 		user.Name,
+		nullIfEmpty(user.Username),
 		user.Email,
 		user.PasswordHash,
 		user.Age,
-		user.UpdatedAt,
+		nullIfEmpty(user.AvatarURL),
+		user.Status,
+		user.PasswordChangedAt,
 		user.ID,
-	)
+	).Scan(&user.UpdatedAt)
 
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrUserNotFound
+		}
+		return uniqueViolationError(err)
+	}
+
+	return nil
+}
+
+func (r *PostgresUserRepository) Delete(ctx context.Context, id int64) error {
+	defer observeQuery(ctx, "Delete", time.Now())
+
+	query := `DELETE FROM users WHERE id = $1 AND ` + notDeleted
+
+	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -168,74 +449,374 @@ func (r *PostgresUserRepository) Update(ctx context.Context, user *domain.User)
 	return nil
 }
 
-func (r *PostgresUserRepository) Delete(ctx context.Context, id int64) error {
-	query := `DELETE FROM users WHERE id = $1`
+// GetActivity returns the account timestamps and status of a user in a single query
+func (r *PostgresUserRepository) GetActivity(ctx context.Context, id int64) (*domain.UserActivity, error) {
+	defer observeQuery(ctx, "GetActivity", time.Now())
+
+	query := `
+		SELECT id, status, created_at, updated_at, last_login_at, password_changed_at
+		FROM users
+		WHERE id = $1 AND ` + notDeleted + `
+	`
+
+	var activity domain.UserActivity
+	err := r.read.QueryRow(ctx, query, id).Scan(
+		&activity.UserID,
+		&activity.Status,
+		&activity.CreatedAt,
+		&activity.UpdatedAt,
+		&activity.LastLoginAt,
+		&activity.PasswordChangedAt,
+	)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42703" { // undefined_column
+		// Older schemas may lack the status/login columns; report what is available
+		query = `SELECT id, created_at, updated_at FROM users WHERE id = $1`
+		activity = domain.UserActivity{}
+		err = r.read.QueryRow(ctx, query, id).Scan(&activity.UserID, &activity.CreatedAt, &activity.UpdatedAt)
+	}
 
-	result, err := r.db.Exec(ctx, query, id)
 	if err != nil {
-		return err
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &activity, nil
+}
+
+// userStatsBuckets are the age ranges reported by GetStats; the last one is open-ended
+var userStatsBuckets = []struct {
+	label    string
+	min, max int
+}{
+	{"0-17", 0, 17},
+	{"18-24", 18, 24},
+	{"25-34", 25, 34},
+	{"35-44", 35, 44},
+	{"45-54", 45, 54},
+	{"55-64", 55, 64},
+	{"65+", 65, -1},
+}
+
+// GetStats aggregates the user count, average age, age distribution and daily signups over the last days
+func (r *PostgresUserRepository) GetStats(ctx context.Context, days int) (*domain.UserStats, error) {
+	defer observeQuery(ctx, "GetStats", time.Now())
+
+	stats := &domain.UserStats{Days: days}
+
+	query := `SELECT COUNT(*), COALESCE(AVG(age), 0) FROM users WHERE ` + notDeleted
+	if err := r.read.QueryRow(ctx, query).Scan(&stats.TotalUsers, &stats.AverageAge); err != nil {
+		return nil, err
+	}
+
+	// width_bucket returns how many of the bucket lower bounds the age has reached
+	var bounds []int
+	for _, b := range userStatsBuckets[1:] {
+		bounds = append(bounds, b.min)
+	}
+	query = `
+		SELECT width_bucket(age, $1::int[]) AS bucket, COUNT(*)
+		FROM users
+		WHERE ` + notDeleted + `
+		GROUP BY bucket
+	`
+	rows, err := r.read.Query(ctx, query, bounds)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int64, len(userStatsBuckets))
+	for rows.Next() {
+		var bucket int
+		var count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		counts[bucket] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats.AgeBuckets = make([]domain.AgeBucket, len(userStatsBuckets))
+	for i, b := range userStatsBuckets {
+		stats.AgeBuckets[i] = domain.AgeBucket{Label: b.label, Min: b.min, Count: counts[i]}
+		if b.max >= 0 {
+			max := b.max
+			stats.AgeBuckets[i].Max = &max
+		}
 	}
 
+	// One row per day in the window, including days without signups
+	query = `
+		SELECT day::date, COUNT(u.id)
+		FROM generate_series(CURRENT_DATE - ($1::int - 1), CURRENT_DATE, INTERVAL '1 day') AS day
+		LEFT JOIN users u ON u.created_at::date = day::date AND u.` + notDeleted + `
+		GROUP BY day
+		ORDER BY day
+	`
+	rows, err = r.read.Query(ctx, query, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats.SignupsPerDay = make([]domain.DailyCount, 0, days)
+	for rows.Next() {
+		var daily domain.DailyCount
+		if err := rows.Scan(&daily.Date, &daily.Count); err != nil {
+			return nil, err
+		}
+		stats.SignupsPerDay = append(stats.SignupsPerDay, daily)
+	}
+
+	return stats, rows.Err()
+}
+
+// TouchLastLogin records a successful login at the database's current time
+func (r *PostgresUserRepository) TouchLastLogin(ctx context.Context, id int64) (time.Time, error) {
+	defer observeQuery(ctx, "TouchLastLogin", time.Now())
+
+	query := `UPDATE users SET last_login_at = NOW() WHERE id = $1 AND ` + notDeleted + ` RETURNING last_login_at`
+
+	var lastLoginAt time.Time
+	err := r.db.QueryRow(ctx, query, id).Scan(&lastLoginAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, domain.ErrUserNotFound
+		}
+		return time.Time{}, err
+	}
+
+	return lastLoginAt, nil
+}
+
+// UpdatePasswordHash swaps the password hash without touching updated_at; the password
+// itself hasn't changed
+func (r *PostgresUserRepository) UpdatePasswordHash(ctx context.Context, id int64, oldHash, newHash string) (bool, error) {
+	defer observeQuery(ctx, "UpdatePasswordHash", time.Now())
+
+	query := `UPDATE users SET password_hash = $3 WHERE id = $1 AND password_hash = $2 AND ` + notDeleted
+
+	result, err := r.db.Exec(ctx, query, id, oldHash, newHash)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// AddPasswordHistory records a password change along with the hash it replaced
+func (r *PostgresUserRepository) AddPasswordHistory(ctx context.Context, userID int64, previousHash, sourceIP string) error {
+	defer observeQuery(ctx, "AddPasswordHistory", time.Now())
+
+	query := `INSERT INTO password_history (user_id, password_hash, source_ip) VALUES ($1, $2, NULLIF($3, ''))`
+
+	_, err := r.db.Exec(ctx, query, userID, previousHash, sourceIP)
+	return err
+}
+
+// GetRecentPasswordHashes returns the n most recently replaced password hashes of a user
+func (r *PostgresUserRepository) GetRecentPasswordHashes(ctx context.Context, userID int64, n int) ([]string, error) {
+	defer observeQuery(ctx, "GetRecentPasswordHashes", time.Now())
+
+	query := `
+		SELECT password_hash
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := r.read.Query(ctx, query, userID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// GetPasswordHistory returns a page of a user's password changes, newest first
+func (r *PostgresUserRepository) GetPasswordHistory(ctx context.Context, userID int64, page, limit int) ([]*domain.PasswordChange, int64, error) {
+	defer observeQuery(ctx, "GetPasswordHistory", time.Now())
+
+	offset := (page - 1) * limit
+
+	var total int64
+	err := r.read.QueryRow(ctx, `SELECT COUNT(*) FROM password_history WHERE user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT changed_at, COALESCE(source_ip, '')
+		FROM password_history
+		WHERE user_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.read.Query(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	changes := []*domain.PasswordChange{}
+	for rows.Next() {
+		var change domain.PasswordChange
+		if err := rows.Scan(&change.ChangedAt, &change.SourceIP); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, &change)
+	}
+
+	return changes, total, rows.Err()
+}
+
+// EnqueueEvent inserts an event into the outbox using the repository's connection or transaction
+func (r *PostgresUserRepository) EnqueueEvent(ctx context.Context, eventType string, userID int64, payload []byte) error {
+	defer observeQuery(ctx, "EnqueueEvent", time.Now())
+
+	query := `INSERT INTO outbox (event_type, user_id, payload) VALUES ($1, $2, $3)`
+
+	_, err := r.db.Exec(ctx, query, eventType, userID, payload)
+	return err
+}
+
+// DeleteMany deletes all users with the given ids in a single transaction
+// and returns the ids that were actually deleted
+func (r *PostgresUserRepository) DeleteMany(ctx context.Context, ids []int64) ([]int64, error) {
+	defer observeQuery(ctx, "DeleteMany", time.Now())
+
+	query := `DELETE FROM users WHERE id = ANY($1) AND ` + notDeleted + ` RETURNING id`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	txq := classifyingQuerier{tx}
+
+	rows, err := txq.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, classifyError(err)
+	}
+
+	return deleted, nil
+}
+
+// MergeUsers soft-deletes the duplicate and records the merge; run it inside
+// WithinTransaction. The duplicate's row and password history are kept. Tables holding
+// data that belongs to the person, rather than to the account's credentials, should be
+// reassigned to primaryID here.
+func (r *PostgresUserRepository) MergeUsers(ctx context.Context, primaryID, duplicateID int64) error {
+	defer observeQuery(ctx, "MergeUsers", time.Now())
+
+	query := `UPDATE users SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND ` + notDeleted
+	result, err := r.db.Exec(ctx, query, duplicateID)
+	if err != nil {
+		return err
+	}
 	if result.RowsAffected() == 0 {
 		return domain.ErrUserNotFound
 	}
 
-	return nil
+	_, err = r.db.Exec(ctx, `INSERT INTO user_merges (duplicate_id, primary_id) VALUES ($1, $2)`, duplicateID, primaryID)
+	return err
+}
+
+// GetMergedInto looks up the user the given id was merged into
+func (r *PostgresUserRepository) GetMergedInto(ctx context.Context, id int64) (int64, error) {
+	defer observeQuery(ctx, "GetMergedInto", time.Now())
+
+	var primaryID int64
+	err := r.read.QueryRow(ctx, `SELECT primary_id FROM user_merges WHERE duplicate_id = $1`, id).Scan(&primaryID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return primaryID, err
 }
 
 // Search searches users by name or email (ILIKE for case-insensitive)
-func (r *PostgresUserRepository) Search(ctx context.Context, keyword string, page, limit int) ([]*domain.User, int64, error) {
+func (r *PostgresUserRepository) Search(ctx context.Context, keyword string, page, limit int) ([]*domain.ScoredUser, int64, error) {
+	defer observeQuery(ctx, "Search", time.Now())
+
 	// Calculate offset
 	offset := (page - 1) * limit
 
-	// Search query
+	// Name matches rank above email matches, and prefix matches above substring matches
 	searchQuery := `
-		SELECT id, name, email, password_hash, age, created_at, updated_at
-		FROM users
-		WHERE name ILIKE $1 OR email ILIKE $1
-		ORDER BY id
-		LIMIT $2 OFFSET $3
+		SELECT ` + userColumns + `, relevance
+		FROM (
+			SELECT *, CASE
+				WHEN name ILIKE $2 ESCAPE '\' THEN 4
+				WHEN name ILIKE $1 ESCAPE '\' THEN 3
+				WHEN email ILIKE $2 ESCAPE '\' THEN 2
+				ELSE 1
+			END AS relevance
+			FROM users
+			WHERE (name ILIKE $1 ESCAPE '\' OR email ILIKE $1 ESCAPE '\') AND ` + notDeleted + `
+		) matches
+		ORDER BY relevance DESC, id
+		LIMIT $3 OFFSET $4
 	`
 
 	// Count query
 	countQuery := `
 		SELECT COUNT(*)
 		FROM users
-		WHERE name ILIKE $1 OR email ILIKE $1
+		WHERE (name ILIKE $1 ESCAPE '\' OR email ILIKE $1 ESCAPE '\') AND ` + notDeleted + `
 	`
 
-	searchPattern := "%" + keyword + "%"
+	searchPattern := "%" + escapeLike(keyword) + "%"
+	prefixPattern := escapeLike(keyword) + "%"
 
 	// Get total count
 	var total int64
-	err := r.db.QueryRow(ctx, countQuery, searchPattern).Scan(&total)
+	err := r.read.QueryRow(ctx, countQuery, searchPattern).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get users
-	rows, err := r.db.Query(ctx, searchQuery, searchPattern, limit, offset)
+	rows, err := r.read.Query(ctx, searchQuery, searchPattern, prefixPattern, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	var users []*domain.User
+	var users []*domain.ScoredUser
 	for rows.Next() {
-		var user domain.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.PasswordHash,
-			&user.Age,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		var relevance int
+		user, err := scanUser(rows, &relevance)
 		if err != nil {
 			return nil, 0, err
 		}
-		users = append(users, &user)
+		users = append(users, &domain.ScoredUser{User: user, Score: float64(relevance) / maxSearchRelevance})
 	}
 
 	if err = rows.Err(); err != nil {
@@ -245,44 +826,104 @@ func (r *PostgresUserRepository) Search(ctx context.Context, keyword string, pag
 	return users, total, nil
 }
 
-// FindWithFilters finds users with multiple filters
-func (r *PostgresUserRepository) FindWithFilters(ctx context.Context, filters interface{}) ([]*domain.User, int64, error) {
-	// Cast filters to ListUsersQuery
-	q, ok := filters.(query.ListUsersQuery)
-	if !ok {
-		return nil, 0, fmt.Errorf("invalid filter type")
+// maxSearchRelevance is the highest relevance Search assigns; scores are scaled to (0, 1]
+const maxSearchRelevance = 4
+
+// FuzzySearch ranks users by trigram similarity using pg_trgm
+func (r *PostgresUserRepository) FuzzySearch(ctx context.Context, keyword string, threshold float64, page, limit int) ([]*domain.ScoredUser, int64, error) {
+	defer observeQuery(ctx, "FuzzySearch", time.Now())
+
+	offset := (page - 1) * limit
+
+	// The % operator can use the trigram indexes; its threshold is set per transaction
+	// so it does not leak to other requests sharing the pooled connection
+	searchQuery := `
+		SELECT ` + userColumns + `,
+			GREATEST(similarity(name, $1), similarity(email, $1)) AS score
+		FROM users
+		WHERE (name % $1 OR email % $1) AND ` + notDeleted + `
+		ORDER BY score DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	countQuery := `
+		SELECT COUNT(*)
+		FROM users
+		WHERE (name % $1 OR email % $1) AND ` + notDeleted + `
+	`
+
+	tx, err := r.read.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
 	}
+	defer tx.Rollback(ctx)
+	txq := classifyingQuerier{tx}
 
-	// Build WHERE clause
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
+	if _, err := txq.Exec(ctx, `SELECT set_config('pg_trgm.similarity_threshold', $1, true)`, fmt.Sprintf("%g", threshold)); err != nil {
+		return nil, 0, fuzzySearchError(err)
+	}
 
-	// Search filter
-	if q.Search != "" {
-		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR email ILIKE $%d)", argIndex, argIndex))
-		args = append(args, "%"+q.Search+"%")
-		argIndex++
+	var total int64
+	if err := txq.QueryRow(ctx, countQuery, keyword).Scan(&total); err != nil {
+		return nil, 0, fuzzySearchError(err)
 	}
 
-	// Age min filter
-	if q.AgeMin > 0 {
-		conditions = append(conditions, fmt.Sprintf("age >= $%d", argIndex))
-		args = append(args, q.AgeMin)
-		argIndex++
+	rows, err := txq.Query(ctx, searchQuery, keyword, limit, offset)
+	if err != nil {
+		return nil, 0, fuzzySearchError(err)
 	}
+	defer rows.Close()
 
-	// Age max filter
-	if q.AgeMax > 0 {
-		conditions = append(conditions, fmt.Sprintf("age <= $%d", argIndex))
-		args = append(args, q.AgeMax)
-		argIndex++
+	var users []*domain.ScoredUser
+	for rows.Next() {
+		var score float64
+		user, err := scanUser(rows, &score)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, &domain.ScoredUser{User: user, Score: score})
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	if err = rows.Err(); err != nil {
+		return nil, 0, fuzzySearchError(err)
+	}
+
+	return users, total, nil
+}
+
+// uniqueViolationError maps unique constraint violations to domain errors
+func uniqueViolationError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		if strings.Contains(pgErr.ConstraintName, "username") {
+			return domain.ErrUsernameTaken
+		}
+		return domain.ErrUserAlreadyExists
+	}
+	return err
+}
+
+// fuzzySearchError maps a missing pg_trgm extension to domain.ErrFuzzySearchUnavailable
+func fuzzySearchError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "42883" { // undefined_function
+		return fmt.Errorf("%w: %s", domain.ErrFuzzySearchUnavailable, pgErr.Message)
 	}
+	return err
+}
+
+// FindWithFilters finds users with multiple filters
+func (r *PostgresUserRepository) FindWithFilters(ctx context.Context, filters interface{}) ([]*domain.User, int64, error) {
+	defer observeQuery(ctx, "FindWithFilters", time.Now())
+
+	// Cast filters to ListUsersQuery
+	q, ok := filters.(query.ListUsersQuery)
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid filter type")
+	}
+
+	conditions, args := filterConditions(q)
+	argIndex := len(args) + 1
 
 	// Validate sort field
 	validSortFields := map[string]bool{
@@ -291,6 +932,7 @@ func (r *PostgresUserRepository) FindWithFilters(ctx context.Context, filters in
 		"email":      true,
 		"age":        true,
 		"created_at": true,
+		"updated_at": true,
 	}
 	sortBy := q.SortBy
 	if !validSortFields[sortBy] {
@@ -306,32 +948,65 @@ func (r *PostgresUserRepository) FindWithFilters(ctx context.Context, filters in
 	// Build ORDER BY clause
 	orderClause := fmt.Sprintf("ORDER BY %s %s", sortBy, strings.ToUpper(order))
 
+	// Cursor pages continue after the cursor's row; id breaks ties so no row is skipped or repeated
+	if q.CursorMode {
+		if sortBy != "id" {
+			orderClause += fmt.Sprintf(", id %s", strings.ToUpper(order))
+		}
+		if q.After != nil {
+			comparison := ">"
+			if order == "desc" {
+				comparison = "<"
+			}
+			value, err := q.After.SortValue()
+			if err != nil {
+				return nil, 0, err
+			}
+			if value == nil {
+				conditions = append(conditions, fmt.Sprintf("id %s $%d", comparison, argIndex))
+				args = append(args, q.After.ID)
+				argIndex++
+			} else {
+				conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", sortBy, comparison, argIndex, argIndex+1))
+				args = append(args, value, q.After.ID)
+				argIndex += 2
+			}
+		}
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
 	// Calculate offset
 	offset := (q.Page - 1) * q.Limit
 
-	// Count query
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users %s", whereClause)
-
-	// Get total count
-	var total int64
-	err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total)
-	if err != nil {
-		return nil, 0, err
+	// Without a total, fetch one extra row so the caller can tell whether there is a next page
+	limit := q.Limit
+	total := int64(-1)
+	if q.SkipTotal {
+		limit++
+	} else {
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users %s", whereClause)
+		if err := r.read.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	// Main query with pagination
 	mainQuery := fmt.Sprintf(`
-		SELECT id, name, email, password_hash, age, created_at, updated_at
+		SELECT `+userColumns+`
 		FROM users
 		%s
 		%s
 		LIMIT $%d OFFSET $%d
 	`, whereClause, orderClause, argIndex, argIndex+1)
 
-	args = append(args, q.Limit, offset)
+	args = append(args, limit, offset)
 
 	// Get users
-	rows, err := r.db.Query(ctx, mainQuery, args...)
+	rows, err := r.read.Query(ctx, mainQuery, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -339,20 +1014,11 @@ func (r *PostgresUserRepository) FindWithFilters(ctx context.Context, filters in
 
 	var users []*domain.User
 	for rows.Next() {
-		var user domain.User
-		err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.PasswordHash,
-			&user.Age,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
+		user, err := scanUser(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		users = append(users, &user)
+		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -360,4 +1026,96 @@ func (r *PostgresUserRepository) FindWithFilters(ctx context.Context, filters in
 	}
 
 	return users, total, nil
-}
\ No newline at end of file
+}
+
+// filterConditions builds the WHERE conditions and their arguments shared by the list and
+// its count, numbering placeholders from $1
+func filterConditions(q query.ListUsersQuery) ([]string, []interface{}) {
+	conditions := []string{notDeleted}
+	var args []interface{}
+	argIndex := 1
+
+	// IDs filter
+	if len(q.IDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("id = ANY($%d)", argIndex))
+		args = append(args, q.IDs)
+		argIndex++
+	}
+
+	// Search filter
+	if q.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(`(name ILIKE $%d ESCAPE '\' OR email ILIKE $%d ESCAPE '\')`, argIndex, argIndex))
+		args = append(args, "%"+escapeLike(q.Search)+"%")
+		argIndex++
+	}
+
+	// Age min filter
+	if q.AgeMin > 0 {
+		conditions = append(conditions, fmt.Sprintf("age >= $%d", argIndex))
+		args = append(args, q.AgeMin)
+		argIndex++
+	}
+
+	// Age max filter
+	if q.AgeMax > 0 {
+		conditions = append(conditions, fmt.Sprintf("age <= $%d", argIndex))
+		args = append(args, q.AgeMax)
+		argIndex++
+	}
+
+	// Exact ages filter
+	if len(q.Ages) > 0 {
+		conditions = append(conditions, fmt.Sprintf("age = ANY($%d)", argIndex))
+		args = append(args, q.Ages)
+		argIndex++
+	}
+
+	// Inactive filter (never logged in counts as inactive)
+	if q.InactiveSince != nil {
+		conditions = append(conditions, fmt.Sprintf("(last_login_at IS NULL OR last_login_at < $%d)", argIndex))
+		args = append(args, *q.InactiveSince)
+		argIndex++
+	}
+
+	// Suspended users are hidden unless asked for; the count uses the same conditions
+	if !q.IncludeSuspended {
+		conditions = append(conditions, fmt.Sprintf("status <> $%d", argIndex))
+		args = append(args, domain.UserStatusSuspended)
+	}
+
+	return conditions, args
+}
+
+// CountWithFilters counts the users FindWithFilters would page through, ignoring paging
+// and cursors
+func (r *PostgresUserRepository) CountWithFilters(ctx context.Context, filters interface{}) (int64, error) {
+	defer observeQuery(ctx, "CountWithFilters", time.Now())
+
+	q, ok := filters.(query.ListUsersQuery)
+	if !ok {
+		return 0, fmt.Errorf("invalid filter type")
+	}
+
+	conditions, args := filterConditions(q)
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int64
+	err := r.read.QueryRow(ctx, fmt.Sprintf("SELECT COUNT(*) FROM users %s", whereClause), args...).Scan(&total)
+	return total, err
+}
+
+// observeQuery records the duration of a repository operation
+func observeQuery(ctx context.Context, operation string, start time.Time) {
+	tracing.RecordDBQueryDuration(ctx, time.Since(start), operation)
+}
+
+// likeEscaper escapes LIKE wildcards so keywords are matched literally (used with ESCAPE '\')
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes LIKE special characters in s
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,654 @@
+package persistence
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"user-crud/internal/application/query"
+	"user-crud/internal/domain"
+)
+
+func TestMergeUsersSoftDeletesDuplicate(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	if err := repo.MergeUsers(context.Background(), 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range db.recorded() {
+		if strings.Contains(s.sql, "DELETE") {
+			t.Errorf("merge deleted rows: %s", s.sql)
+		}
+	}
+	writes := db.writes()
+	if len(writes) != 2 || !strings.Contains(writes[0], "SET deleted_at = NOW()") || !strings.HasPrefix(writes[1], "INSERT INTO user_merges") {
+		t.Errorf("merge ran %q, want a soft delete followed by the merge record", writes)
+	}
+}
+
+func TestReadsSkipDeletedUsers(t *testing.T) {
+	ctx := context.Background()
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	repo.GetByID(ctx, 1)
+	repo.GetByIDs(ctx, []int64{1})
+	repo.GetByEmail(ctx, "a@example.com")
+	repo.GetByUsername(ctx, "alice")
+	repo.Exists(ctx, 1)
+	repo.GetAllPaged(ctx, 10, 0)
+	repo.GetRecentlyCreated(ctx, 10)
+	repo.GetRecentlyActive(ctx, 10)
+	repo.Search(ctx, "a", 1, 10)
+
+	for _, s := range db.recorded() {
+		if !strings.Contains(s.sql, notDeleted) {
+			t.Errorf("query does not skip deleted users: %s", s.sql)
+		}
+	}
+}
+
+func TestGetAllPagedRejectsInvalidBounds(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	tests := []struct {
+		name          string
+		limit, offset int
+	}{
+		{"zero limit", 0, 0},
+		{"negative limit", -1, 0},
+		{"negative offset", 10, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := repo.GetAllPaged(context.Background(), tt.limit, tt.offset); err == nil {
+				t.Error("GetAllPaged accepted invalid bounds")
+			}
+		})
+	}
+	if n := len(db.recorded()); n != 0 {
+		t.Errorf("invalid bounds still ran %d queries", n)
+	}
+}
+
+func TestGetAllPagedBoundsQuery(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	if _, err := repo.GetAllPaged(context.Background(), 25, 50); err != nil {
+		t.Fatal(err)
+	}
+
+	s := db.recorded()[0]
+	if !strings.Contains(s.sql, "LIMIT $1 OFFSET $2") || len(s.args) != 2 || s.args[0] != 25 || s.args[1] != 50 {
+		t.Errorf("query %q with args %v does not bound the page to limit 25 and offset 50", s.sql, s.args)
+	}
+}
+
+func TestGetAllPagedPages(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	createUsers(t, repo, 5)
+
+	var seen []int64
+	for offset := 0; offset < 6; offset += 2 {
+		users, err := repo.GetAllPaged(ctx, 2, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := min(2, 5-offset); len(users) != want {
+			t.Fatalf("page at offset %d has %d users, want %d", offset, len(users), want)
+		}
+		for _, u := range users {
+			seen = append(seen, u.ID)
+		}
+	}
+
+	for i := 1; i < len(seen); i++ {
+		if seen[i] <= seen[i-1] {
+			t.Errorf("pages are not in id order without overlap: %v", seen)
+		}
+	}
+
+	users, err := repo.GetAllPaged(ctx, 2, 10)
+	if err != nil || len(users) != 0 {
+		t.Errorf("page past the end = %d users, %v; want none", len(users), err)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{"alice", "alice"},
+		{"50%", `50\%`},
+		{"a_b", `a\_b`},
+		{`back\slash`, `back\\slash`},
+		{"%", `\%`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLike(tt.keyword); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.keyword, got, tt.want)
+		}
+	}
+}
+
+func TestSearchPatternsAreEscaped(t *testing.T) {
+	ctx := context.Background()
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	repo.Search(ctx, "50%", 1, 10)
+	conditions, args := filterConditions(query.ListUsersQuery{Search: "a_b"})
+
+	if s := db.recorded()[0]; !strings.Contains(s.sql, `ESCAPE '\'`) || s.args[0] != `%50\%%` {
+		t.Errorf("search ran %q with %v, want an escaped pattern", s.sql, s.args)
+	}
+	if !strings.Contains(strings.Join(conditions, " "), `ESCAPE '\'`) || args[0] != `%a\_b%` {
+		t.Errorf("filter conditions %q with %v, want an escaped pattern", conditions, args)
+	}
+}
+
+func TestSearchMatchesWildcardsLiterally(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 4)
+	for i, name := range []string{"50% Off", "500 Off", "a_b", "axb"} {
+		users[i].Name = name
+		if err := repo.Update(ctx, users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		keyword string
+		want    string
+	}{
+		{"50%", "50% Off"},
+		{"a_b", "a_b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.keyword, func(t *testing.T) {
+			found, total, err := repo.Search(ctx, tt.keyword, 1, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || len(found) != 1 || found[0].User.Name != tt.want {
+				t.Errorf("Search(%q) found %d users, want only %q", tt.keyword, total, tt.want)
+			}
+
+			listed, total, err := repo.FindWithFilters(ctx, query.ListUsersQuery{Search: tt.keyword, Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || len(listed) != 1 || listed[0].Name != tt.want {
+				t.Errorf("list search %q found %d users, want only %q", tt.keyword, total, tt.want)
+			}
+		})
+	}
+
+	if _, total, err := repo.Search(ctx, "%", 1, 10); err != nil || total != 0 {
+		t.Errorf("a lone %% matched %d users, %v; want none", total, err)
+	}
+}
+
+func TestWritesTakeTimestampsFromDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	repo.Create(ctx, testUser("a@example.com"))
+	repo.Update(ctx, &domain.User{ID: 1, Name: "A", Email: "a@example.com"})
+
+	statements := db.recorded()
+	if s := statements[0].sql; !strings.Contains(s, "NOW(), NOW()") || !strings.Contains(s, "RETURNING id, created_at, updated_at") {
+		t.Errorf("create does not stamp and return database timestamps: %s", s)
+	}
+	if s := statements[1].sql; !strings.Contains(s, "updated_at = NOW()") || !strings.Contains(s, "RETURNING updated_at") {
+		t.Errorf("update does not stamp and return the database timestamp: %s", s)
+	}
+}
+
+func TestWritesReturnDatabaseTimestamps(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+
+	stale := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	user := testUser("alice@example.com")
+	user.CreatedAt, user.UpdatedAt = stale, stale
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.CreatedAt.Equal(stored.CreatedAt) || !user.UpdatedAt.Equal(stored.UpdatedAt) || user.CreatedAt.Equal(stale) {
+		t.Errorf("create returned %v/%v, want the stored %v/%v", user.CreatedAt, user.UpdatedAt, stored.CreatedAt, stored.UpdatedAt)
+	}
+
+	created := user.CreatedAt
+	user.UpdatedAt = stale
+	if err := repo.Update(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err = repo.GetByID(ctx, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !user.UpdatedAt.Equal(stored.UpdatedAt) || user.UpdatedAt.Before(created) {
+		t.Errorf("update returned %v, want the stored %v", user.UpdatedAt, stored.UpdatedAt)
+	}
+	if !stored.CreatedAt.Equal(created) {
+		t.Errorf("update changed created_at from %v to %v", created, stored.CreatedAt)
+	}
+}
+
+func TestFilterConditionsCombineIDsWithOtherFilters(t *testing.T) {
+	conditions, args := filterConditions(query.ListUsersQuery{
+		IDs:    []int64{1, 2, 3},
+		Search: "al",
+		AgeMin: 18,
+		AgeMax: 65,
+	})
+
+	want := []string{
+		notDeleted,
+		"id = ANY($1)",
+		`(name ILIKE $2 ESCAPE '\' OR email ILIKE $2 ESCAPE '\')`,
+		"age >= $3",
+		"age <= $4",
+		"status <> $5",
+	}
+	if strings.Join(conditions, " AND ") != strings.Join(want, " AND ") {
+		t.Errorf("conditions = %q, want %q", conditions, want)
+	}
+	if len(args) != 5 || len(args[0].([]int64)) != 3 || args[1] != "%al%" || args[2] != 18 || args[3] != 65 {
+		t.Errorf("args = %v, want ids, pattern, bounds and status in placeholder order", args)
+	}
+}
+
+func TestFindWithFiltersCombinesIDsWithOtherFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 4)
+	for i, age := range []int{20, 40, 40, 40} {
+		users[i].Age = age
+		if err := repo.Update(ctx, users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, total, err := repo.FindWithFilters(ctx, query.ListUsersQuery{
+		IDs:    []int64{users[0].ID, users[1].ID, users[2].ID},
+		Search: "user",
+		AgeMin: 30,
+		SortBy: "id",
+		Order:  "asc",
+		Page:   1,
+		Limit:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 2 || len(found) != 2 || found[0].ID != users[1].ID || found[1].ID != users[2].ID {
+		t.Errorf("found %d users (total %d), want users 2 and 3: listed ids that also match the age filter", len(found), total)
+	}
+}
+
+func TestFindWithFiltersSkipTotalFetchesOneExtraRow(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	_, total, err := repo.FindWithFilters(context.Background(), query.ListUsersQuery{Page: 3, Limit: 10, SkipTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := db.recorded()
+	if len(statements) != 1 || strings.Contains(statements[0].sql, "COUNT(*)") {
+		t.Fatalf("ran %d statements, want only the page query without COUNT", len(statements))
+	}
+	args := statements[0].args
+	if limit, offset := args[len(args)-2], args[len(args)-1]; limit != 11 || offset != 20 {
+		t.Errorf("LIMIT %v OFFSET %v, want LIMIT 11 OFFSET 20", limit, offset)
+	}
+	if total != -1 {
+		t.Errorf("total = %d, want -1 when skipped", total)
+	}
+}
+
+func TestFilterConditionsHideSuspendedUnlessIncluded(t *testing.T) {
+	tests := []struct {
+		name   string
+		q      query.ListUsersQuery
+		hidden bool
+	}{
+		{"default", query.ListUsersQuery{}, true},
+		{"default with search", query.ListUsersQuery{Search: "al"}, true},
+		{"included", query.ListUsersQuery{IncludeSuspended: true}, false},
+		{"included with search", query.ListUsersQuery{Search: "al", IncludeSuspended: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, args := filterConditions(tt.q)
+			where := strings.Join(conditions, " AND ")
+
+			if !strings.Contains(where, notDeleted) {
+				t.Errorf("conditions %q do not skip deleted users", conditions)
+			}
+			if hidden := strings.Contains(where, "status <>"); hidden != tt.hidden {
+				t.Errorf("conditions %q hide suspended users: %t, want %t", conditions, hidden, tt.hidden)
+			}
+			if tt.hidden && args[len(args)-1] != domain.UserStatusSuspended {
+				t.Errorf("last arg = %v, want the suspended status", args[len(args)-1])
+			}
+		})
+	}
+}
+
+func TestListAndCountShareVisibility(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	// The recording querier finds no count row, so FindWithFilters stops after its count
+	repo.FindWithFilters(context.Background(), query.ListUsersQuery{Search: "al", Page: 1, Limit: 10})
+	repo.CountWithFilters(context.Background(), query.ListUsersQuery{Search: "al"})
+
+	statements := db.recorded()
+	if len(statements) != 2 {
+		t.Fatalf("ran %d statements, want two counts", len(statements))
+	}
+	if statements[0].sql != statements[1].sql {
+		t.Errorf("list total and count differ:\n%s\n%s", statements[0].sql, statements[1].sql)
+	}
+	if s := statements[1].sql; !strings.Contains(s, notDeleted) || !strings.Contains(s, "status <>") {
+		t.Errorf("count does not apply the default visibility: %s", s)
+	}
+}
+
+func TestFindWithFiltersExcludesSuspendedAndDeleted(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 4)
+	users[1].Status = domain.UserStatusSuspended
+	if err := repo.Update(ctx, users[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, users[2].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		q    query.ListUsersQuery
+		want []int64
+	}{
+		{"default", query.ListUsersQuery{}, []int64{users[0].ID, users[3].ID}},
+		{"include suspended", query.ListUsersQuery{IncludeSuspended: true}, []int64{users[0].ID, users[1].ID, users[3].ID}},
+		{"search a suspended user", query.ListUsersQuery{Search: "User 2"}, nil},
+		{"search a suspended user, included", query.ListUsersQuery{Search: "User 2", IncludeSuspended: true}, []int64{users[1].ID}},
+		{"search a deleted user, included", query.ListUsersQuery{Search: "User 3", IncludeSuspended: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.SortBy, tt.q.Order, tt.q.Page, tt.q.Limit = "id", "asc", 1, 10
+
+			found, total, err := repo.FindWithFilters(ctx, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int64
+			for _, u := range found {
+				ids = append(ids, u.ID)
+			}
+			if !slices.Equal(ids, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("listed %v (total %d), want %v", ids, total, tt.want)
+			}
+
+			count, err := repo.CountWithFilters(ctx, tt.q)
+			if err != nil || count != total {
+				t.Errorf("CountWithFilters = %d, %v; want the listed total %d", count, err, total)
+			}
+		})
+	}
+}
+
+func TestSearchRanksInOneQuery(t *testing.T) {
+	db := &recordingQuerier{counts: true}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	if _, _, err := repo.Search(context.Background(), "jo_", 2, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	statements := db.recorded()
+	if len(statements) != 2 {
+		t.Fatalf("ran %d statements, want a count and one ranked page query", len(statements))
+	}
+	s := statements[1]
+	if !strings.Contains(s.sql, "CASE") || !strings.Contains(s.sql, "ORDER BY relevance DESC, id") {
+		t.Errorf("page query does not rank by relevance: %s", s.sql)
+	}
+	if len(s.args) != 4 || s.args[0] != `%jo\_%` || s.args[1] != `jo\_%` || s.args[2] != 10 || s.args[3] != 10 {
+		t.Errorf("args = %v, want the substring and prefix patterns, limit and offset", s.args)
+	}
+}
+
+func TestSearchOrdersByRelevance(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 5)
+	for i, u := range []struct{ name, email string }{
+		{"Bob", "bob@john.example"},
+		{"Alice", "john.alice@example.com"},
+		{"Big John", "big@example.com"},
+		{"John Smith", "smith@example.com"},
+		{"Carol", "carol@example.com"},
+	} {
+		users[i].Name, users[i].Email = u.name, u.email
+		if err := repo.Update(ctx, users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, total, err := repo.Search(ctx, "JOHN", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name  string
+		score float64
+	}{
+		{"John Smith", 1},  // name prefix
+		{"Big John", 0.75}, // name substring
+		{"Alice", 0.5},     // email prefix
+		{"Bob", 0.25},      // email substring
+	}
+	if total != int64(len(want)) || len(found) != len(want) {
+		t.Fatalf("found %d users (total %d), want %d", len(found), total, len(want))
+	}
+	for i, w := range want {
+		if found[i].User.Name != w.name || found[i].Score != w.score {
+			t.Errorf("result %d = %s scored %v, want %s scored %v", i, found[i].User.Name, found[i].Score, w.name, w.score)
+		}
+	}
+
+	second, _, err := repo.Search(ctx, "john", 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 2 || second[0].User.Name != "Alice" || second[1].User.Name != "Bob" {
+		t.Errorf("second page does not continue the ranking")
+	}
+}
+
+func TestFindWithFiltersCursorContinuesAfterRow(t *testing.T) {
+	db := &recordingQuerier{}
+	repo := &PostgresUserRepository{db: db, read: db}
+
+	after := &query.Cursor{SortBy: "name", Order: "desc", Value: "Bob", ID: 7}
+	repo.FindWithFilters(context.Background(), query.ListUsersQuery{
+		CursorMode: true, After: after, SortBy: "name", Order: "desc", Page: 1, Limit: 10, SkipTotal: true,
+	})
+
+	statements := db.recorded()
+	if len(statements) != 1 {
+		t.Fatalf("ran %d statements, want only the page query", len(statements))
+	}
+	s := statements[0]
+	if !strings.Contains(s.sql, "(name, id) < ($2, $3)") || !strings.Contains(s.sql, "ORDER BY name DESC, id DESC") {
+		t.Errorf("page query does not continue after the cursor row: %s", s.sql)
+	}
+	if s.args[1] != "Bob" || s.args[2] != int64(7) || s.args[3] != 11 || s.args[4] != 0 {
+		t.Errorf("args = %v, want the cursor's name and id, one extra row and no offset", s.args)
+	}
+}
+
+func TestFindWithFiltersCursorWalk(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 5)
+	// Repeated names make the walk depend on the id tie-break
+	for i, name := range []string{"Bea", "Al", "Bea", "Al", "Cy"} {
+		users[i].Name = name
+		if err := repo.Update(ctx, users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, order := range []string{"asc", "desc"} {
+		t.Run(order, func(t *testing.T) {
+			var walked []int64
+			var after *query.Cursor
+			for pages := 0; pages < 5; pages++ {
+				found, _, err := repo.FindWithFilters(ctx, query.ListUsersQuery{
+					CursorMode: true, After: after, SortBy: "name", Order: order, Page: 1, Limit: 2, SkipTotal: true,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				more := len(found) > 2
+				found = found[:min(len(found), 2)]
+				for _, u := range found {
+					walked = append(walked, u.ID)
+				}
+				if !more {
+					break
+				}
+				last := found[len(found)-1]
+				after = &query.Cursor{SortBy: "name", Order: order, Value: last.Name, ID: last.ID}
+			}
+
+			// Al(2), Al(4), Bea(1), Bea(3), Cy(5)
+			want := []int64{users[1].ID, users[3].ID, users[0].ID, users[2].ID, users[4].ID}
+			if order == "desc" {
+				slices.Reverse(want)
+			}
+			if !slices.Equal(walked, want) {
+				t.Errorf("walk visited %v, want %v", walked, want)
+			}
+		})
+	}
+}
+
+func TestGetRecentlyCreatedNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 3)
+
+	recent, err := repo.GetRecentlyCreated(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recent) != 2 || recent[0].ID != users[2].ID || recent[1].ID != users[1].ID {
+		t.Errorf("recent users are not the two newest, newest first")
+	}
+}
+
+func TestFilterConditionsExactAges(t *testing.T) {
+	ages := []int{18, 21, 65}
+
+	tests := []struct {
+		name string
+		q    query.ListUsersQuery
+		want []string
+		args []interface{}
+	}{
+		{"alone", query.ListUsersQuery{Ages: ages}, []string{"age = ANY($1)"}, []interface{}{ages}},
+		{"with search", query.ListUsersQuery{Search: "al", Ages: ages}, []string{"ILIKE $1", "age = ANY($2)"}, []interface{}{"%al%", ages}},
+		{"with a range", query.ListUsersQuery{AgeMin: 20, Ages: ages}, []string{"age >= $1", "age = ANY($2)"}, []interface{}{20, ages}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, args := filterConditions(tt.q)
+			where := strings.Join(conditions, " AND ")
+
+			for _, want := range tt.want {
+				if !strings.Contains(where, want) {
+					t.Errorf("conditions %q lack %q", where, want)
+				}
+			}
+			if got := args[:len(tt.args)]; !reflect.DeepEqual(got, tt.args) {
+				t.Errorf("args = %v, want them to start with %v", args, tt.args)
+			}
+		})
+	}
+}
+
+func TestFindWithFiltersExactAges(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	users := createUsers(t, repo, 4)
+	for i, age := range []int{18, 21, 40, 21} {
+		users[i].Age = age
+		if err := repo.Update(ctx, users[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    query.ListUsersQuery
+		want []int64
+	}{
+		{"alone", query.ListUsersQuery{Ages: []int{18, 21}}, []int64{users[0].ID, users[1].ID, users[3].ID}},
+		{"no match", query.ListUsersQuery{Ages: []int{99}}, nil},
+		{"with search", query.ListUsersQuery{Ages: []int{21}, Search: "User 4"}, []int64{users[3].ID}},
+		{"with search matching another age", query.ListUsersQuery{Ages: []int{21}, Search: "User 3"}, nil},
+		{"with a range", query.ListUsersQuery{Ages: []int{18, 21, 40}, AgeMin: 20, AgeMax: 30}, []int64{users[1].ID, users[3].ID}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.SortBy, tt.q.Order, tt.q.Page, tt.q.Limit = "id", "asc", 1, 10
+
+			found, total, err := repo.FindWithFilters(ctx, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int64
+			for _, u := range found {
+				ids = append(ids, u.ID)
+			}
+			if !slices.Equal(ids, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("listed %v (total %d), want %v", ids, total, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// statement is one SQL statement seen by a recordingQuerier
+type statement struct {
+	sql  string
+	args []any
+}
+
+// recordingQuerier is a Querier that records every statement instead of running it.
+// Exec reports one affected row, QueryRow finds no rows and Query returns no rows,
+// unless err is set, in which case every call fails with it. With counts set, COUNT
+// queries find a count of zero instead of no row, so statements after them run too.
+type recordingQuerier struct {
+	mu         sync.Mutex
+	statements []statement
+	err        error
+	counts     bool
+}
+
+func (q *recordingQuerier) record(sql string, args []any) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.statements = append(q.statements, statement{sql: sql, args: args})
+}
+
+// recorded returns the recorded statements in order
+func (q *recordingQuerier) recorded() []statement {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]statement(nil), q.statements...)
+}
+
+// writes returns the recorded statements that modify data
+func (q *recordingQuerier) writes() []string {
+	var writes []string
+	for _, s := range q.recorded() {
+		verb := strings.ToUpper(strings.Fields(s.sql)[0])
+		if verb == "INSERT" || verb == "UPDATE" || verb == "DELETE" {
+			writes = append(writes, s.sql)
+		}
+	}
+	return writes
+}
+
+func (q *recordingQuerier) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	q.record(sql, args)
+	if q.err != nil {
+		return pgconn.CommandTag{}, q.err
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (q *recordingQuerier) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	q.record(sql, args)
+	if q.err != nil {
+		return nil, q.err
+	}
+	return &emptyRows{}, nil
+}
+
+func (q *recordingQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	q.record(sql, args)
+	if q.err != nil {
+		return errRow{q.err}
+	}
+	if q.counts && strings.Contains(sql, "COUNT(*)") {
+		return zeroCountRow{}
+	}
+	return errRow{pgx.ErrNoRows}
+}
+
+func (q *recordingQuerier) Begin(ctx context.Context) (pgx.Tx, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	return &recordingTx{q: q}, nil
+}
+
+// recordingTx runs its statements through the querier that began it
+type recordingTx struct {
+	pgx.Tx
+	q *recordingQuerier
+}
+
+func (tx *recordingTx) Begin(ctx context.Context) (pgx.Tx, error) { return tx.q.Begin(ctx) }
+
+// Commit is recorded as a COMMIT statement; a rollback leaves no trace
+func (tx *recordingTx) Commit(ctx context.Context) error {
+	tx.q.record("COMMIT", nil)
+	return nil
+}
+
+func (tx *recordingTx) Rollback(ctx context.Context) error { return nil }
+
+func (tx *recordingTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return tx.q.Exec(ctx, sql, args...)
+}
+
+func (tx *recordingTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return tx.q.Query(ctx, sql, args...)
+}
+
+func (tx *recordingTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return tx.q.QueryRow(ctx, sql, args...)
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(dest ...any) error { return r.err }
+
+// zeroCountRow is the result of a COUNT query over no rows
+type zeroCountRow struct{}
+
+func (zeroCountRow) Scan(dest ...any) error {
+	*dest[0].(*int64) = 0
+	return nil
+}
+
+// emptyRows is a result set without rows
+type emptyRows struct{ pgx.Rows }
+
+func (*emptyRows) Close()                        {}
+func (*emptyRows) Err() error                    { return nil }
+func (*emptyRows) Next() bool                    { return false }
+func (*emptyRows) CommandTag() pgconn.CommandTag { return pgconn.NewCommandTag("SELECT 0") }
+func (*emptyRows) FieldDescriptions() []pgconn.FieldDescription {
+	return nil
+}
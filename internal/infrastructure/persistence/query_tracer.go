@@ -0,0 +1,97 @@
+package persistence
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"user-crud/internal/infrastructure/tracing"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer creates a span for every query executed through the pool and logs queries
+// slower than slowThreshold (0 disables the log). Only the parameterized SQL is recorded,
+// never the query arguments, so PII stays out of traces and logs.
+type queryTracer struct {
+	slowThreshold time.Duration
+}
+
+type querySpanKey struct{}
+
+type querySpan struct {
+	span      trace.Span // nil when tracing is off
+	operation string
+	statement string
+	start     time.Time
+}
+
+// TraceQueryStart starts a child span of the caller's span
+func (t *queryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if tracing.GetTracer() == nil && t.slowThreshold <= 0 {
+		return ctx
+	}
+
+	qs := &querySpan{
+		operation: sqlOperation(data.SQL),
+		statement: strings.Join(strings.Fields(data.SQL), " "),
+		start:     time.Now(),
+	}
+
+	if tracing.GetTracer() != nil {
+		ctx, qs.span = tracing.StartSpan(ctx, "db."+strings.ToLower(qs.operation))
+		qs.span.SetAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", qs.operation),
+			attribute.String("db.statement", qs.statement),
+		)
+	}
+
+	return context.WithValue(ctx, querySpanKey{}, qs)
+}
+
+// TraceQueryEnd logs slow queries, records the row count, duration and error, then ends the span
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	qs, ok := ctx.Value(querySpanKey{}).(*querySpan)
+	if !ok {
+		return
+	}
+
+	elapsed := time.Since(qs.start)
+	slow := t.slowThreshold > 0 && elapsed > t.slowThreshold
+	if slow {
+		log.Printf("Slow query (%s): duration=%v threshold=%v sql=%q", qs.operation, elapsed, t.slowThreshold, qs.statement)
+	}
+
+	if qs.span == nil {
+		return
+	}
+
+	qs.span.SetAttributes(
+		attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()),
+		attribute.Float64("db.duration_ms", float64(elapsed.Microseconds())/1000),
+	)
+	if slow {
+		qs.span.SetAttributes(attribute.Bool("slow", true))
+	}
+
+	if data.Err != nil {
+		qs.span.RecordError(data.Err)
+		qs.span.SetStatus(codes.Error, data.Err.Error())
+	}
+
+	qs.span.End()
+}
+
+// sqlOperation returns the leading SQL keyword (SELECT, INSERT, ...)
+func sqlOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "UNKNOWN"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// traceQuery runs sql through tracer as if it took elapsed, returning what was logged
+func traceQuery(t *testing.T, tracer *queryTracer, sql string, elapsed time.Duration) string {
+	t.Helper()
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql})
+	if qs, ok := ctx.Value(querySpanKey{}).(*querySpan); ok {
+		qs.start = qs.start.Add(-elapsed)
+	}
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	return logs.String()
+}
+
+func TestSlowQueriesAreLogged(t *testing.T) {
+	tracer := &queryTracer{slowThreshold: 100 * time.Millisecond}
+
+	logged := traceQuery(t, tracer, "SELECT id\n\t\tFROM users WHERE id = $1", 200*time.Millisecond)
+	for _, want := range []string{"Slow query (SELECT)", "threshold=100ms", `sql="SELECT id FROM users WHERE id = $1"`} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("log %q does not contain %q", logged, want)
+		}
+	}
+
+	if logged := traceQuery(t, tracer, "SELECT 1", 0); logged != "" {
+		t.Errorf("fast query was logged: %q", logged)
+	}
+}
+
+func TestSlowQueryLogDisabled(t *testing.T) {
+	if logged := traceQuery(t, &queryTracer{}, "SELECT 1", time.Hour); logged != "" {
+		t.Errorf("disabled slow query log wrote %q", logged)
+	}
+}
@@ -0,0 +1,73 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"user-crud/internal/application/query"
+	"user-crud/internal/domain"
+)
+
+func TestWritesNeverHitTheReadPool(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := &recordingQuerier{}, &recordingQuerier{}
+	repo := &PostgresUserRepository{db: primary, read: replica}
+
+	repo.Create(ctx, testUser("a@example.com"))
+	repo.Update(ctx, &domain.User{ID: 1, Name: "A", Email: "a@example.com"})
+	repo.Delete(ctx, 1)
+	repo.DeleteMany(ctx, []int64{1, 2})
+	repo.TouchLastLogin(ctx, 1)
+	repo.UpdatePasswordHash(ctx, 1, "old", "new")
+	repo.AddPasswordHistory(ctx, 1, "old", "127.0.0.1")
+	repo.EnqueueEvent(ctx, "user.updated", 1, []byte("{}"))
+	repo.MergeUsers(ctx, 1, 2)
+
+	for _, s := range replica.recorded() {
+		t.Errorf("write sent to the read pool: %s", s.sql)
+	}
+	if len(primary.writes()) == 0 {
+		t.Fatal("writes did not reach the primary")
+	}
+}
+
+func TestReadsUseTheReadPool(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := &recordingQuerier{}, &recordingQuerier{}
+	repo := &PostgresUserRepository{db: primary, read: replica}
+
+	repo.GetByID(ctx, 1)
+	repo.GetByIDs(ctx, []int64{1})
+	repo.GetByEmail(ctx, "a@example.com")
+	repo.Search(ctx, "a", 1, 10)
+	repo.FindWithFilters(ctx, query.ListUsersQuery{Page: 1, Limit: 10})
+	repo.CountWithFilters(ctx, query.ListUsersQuery{})
+
+	if statements := primary.recorded(); len(statements) != 0 {
+		t.Errorf("reads sent %d statements to the primary, first: %s", len(statements), statements[0].sql)
+	}
+	for _, s := range replica.recorded() {
+		if verb := strings.ToUpper(strings.Fields(s.sql)[0]); verb != "SELECT" {
+			t.Errorf("read pool ran a %s statement: %s", verb, s.sql)
+		}
+	}
+}
+
+func TestTransactionReadsUseThePrimary(t *testing.T) {
+	ctx := context.Background()
+	primary, replica := &recordingQuerier{}, &recordingQuerier{}
+	repo := &PostgresUserRepository{db: primary, read: replica}
+
+	repo.WithinTransaction(ctx, func(tx domain.UserRepository) error {
+		tx.GetByID(ctx, 1)
+		return nil
+	})
+
+	if n := len(replica.recorded()); n != 0 {
+		t.Errorf("read inside a transaction ran %d statements on the read pool", n)
+	}
+	if n := len(primary.recorded()); n == 0 {
+		t.Error("read inside a transaction did not use the primary")
+	}
+}
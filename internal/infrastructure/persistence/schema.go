@@ -0,0 +1,40 @@
+package persistence
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// requiredTables must exist before the application can serve traffic
+var requiredTables = []string{"users", "outbox", "password_history", "user_merges"}
+
+// SchemaReady reports whether migrations have created every required table and
+// every users column the repository selects or filters on
+func SchemaReady(ctx context.Context, db *pgxpool.Pool) (bool, error) {
+	for _, table := range requiredTables {
+		var exists bool
+		if err := db.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+			return false, err
+		}
+		if !exists {
+			return false, nil
+		}
+	}
+
+	columns := append(strings.Split(userColumns, ", "), "deleted_at")
+
+	query := `
+		SELECT COUNT(*)
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = ANY($1)
+	`
+
+	var found int
+	if err := db.QueryRow(ctx, query, columns).Scan(&found); err != nil {
+		return false, err
+	}
+
+	return found == len(columns), nil
+}
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"user-crud/internal/domain"
+)
+
+func TestWithinTransactionCommitsOnlyOnSuccess(t *testing.T) {
+	tests := []struct {
+		name       string
+		fnErr      error
+		wantCommit bool
+	}{
+		{"success", nil, true},
+		{"failure", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &recordingQuerier{}
+			repo := &PostgresUserRepository{db: db, read: db}
+
+			err := repo.WithinTransaction(context.Background(), func(tx domain.UserRepository) error {
+				tx.Delete(context.Background(), 1)
+				return tt.fnErr
+			})
+
+			if !errors.Is(err, tt.fnErr) {
+				t.Errorf("err = %v, want %v", err, tt.fnErr)
+			}
+			committed := false
+			for _, s := range db.recorded() {
+				committed = committed || s.sql == "COMMIT"
+			}
+			if committed != tt.wantCommit {
+				t.Errorf("committed = %v, want %v", committed, tt.wantCommit)
+			}
+		})
+	}
+}
+
+func TestWithinTransactionRollbackDiscardsWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+	errAbort := errors.New("abort")
+
+	err := repo.WithinTransaction(ctx, func(tx domain.UserRepository) error {
+		if err := tx.Create(ctx, testUser("rollback@example.com")); err != nil {
+			return err
+		}
+		if _, err := tx.GetByEmail(ctx, "rollback@example.com"); err != nil {
+			t.Errorf("write is not visible inside its transaction: %v", err)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("err = %v, want the callback's error", err)
+	}
+
+	if _, err := repo.GetByEmail(ctx, "rollback@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Errorf("user created in a rolled back transaction: err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestWithinTransactionCommitKeepsWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPostgresUserRepository(migratedPool(t))
+
+	err := repo.WithinTransaction(ctx, func(tx domain.UserRepository) error {
+		return tx.Create(ctx, testUser("commit@example.com"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.GetByEmail(ctx, "commit@example.com"); err != nil {
+		t.Errorf("committed user not found: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// maxDelay caps the wait between attempts
+const maxDelay = 30 * time.Second
+
+// Do calls fn until it succeeds, maxAttempts is reached or ctx is done.
+// The wait after attempt n is baseDelay*2^(n-1), capped at 30s, with the upper
+// half randomized so that several instances don't retry in lockstep.
+// It returns nil on success, otherwise the last error from fn (joined with the
+// context error if ctx ended first).
+func Do(ctx context.Context, maxAttempts int, baseDelay time.Duration, fn func(ctx context.Context) error) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return errors.Join(ctxErr, err)
+		}
+
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+
+		if attempt == maxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(Backoff(attempt, baseDelay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
+
+// Backoff returns the jittered delay to wait after the given attempt (starting at 1)
+func Backoff(attempt int, baseDelay time.Duration) time.Duration {
+	delay := baseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+// failTimes returns a fn that fails n times before succeeding, counting its calls
+func failTimes(n int, calls *int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if *calls++; *calls <= n {
+			return errTransient
+		}
+		return nil
+	}
+}
+
+func TestDoSucceedsOnNthAttempt(t *testing.T) {
+	calls := 0
+
+	if err := Do(context.Background(), 5, time.Millisecond, failTimes(2, &calls)); err != nil {
+		t.Fatalf("Do = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Errorf("fn called %d times, want 3", calls)
+	}
+}
+
+func TestDoReturnsLastErrorWhenExhausted(t *testing.T) {
+	calls := 0
+
+	err := Do(context.Background(), 4, time.Millisecond, failTimes(10, &calls))
+
+	if !errors.Is(err, errTransient) {
+		t.Errorf("Do = %v, want the last error from fn", err)
+	}
+	if calls != 4 {
+		t.Errorf("fn called %d times, want maxAttempts", calls)
+	}
+}
+
+func TestDoTriesAtLeastOnce(t *testing.T) {
+	calls := 0
+
+	if err := Do(context.Background(), 0, time.Millisecond, failTimes(0, &calls)); err != nil || calls != 1 {
+		t.Errorf("Do with no attempts = %v after %d calls, want one successful call", err, calls)
+	}
+}
+
+func TestDoStopsWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	calls := 0
+
+	start := time.Now()
+	err := Do(ctx, 10, time.Second, failTimes(10, &calls))
+
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errTransient) {
+		t.Errorf("Do = %v, want the deadline joined with the last error", err)
+	}
+	if calls != 1 {
+		t.Errorf("fn called %d times, want the backoff wait to be cut short", calls)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Do returned after %v, want it bounded by the context", elapsed)
+	}
+}
+
+func TestDoWithCanceledContextDoesNotCall(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+
+	if err := Do(ctx, 3, time.Millisecond, failTimes(0, &calls)); !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Errorf("Do = %v after %d calls, want context.Canceled without calling fn", err, calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{1, base},
+		{2, 2 * base},
+		{3, 4 * base},
+		{20, maxDelay},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if d := Backoff(tt.attempt, base); d < tt.full/2 || d > tt.full {
+				t.Fatalf("Backoff(%d) = %v, want within [%v, %v]", tt.attempt, d, tt.full/2, tt.full)
+			}
+		}
+	}
+
+	if d := Backoff(1, 0); d != 0 {
+		t.Errorf("Backoff with no base delay = %v, want 0", d)
+	}
+}
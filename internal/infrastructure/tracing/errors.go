@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// exportErrors counts OpenTelemetry errors, such as failed exports to an unreachable
+// collector, between periodic summaries
+type exportErrors struct {
+	mu    sync.Mutex
+	count int
+	last  error
+}
+
+// Handle implements otel.ErrorHandler
+func (e *exportErrors) Handle(err error) {
+	e.mu.Lock()
+	e.count++
+	e.last = err
+	e.mu.Unlock()
+}
+
+// flush logs and resets the count if any errors happened since the last call
+func (e *exportErrors) flush(interval time.Duration) {
+	e.mu.Lock()
+	count, last := e.count, e.last
+	e.count, e.last = 0, nil
+	e.mu.Unlock()
+
+	if count > 0 {
+		log.Printf("Warning: %d telemetry export errors in the last %v, last: %v", count, interval, last)
+	}
+}
+
+// ReportExportErrors replaces the OpenTelemetry error handler, which logs every failed
+// export, with one that logs a summary every interval. The returned function stops the
+// reporter after logging any remaining errors.
+func ReportExportErrors(interval time.Duration) (stop func()) {
+	errs := &exportErrors{}
+	otel.SetErrorHandler(errs)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				errs.flush(interval)
+			case <-done:
+				errs.flush(interval)
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package tracing
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+var (
+	meter           metric.Meter
+	requestDuration metric.Float64Histogram
+	dbQueryDuration metric.Float64Histogram
+	rateLimitCount  metric.Int64Counter
+)
+
+// InitMeter initializes the OTLP metrics pipeline
+func InitMeter(serviceName, otlpEndpoint string) (func(context.Context) error, error) {
+	// Create OTLP exporter
+	exp, err := otlpmetrichttp.New(context.Background(),
+		otlpmetrichttp.WithEndpointURL(signalURL(otlpEndpoint, "/v1/metrics")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create meter provider
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)),
+		sdkmetric.WithResource(newResource(serviceName)),
+	)
+
+	// Set global meter provider
+	otel.SetMeterProvider(mp)
+
+	meter = mp.Meter(serviceName)
+
+	requestDuration, err = meter.Float64Histogram(
+		"http.server.request.duration",
+		metric.WithDescription("Duration of HTTP server requests"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dbQueryDuration, err = meter.Float64Histogram(
+		"db.client.operation.duration",
+		metric.WithDescription("Duration of database queries"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rateLimitCount, err = meter.Int64Counter(
+		"http.server.rate_limit.decisions",
+		metric.WithDescription("Rate limiter decisions per request"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("OTLP metrics initialized: %s", otlpEndpoint)
+
+	// Return shutdown function
+	return mp.Shutdown, nil
+}
+
+// RecordRequestDuration records the duration of an HTTP request
+func RecordRequestDuration(ctx context.Context, duration time.Duration, method, route string, statusCode int) {
+	if requestDuration == nil {
+		return
+	}
+	requestDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("http.method", method),
+		attribute.String("http.route", route),
+		attribute.Int("http.status_code", statusCode),
+	))
+}
+
+// RecordDBQueryDuration records the duration of a database query
+func RecordDBQueryDuration(ctx context.Context, duration time.Duration, operation string) {
+	if dbQueryDuration == nil {
+		return
+	}
+	dbQueryDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("db.operation", operation),
+	))
+}
+
+// RecordRateLimitDecision counts a rate limiter decision
+func RecordRateLimitDecision(ctx context.Context, mode string, limited bool) {
+	if rateLimitCount == nil {
+		return
+	}
+	rateLimitCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("rate_limit.mode", mode),
+		attribute.Bool("rate_limit.limited", limited),
+	))
+}
+
+// DBPoolStats is a snapshot of database connection pool usage
+type DBPoolStats struct {
+	AcquiredConns        int32 `json:"acquired_conns"`
+	IdleConns            int32 `json:"idle_conns"`
+	TotalConns           int32 `json:"total_conns"`
+	MaxConns             int32 `json:"max_conns"`
+	AcquireCount         int64 `json:"acquire_count"`
+	EmptyAcquireCount    int64 `json:"empty_acquire_count"`
+	CanceledAcquireCount int64 `json:"canceled_acquire_count"`
+}
+
+// RegisterDBPoolMetrics exports pool saturation gauges, sampling stats each time metrics are
+// collected. EmptyAcquireCount counts acquires that had to wait for a free connection.
+func RegisterDBPoolMetrics(stats func() DBPoolStats) error {
+	if meter == nil {
+		return nil
+	}
+
+	connections, err := meter.Int64ObservableGauge(
+		"db.client.connection.count",
+		metric.WithDescription("Connections in the pool by state (used or idle)"),
+	)
+	if err != nil {
+		return err
+	}
+	maxConnections, err := meter.Int64ObservableGauge(
+		"db.client.connection.max",
+		metric.WithDescription("Maximum connections allowed in the pool"),
+	)
+	if err != nil {
+		return err
+	}
+	acquires, err := meter.Int64ObservableCounter(
+		"db.client.connection.acquires",
+		metric.WithDescription("Connection acquires by outcome (immediate, waited or canceled)"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		s := stats()
+		o.ObserveInt64(connections, int64(s.AcquiredConns), metric.WithAttributes(attribute.String("state", "used")))
+		o.ObserveInt64(connections, int64(s.IdleConns), metric.WithAttributes(attribute.String("state", "idle")))
+		o.ObserveInt64(maxConnections, int64(s.MaxConns))
+		o.ObserveInt64(acquires, s.AcquireCount-s.EmptyAcquireCount, metric.WithAttributes(attribute.String("outcome", "immediate")))
+		o.ObserveInt64(acquires, s.EmptyAcquireCount, metric.WithAttributes(attribute.String("outcome", "waited")))
+		o.ObserveInt64(acquires, s.CanceledAcquireCount, metric.WithAttributes(attribute.String("outcome", "canceled")))
+		return nil
+	}, connections, maxConnections, acquires)
+	return err
+}
@@ -3,9 +3,13 @@ package tracing
 import (
 	"context"
 	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
@@ -14,21 +18,29 @@ import (
 
 var tracer trace.Tracer
 
-// InitTracer initializes Jaeger tracing
-func InitTracer(serviceName, jaegerEndpoint string) (func(context.Context) error, error) {
-	// Create Jaeger exporter
-	exp, err := jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(jaegerEndpoint)))
+// exportTimeout bounds each export so an unreachable collector can't stall the batcher
+const exportTimeout = 10 * time.Second
+
+// InitTracer initializes OTLP tracing. Spans are exported in batches from a queue of at
+// most queueSize spans; when the collector is slow or unreachable the queue fills and new
+// spans are dropped rather than blocking request handling.
+func InitTracer(serviceName, otlpEndpoint string, queueSize int) (func(context.Context) error, error) {
+	// Create OTLP exporter
+	exp, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(signalURL(otlpEndpoint, "/v1/traces")),
+	)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create trace provider
 	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp),
-		sdktrace.WithResource(resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(serviceName),
-		)),
+		sdktrace.WithBatcher(exp,
+			sdktrace.WithMaxQueueSize(queueSize),
+			sdktrace.WithMaxExportBatchSize(min(queueSize, sdktrace.DefaultMaxExportBatchSize)),
+			sdktrace.WithExportTimeout(exportTimeout),
+		),
+		sdktrace.WithResource(newResource(serviceName)),
 	)
 
 	// Set global trace provider
@@ -37,22 +49,56 @@ func InitTracer(serviceName, jaegerEndpoint string) (func(context.Context) error
 	// Get tracer
 	tracer = tp.Tracer(serviceName)
 
-	log.Printf("Jaeger tracing initialized: %s", jaegerEndpoint)
+	log.Printf("OTLP tracing initialized: %s", otlpEndpoint)
 
 	// Return shutdown function
 	return tp.Shutdown, nil
 }
 
-// GetTracer returns the global tracer
+// newResource describes this service for traces and metrics
+func newResource(serviceName string) *resource.Resource {
+	return resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(getEnv("SERVICE_VERSION", "2.0")),
+		semconv.DeploymentEnvironment(getEnv("DEPLOYMENT_ENVIRONMENT", "development")),
+	)
+}
+
+// signalURL appends the default OTLP signal path when the endpoint is a bare collector address
+func signalURL(endpoint, signalPath string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || strings.Trim(u.Path, "/") != "" {
+		return endpoint
+	}
+	u.Path = signalPath
+	return u.String()
+}
+
+// getEnv gets environment variable with default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetTracer returns the global tracer, or nil when tracing is not initialized
 func GetTracer() trace.Tracer {
 	return tracer
 }
 
-// StartSpan starts a new span
+// IsEnabled reports whether InitTracer succeeded. When it is false spans are no-ops, so
+// callers can skip work that only feeds span attributes.
+func IsEnabled() bool {
+	return tracer != nil
+}
+
+// StartSpan starts a new span, or returns ctx and its current span unchanged when
+// tracing is not enabled
 func StartSpan(ctx context.Context, spanName string) (context.Context, trace.Span) {
-	if tracer == nil {
-		// Return no-op span if tracer not initialized
+	if !IsEnabled() {
 		return ctx, trace.SpanFromContext(ctx)
 	}
 	return tracer.Start(ctx, spanName)
-}
\ No newline at end of file
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpanWithTracingDisabled(t *testing.T) {
+	if IsEnabled() {
+		t.Fatal("IsEnabled before InitTracer = true")
+	}
+	ctx := context.Background()
+
+	got, span := StartSpan(ctx, "disabled")
+	span.End()
+
+	if got != ctx {
+		t.Error("StartSpan replaced the context with tracing disabled")
+	}
+	if span.IsRecording() || span.SpanContext().IsValid() {
+		t.Error("StartSpan started a span with tracing disabled")
+	}
+}
+
+func TestStartSpanWithTracingDisabledKeepsTheCurrentSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	ctx, parent := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test").Start(context.Background(), "request")
+
+	got, span := StartSpan(ctx, "disabled")
+	parent.End()
+
+	if trace.SpanFromContext(got) != parent || span != parent {
+		t.Error("StartSpan did not hand back the caller's span")
+	}
+	if spans := recorder.Ended(); len(spans) != 1 {
+		t.Errorf("recorded %d spans, want only the caller's", len(spans))
+	}
+}
@@ -0,0 +1,149 @@
+// Package webhook delivers user events to an integrator's HTTP endpoint.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"user-crud/internal/application/event"
+	"user-crud/internal/infrastructure/retry"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body when a secret is set
+const SignatureHeader = "X-Signature"
+
+// retryBaseDelay is the backoff after the first failed delivery; it doubles after each failure
+const retryBaseDelay = 500 * time.Millisecond
+
+// Config configures webhook delivery
+type Config struct {
+	URL         string
+	Secret      string        // Signs bodies when set
+	Timeout     time.Duration // Per delivery attempt
+	MaxAttempts int
+	QueueSize   int
+}
+
+// Publisher POSTs events to a webhook from a background worker, so a slow or failing
+// endpoint never holds up the caller. Events that arrive while the queue is full are
+// dropped and logged.
+type Publisher struct {
+	cfg    Config
+	client *http.Client
+	queue  chan event.Event
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewPublisher starts the delivery worker
+func NewPublisher(cfg Config) *Publisher {
+	p := &Publisher{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan event.Event, cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go p.worker()
+	return p
+}
+
+// Publish queues the event for delivery and returns without waiting for it
+func (p *Publisher) Publish(ctx context.Context, e event.Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return fmt.Errorf("webhook publisher is stopped")
+	}
+
+	select {
+	case p.queue <- e:
+	default:
+		log.Printf("Webhook queue full, dropping %s event for user %d", e.Type, e.UserID)
+	}
+	return nil
+}
+
+// Stop stops accepting events and waits for queued ones to be delivered or ctx to be done
+func (p *Publisher) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Publisher) worker() {
+	defer close(p.done)
+	for e := range p.queue {
+		if err := p.deliver(context.Background(), e); err != nil {
+			log.Printf("Webhook delivery of %s event for user %d failed after %d attempts: %v",
+				e.Type, e.UserID, p.cfg.MaxAttempts, err)
+		}
+	}
+}
+
+// deliver POSTs the event, retrying with backoff until it is accepted or attempts run out
+func (p *Publisher) deliver(ctx context.Context, e event.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return retry.Do(ctx, p.cfg.MaxAttempts, retryBaseDelay, func(ctx context.Context) error {
+		return p.post(ctx, e.Type, body)
+	})
+}
+
+// post makes one delivery attempt; any non-2xx response is a failure
+func (p *Publisher) post(ctx context.Context, eventType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", eventType)
+	if p.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(p.cfg.Secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as sent in X-Signature
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
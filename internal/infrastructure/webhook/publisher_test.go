@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"user-crud/internal/application/event"
+)
+
+// delivery is one request received by a test webhook
+type delivery struct {
+	body      []byte
+	signature string
+	eventType string
+}
+
+// receiver starts a webhook that answers the first failures attempts with 500 and
+// hands every request it receives to the returned channel
+func receiver(t *testing.T, failures int32) (*httptest.Server, <-chan delivery) {
+	t.Helper()
+	received := make(chan delivery, 16)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{body: body, signature: r.Header.Get(SignatureHeader), eventType: r.Header.Get("X-Event-Type")}
+		if attempts.Add(1) <= failures {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func newPublisher(t *testing.T, cfg Config) *Publisher {
+	t.Helper()
+	p := NewPublisher(cfg)
+	t.Cleanup(func() { p.Stop(context.Background()) })
+	return p
+}
+
+func next(t *testing.T, received <-chan delivery) delivery {
+	t.Helper()
+	select {
+	case d := <-received:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+		return delivery{}
+	}
+}
+
+func TestDeliveryIsSigned(t *testing.T) {
+	const secret = "webhook-secret"
+	server, received := receiver(t, 0)
+	p := newPublisher(t, Config{URL: server.URL, Secret: secret, Timeout: time.Second, MaxAttempts: 1, QueueSize: 4})
+
+	if err := p.Publish(context.Background(), event.New(event.UserUpdated, 7)); err != nil {
+		t.Fatal(err)
+	}
+	d := next(t, received)
+
+	if !hmac.Equal([]byte(d.signature), []byte(Sign(secret, d.body))) {
+		t.Errorf("signature %q does not match the body", d.signature)
+	}
+	if d.signature == Sign("another-secret", d.body) {
+		t.Error("signature does not depend on the secret")
+	}
+	var got event.Event
+	if err := json.Unmarshal(d.body, &got); err != nil {
+		t.Fatalf("body %q is not an event: %v", d.body, err)
+	}
+	if got.Type != event.UserUpdated || got.UserID != 7 || d.eventType != event.UserUpdated {
+		t.Errorf("delivered %+v with X-Event-Type %q, want a user.updated event for user 7", got, d.eventType)
+	}
+}
+
+func TestDeliveryWithoutSecretIsUnsigned(t *testing.T) {
+	server, received := receiver(t, 0)
+	p := newPublisher(t, Config{URL: server.URL, Timeout: time.Second, MaxAttempts: 1, QueueSize: 4})
+
+	p.Publish(context.Background(), event.New(event.UserCreated, 1))
+
+	if d := next(t, received); d.signature != "" {
+		t.Errorf("unsigned delivery carries %s %q", SignatureHeader, d.signature)
+	}
+}
+
+func TestFailedDeliveryIsRetried(t *testing.T) {
+	server, received := receiver(t, 1)
+	p := newPublisher(t, Config{URL: server.URL, Secret: "s", Timeout: time.Second, MaxAttempts: 2, QueueSize: 4})
+
+	p.Publish(context.Background(), event.New(event.UserDeleted, 3))
+
+	first, second := next(t, received), next(t, received)
+	if string(first.body) != string(second.body) {
+		t.Errorf("retry sent %q, want the original body %q", second.body, first.body)
+	}
+}
+
+func TestPublishDoesNotWaitForASlowWebhook(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	p := newPublisher(t, Config{URL: server.URL, Timeout: 5 * time.Second, MaxAttempts: 1, QueueSize: 1})
+	t.Cleanup(func() { close(release) })
+
+	start := time.Now()
+	for i := range 10 {
+		if err := p.Publish(context.Background(), event.New(event.UserUpdated, int64(i))); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("publishing to a stalled webhook took %v", elapsed)
+	}
+}
@@ -0,0 +1,8 @@
+-- migrate:optional
+-- Trigram indexes for fuzzy (typo tolerant) search. pg_trgm may need privileges
+-- the app user lacks; without it search falls back to substring matching.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_users_name_trgm ON users USING GIN (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_users_email_trgm ON users USING GIN (email gin_trgm_ops);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Optional avatar/profile picture URL (metadata only, no upload)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(2048);
\ No newline at end of file
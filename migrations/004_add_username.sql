@@ -0,0 +1,4 @@
+-- Optional username, unique and stored lowercase
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(30);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
\ No newline at end of file
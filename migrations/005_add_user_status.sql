@@ -0,0 +1,2 @@
+-- Account status: active or suspended
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
\ No newline at end of file
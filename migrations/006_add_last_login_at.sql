@@ -0,0 +1,4 @@
+-- Last successful login, used to find dormant accounts
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_last_login_at ON users(last_login_at);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- When the password was last changed or reset
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_changed_at TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Indexes created by the original inline schema, so fresh and existing databases match
+CREATE INDEX IF NOT EXISTS idx_users_name ON users(name);
+
+CREATE INDEX IF NOT EXISTS idx_users_age ON users(age);
\ No newline at end of file
@@ -0,0 +1,10 @@
+-- Password hashes replaced by each password change, for auditing and reuse prevention
+CREATE TABLE IF NOT EXISTS password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    source_ip VARCHAR(45),
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_history_user_changed_at ON password_history(user_id, changed_at DESC);
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Duplicate accounts merged into a surviving user; a row per merged duplicate
+CREATE TABLE IF NOT EXISTS user_merges (
+    duplicate_id BIGINT PRIMARY KEY,
+    primary_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    merged_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_merges_primary_id ON user_merges(primary_id);
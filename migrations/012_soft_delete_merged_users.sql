@@ -0,0 +1,5 @@
+-- Merged duplicates are soft-deleted so their password history and merge record are kept
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+-- The merge record is an audit trail and must outlive both accounts
+ALTER TABLE user_merges DROP CONSTRAINT IF EXISTS user_merges_primary_id_fkey;
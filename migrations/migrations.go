@@ -0,0 +1,9 @@
+// Package migrations embeds the numbered SQL migration files applied at startup.
+package migrations
+
+import "embed"
+
+// FS holds every NNN_description.sql file in this directory
+//
+//go:embed *.sql
+var FS embed.FS